@@ -1,53 +1,300 @@
 package adapters
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"github.com/bigmeech/tradingbot/clients"
 	"github.com/bigmeech/tradingbot/pkg/types"
+	"io"
+	"net/url"
+	"time"
 )
 
-// RequestFormatter formats requests for the REST API using orderType and side.
-type RequestFormatter func(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64) (string, string, interface{}, error)
+// RequestFormatter formats requests for the REST API using orderType and side, returning the endpoint,
+// HTTP method and body. The body is sent form encoded if it is a url.Values and as JSON otherwise.
+// The client order ID should be passed to the exchange so the order can be matched up later.
+type RequestFormatter func(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64, clientOrderID string) (string, string, interface{}, error)
+
+// CancelRequestFormatter formats a request to cancel an order by its exchange order ID.
+type CancelRequestFormatter func(tradingPair, orderID string) (string, string, interface{}, error)
+
+// AmendRequestFormatter formats a request to replace an open order with a new amount and price.
+// The original order is looked up from the open orders first, since some exchanges need its side and type.
+type AmendRequestFormatter func(original *types.Order, amount, price float64, clientOrderID string) (string, string, interface{}, error)
+
+// OpenOrdersRequestFormatter formats a request to list open orders for a trading pair.
+type OpenOrdersRequestFormatter func(tradingPair string) (string, string, interface{}, error)
+
+// QueryOrderRequestFormatter formats a request to look up an order by its exchange order ID.
+type QueryOrderRequestFormatter func(tradingPair, orderID string) (string, string, interface{}, error)
+
+// OrderParser parses an exchange's order response body into an Order. Fields the exchange does not
+// report are filled in from the request by RestExecutor.
+type OrderParser func(body []byte) (*types.Order, error)
+
+// OrdersParser parses an exchange response listing several orders.
+type OrdersParser func(body []byte) ([]*types.Order, error)
+
+// ExchangeAPI bundles the exchange-specific request formatters and response parsers used by RestExecutor.
+// Only FormatOrder and ParseOrder are required; operations without a formatter return an error.
+// Amending also requires FormatOpenOrders and ParseOpenOrders to look up the original order.
+type ExchangeAPI struct {
+	FormatOrder      RequestFormatter
+	ParseOrder       OrderParser
+	FormatCancel     CancelRequestFormatter
+	ParseCancel      OrderParser
+	FormatAmend      AmendRequestFormatter
+	ParseAmend       OrderParser
+	FormatOpenOrders OpenOrdersRequestFormatter
+	ParseOpenOrders  OrdersParser
+	FormatQueryOrder QueryOrderRequestFormatter
+	ParseQueryOrder  OrderParser
+}
 
 type RestExecutor struct {
-	restClient    *clients.RestClient
-	formatRequest RequestFormatter
+	restClient *clients.RestClient
+	api        ExchangeAPI
 }
 
-// NewRestExecutor initializes a RestExecutor with a REST client and a request formatter.
-func NewRestExecutor(restClient *clients.RestClient, formatRequest RequestFormatter) *RestExecutor {
+// NewRestExecutor initializes a RestExecutor with a REST client and the exchange's request formatters and parsers.
+func NewRestExecutor(restClient *clients.RestClient, api ExchangeAPI) *RestExecutor {
 	return &RestExecutor{
-		restClient:    restClient,
-		formatRequest: formatRequest,
+		restClient: restClient,
+		api:        api,
 	}
 }
 
-// ExecuteOrder prepares and sends a request to the exchange's REST API to place an order.
-func (re *RestExecutor) ExecuteOrder(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64) error {
+// ExecuteOrder prepares and sends a request to the exchange's REST API to place an order
+// and returns the order parsed from the exchange's response.
+func (re *RestExecutor) ExecuteOrder(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64) (*types.Order, error) {
+	request := &types.Order{
+		ClientOrderID: types.NewClientOrderID(),
+		TradingPair:   tradingPair,
+		Type:          orderType,
+		Side:          side,
+		Price:         price,
+		Quantity:      amount,
+		CreatedAt:     time.Now().UnixMilli(),
+	}
+
 	// Format the request using orderType and side
-	endpoint, method, body, err := re.formatRequest(orderType, side, tradingPair, amount, price)
+	endpoint, method, body, err := re.api.FormatOrder(orderType, side, tradingPair, amount, price, request.ClientOrderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format request: %w", err)
+	}
+	return re.sendOrderRequest(method, endpoint, body, request, re.api.ParseOrder)
+}
+
+// CancelOrder cancels an open order by its exchange order ID and returns the cancelled order.
+func (re *RestExecutor) CancelOrder(tradingPair, orderID string) (*types.Order, error) {
+	if re.api.FormatCancel == nil || re.api.ParseCancel == nil {
+		return nil, fmt.Errorf("order cancellation is not supported by this exchange")
+	}
+
+	endpoint, method, body, err := re.api.FormatCancel(tradingPair, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format cancel request: %w", err)
+	}
+	request := &types.Order{
+		ExchangeOrderID: orderID,
+		TradingPair:     tradingPair,
+		Status:          types.OrderStatusCancelled,
+		UpdatedAt:       time.Now().UnixMilli(),
+	}
+	return re.sendOrderRequest(method, endpoint, body, request, re.api.ParseCancel)
+}
+
+// AmendOrder replaces an open order with a new amount and price and returns the replacement order.
+// Exchanges implement this as cancel/replace, so the returned order usually has a new exchange order ID.
+func (re *RestExecutor) AmendOrder(tradingPair, orderID string, amount, price float64) (*types.Order, error) {
+	if re.api.FormatAmend == nil || re.api.ParseAmend == nil {
+		return nil, fmt.Errorf("order amendment is not supported by this exchange")
+	}
+
+	openOrders, err := re.GetOpenOrders(tradingPair)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up order %s: %w", orderID, err)
+	}
+	var original *types.Order
+	for _, order := range openOrders {
+		if order.ExchangeOrderID == orderID {
+			original = order
+			break
+		}
+	}
+	if original == nil {
+		return nil, fmt.Errorf("order %s is not open", orderID)
+	}
+
+	request := &types.Order{
+		ClientOrderID: types.NewClientOrderID(),
+		TradingPair:   tradingPair,
+		Type:          original.Type,
+		Side:          original.Side,
+		Price:         price,
+		Quantity:      amount,
+		CreatedAt:     time.Now().UnixMilli(),
+	}
+	endpoint, method, body, err := re.api.FormatAmend(original, amount, price, request.ClientOrderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format amend request: %w", err)
+	}
+	return re.sendOrderRequest(method, endpoint, body, request, re.api.ParseAmend)
+}
+
+// GetOpenOrders lists the open orders for a trading pair.
+func (re *RestExecutor) GetOpenOrders(tradingPair string) ([]*types.Order, error) {
+	if re.api.FormatOpenOrders == nil || re.api.ParseOpenOrders == nil {
+		return nil, fmt.Errorf("open order queries are not supported by this exchange")
+	}
+
+	endpoint, method, body, err := re.api.FormatOpenOrders(tradingPair)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format open orders request: %w", err)
+	}
+	respBody, statusCode, err := re.send(method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode < 200 || statusCode >= 300 {
+		return nil, fmt.Errorf("open orders request failed with status %d: %s", statusCode, respBody)
+	}
+
+	orders, err := re.api.ParseOpenOrders(respBody)
 	if err != nil {
-		return fmt.Errorf("failed to format request: %w", err)
+		return nil, fmt.Errorf("failed to parse open orders response: %w", err)
+	}
+	for _, order := range orders {
+		if order.TradingPair == "" {
+			order.TradingPair = tradingPair
+		}
+		if order.Status == "" {
+			order.Status = types.OrderStatusNew
+		}
+	}
+	return orders, nil
+}
+
+// GetOrder looks up the current state of an order by its exchange order ID.
+func (re *RestExecutor) GetOrder(tradingPair, orderID string) (*types.Order, error) {
+	if re.api.FormatQueryOrder == nil || re.api.ParseQueryOrder == nil {
+		return nil, fmt.Errorf("order queries are not supported by this exchange")
+	}
+
+	endpoint, method, body, err := re.api.FormatQueryOrder(tradingPair, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format order query: %w", err)
+	}
+	respBody, statusCode, err := re.send(method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode < 200 || statusCode >= 300 {
+		return nil, fmt.Errorf("order query failed with status %d: %s", statusCode, respBody)
+	}
+
+	order, err := re.api.ParseQueryOrder(respBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse order response: %w", err)
+	}
+	return mergeOrder(&types.Order{ExchangeOrderID: orderID, TradingPair: tradingPair}, order), nil
+}
+
+// sendOrderRequest sends an order request and parses the response, merging in the requested fields.
+func (re *RestExecutor) sendOrderRequest(method, endpoint string, body interface{}, request *types.Order, parse OrderParser) (*types.Order, error) {
+	respBody, statusCode, err := re.send(method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode < 200 || statusCode >= 300 {
+		if request.Status != "" {
+			// A failed cancellation leaves the original order as it was, so there is no order to return
+			return nil, fmt.Errorf("request failed with status %d: %s", statusCode, respBody)
+		}
+		request.Status = types.OrderStatusRejected
+		request.UpdatedAt = time.Now().UnixMilli()
+		return request, fmt.Errorf("order rejected with status %d: %s", statusCode, respBody)
 	}
 
-	// Marshal body to JSON if it's a POST request
-	var jsonBody []byte
-	if method == "POST" {
-		jsonBody, err = json.Marshal(body)
+	// Parsers may return a rejected order alongside the error when the exchange reports one
+	order, err := parse(respBody)
+	if err != nil {
+		if order != nil {
+			return mergeOrder(request, order), err
+		}
+		return nil, fmt.Errorf("failed to parse order response: %w", err)
+	}
+	return mergeOrder(request, order), nil
+}
+
+// send makes the request using the REST client and returns the response body and status code.
+// Bodies given as url.Values are form encoded; any other body is encoded as JSON.
+func (re *RestExecutor) send(method, endpoint string, body interface{}) ([]byte, int, error) {
+	var payload []byte
+	contentType := clients.ContentTypeJSON
+	switch b := body.(type) {
+	case nil:
+	case url.Values:
+		payload = []byte(b.Encode())
+		contentType = clients.ContentTypeForm
+	default:
+		var err error
+		payload, err = json.Marshal(body)
 		if err != nil {
-			return fmt.Errorf("failed to marshal request body: %w", err)
+			return nil, 0, fmt.Errorf("failed to marshal request body: %w", err)
 		}
 	}
 
-	// Make the request using the REST client
-	resp, err := re.restClient.DoRequest(method, endpoint, bytes.NewBuffer(jsonBody))
+	resp, err := re.restClient.Do(method, endpoint, contentType, payload)
 	if err != nil {
-		return fmt.Errorf("failed to execute order: %w", err)
+		return nil, 0, fmt.Errorf("failed to execute request: %w", err)
 	}
+	defer resp.Body.Close()
 
-	// Handle response here if needed, e.g., check status code or parse response body
-	fmt.Printf("Order executed successfully: %v\n", resp)
-	return nil
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read response: %w", err)
+	}
+	return respBody, resp.StatusCode, nil
+}
+
+// mergeOrder fills in anything the exchange's response left out with the values that were requested.
+func mergeOrder(request, order *types.Order) *types.Order {
+	if order.ClientOrderID == "" {
+		order.ClientOrderID = request.ClientOrderID
+	}
+	if order.ExchangeOrderID == "" {
+		order.ExchangeOrderID = request.ExchangeOrderID
+	}
+	if order.TradingPair == "" {
+		order.TradingPair = request.TradingPair
+	}
+	if order.Type == "" {
+		order.Type = request.Type
+	}
+	if order.Side == "" {
+		order.Side = request.Side
+	}
+	if order.Price == 0 {
+		order.Price = request.Price
+	}
+	if order.Quantity == 0 {
+		order.Quantity = request.Quantity
+	}
+	if order.Status == "" {
+		order.Status = request.Status
+	}
+	if order.Status == "" {
+		order.Status = types.OrderStatusNew
+	}
+	if order.CreatedAt == 0 {
+		order.CreatedAt = request.CreatedAt
+	}
+	if order.UpdatedAt == 0 {
+		order.UpdatedAt = request.UpdatedAt
+	}
+	if order.UpdatedAt == 0 {
+		order.UpdatedAt = order.CreatedAt
+	}
+	return order
 }
@@ -0,0 +1,151 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/bigmeech/tradingbot/clients"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func testFormatter(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64, clientOrderID string) (string, string, interface{}, error) {
+	return "/order", "POST", map[string]interface{}{
+		"symbol":   tradingPair,
+		"side":     string(side),
+		"type":     string(orderType),
+		"quantity": amount,
+		"clientId": clientOrderID,
+	}, nil
+}
+
+func testParser(body []byte) (*types.Order, error) {
+	var resp struct {
+		ID       string  `json:"id"`
+		ClientID string  `json:"clientId"`
+		Status   string  `json:"status"`
+		Filled   float64 `json:"filled"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	return &types.Order{
+		ExchangeOrderID: resp.ID,
+		ClientOrderID:   resp.ClientID,
+		Status:          types.OrderStatus(resp.Status),
+		FilledQuantity:  resp.Filled,
+	}, nil
+}
+
+func TestRestExecutor_ExecuteOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		fmt.Fprintf(w, `{"id":"42","clientId":%q,"status":"filled","filled":%v}`, body["clientId"], body["quantity"])
+	}))
+	defer server.Close()
+
+	executor := NewRestExecutor(clients.NewRestClient(server.URL, "key"), ExchangeAPI{FormatOrder: testFormatter, ParseOrder: testParser})
+	order, err := executor.ExecuteOrder(types.OrderTypeMarket, types.OrderSideBuy, "BTC/USDT", 2, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if order.ExchangeOrderID != "42" || order.Status != types.OrderStatusFilled || order.FilledQuantity != 2 {
+		t.Errorf("Unexpected order %+v", order)
+	}
+	if order.ClientOrderID == "" {
+		t.Error("Expected the generated client order ID to round trip")
+	}
+	// Fields missing from the response are filled in from the request
+	if order.TradingPair != "BTC/USDT" || order.Side != types.OrderSideBuy || order.Quantity != 2 || order.CreatedAt == 0 {
+		t.Errorf("Expected request fields to be merged, got %+v", order)
+	}
+}
+
+func TestRestExecutor_RejectedOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"msg":"insufficient balance"}`, http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	executor := NewRestExecutor(clients.NewRestClient(server.URL, "key"), ExchangeAPI{FormatOrder: testFormatter, ParseOrder: testParser})
+	order, err := executor.ExecuteOrder(types.OrderTypeMarket, types.OrderSideSell, "BTC/USDT", 1, 0)
+	if err == nil {
+		t.Fatal("Expected an error for a rejected order")
+	}
+	if order == nil || order.Status != types.OrderStatusRejected {
+		t.Errorf("Expected a rejected order, got %+v", order)
+	}
+}
+
+func TestRestExecutor_OrderManagement(t *testing.T) {
+	var amendedFrom string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/open":
+			fmt.Fprint(w, `[{"id":"7","status":"new"}]`)
+		case r.Method == "DELETE" && r.URL.Path == "/order":
+			fmt.Fprintf(w, `{"id":%q,"status":"cancelled"}`, r.URL.Query().Get("id"))
+		case r.Method == "PUT" && r.URL.Path == "/order":
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			amendedFrom, _ = body["original"].(string)
+			fmt.Fprint(w, `{"id":"8","status":"new"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	api := ExchangeAPI{
+		FormatOrder: testFormatter,
+		ParseOrder:  testParser,
+		FormatCancel: func(tradingPair, orderID string) (string, string, interface{}, error) {
+			return "/order?id=" + orderID, "DELETE", nil, nil
+		},
+		ParseCancel: testParser,
+		FormatAmend: func(original *types.Order, amount, price float64, clientOrderID string) (string, string, interface{}, error) {
+			return "/order", "PUT", map[string]interface{}{"original": original.ExchangeOrderID, "quantity": amount, "price": price}, nil
+		},
+		ParseAmend: testParser,
+		FormatOpenOrders: func(tradingPair string) (string, string, interface{}, error) {
+			return "/open", "GET", nil, nil
+		},
+		ParseOpenOrders: func(body []byte) ([]*types.Order, error) {
+			order, err := testParser(body[1 : len(body)-1])
+			return []*types.Order{order}, err
+		},
+	}
+	executor := NewRestExecutor(clients.NewRestClient(server.URL, "key"), api)
+
+	openOrders, err := executor.GetOpenOrders("BTC/USDT")
+	if err != nil || len(openOrders) != 1 || openOrders[0].TradingPair != "BTC/USDT" {
+		t.Fatalf("Unexpected open orders %+v, err %v", openOrders, err)
+	}
+
+	cancelled, err := executor.CancelOrder("BTC/USDT", "7")
+	if err != nil || cancelled.Status != types.OrderStatusCancelled || cancelled.ExchangeOrderID != "7" {
+		t.Errorf("Unexpected cancel result %+v, err %v", cancelled, err)
+	}
+
+	amended, err := executor.AmendOrder("BTC/USDT", "7", 2, 101)
+	if err != nil || amended.ExchangeOrderID != "8" || amended.Quantity != 2 || amended.Price != 101 {
+		t.Errorf("Unexpected amend result %+v, err %v", amended, err)
+	}
+	if amendedFrom != "7" {
+		t.Errorf("Expected the amend request to reference order 7, got %q", amendedFrom)
+	}
+
+	if _, err := executor.AmendOrder("BTC/USDT", "99", 1, 100); err == nil {
+		t.Error("Expected an error when amending an order that is not open")
+	}
+
+	unsupported := NewRestExecutor(clients.NewRestClient(server.URL, "key"), ExchangeAPI{FormatOrder: testFormatter, ParseOrder: testParser})
+	if _, err := unsupported.CancelOrder("BTC/USDT", "7"); err == nil {
+		t.Error("Expected an error when cancellation is not supported")
+	}
+}
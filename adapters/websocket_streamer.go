@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"github.com/bigmeech/tradingbot/clients"
 	"github.com/bigmeech/tradingbot/pkg/types"
+	"sync"
+	"time"
 )
 
 // MessageParser is a function type for parsing WebSocket messages into MarketData and trading pairs.
@@ -14,7 +16,9 @@ type MessageParser func(message []byte) (*types.MarketData, string, error)
 type WebSocketStreamer struct {
 	Client        *clients.WebSocketClient
 	messageParser MessageParser
+	mu            sync.Mutex // Guards activeStreams and clientStreams
 	activeStreams int
+	clientStreams int // Streams started on Client, by StartStreaming and StreamMessages
 	maxStreams    int
 }
 
@@ -29,19 +33,28 @@ func NewWebSocketStreamer(client *clients.WebSocketClient, messageParser Message
 }
 
 // StartStreaming begins streaming data to the handler function, using the provided message parser.
+// Ticks are stamped with the time their message was received unless the parser set one.
 func (ws *WebSocketStreamer) StartStreaming(handler types.MarketDataHandler) error {
+	ws.mu.Lock()
 	if ws.activeStreams >= ws.maxStreams {
+		ws.mu.Unlock()
 		return fmt.Errorf("maximum stream limit reached")
 	}
-
 	ws.activeStreams++
-	return ws.Client.StartStreaming(func(url string, data []byte) {
+	ws.mu.Unlock()
+
+	err := ws.startClientStream(func(url string, data []byte) {
+		receivedAt := time.Now().UnixMilli()
+
 		// Parse the message using the provided message parser
 		marketData, tradingPair, err := ws.messageParser(data)
 		if err != nil {
 			// Log or handle parsing errors if necessary
 			return
 		}
+		if marketData.ReceiveTime == 0 {
+			marketData.ReceiveTime = receivedAt
+		}
 
 		// Call the handler with the parsed market data and trading pair
 		handler(&types.TickContext{
@@ -50,13 +63,49 @@ func (ws *WebSocketStreamer) StartStreaming(handler types.MarketDataHandler) err
 			MarketData:  marketData,
 		})
 	})
+	if err != nil {
+		ws.mu.Lock()
+		ws.activeStreams--
+		ws.mu.Unlock()
+	}
+	return err
 }
 
-// StopStreaming decreases the active stream count and stops the client if no streams are active.
-func (ws *WebSocketStreamer) StopStreaming() error {
-	ws.activeStreams--
-	if ws.activeStreams == 0 {
-		return ws.Client.StopStreaming()
+// StreamMessages passes every raw message read on the connection to handler, e.g. for order book depth,
+// which the message parser does not turn into ticks. The handler is released by StopStreaming together
+// with the market data streams.
+func (ws *WebSocketStreamer) StreamMessages(handler func(url string, data []byte)) error {
+	return ws.startClientStream(handler)
+}
+
+// startClientStream registers a handler with the client and counts it, so StopStreaming can release it.
+func (ws *WebSocketStreamer) startClientStream(handler func(url string, data []byte)) error {
+	if err := ws.Client.StartStreaming(handler); err != nil {
+		return err
 	}
+	ws.mu.Lock()
+	ws.clientStreams++
+	ws.mu.Unlock()
 	return nil
 }
+
+// StopStreaming decreases the active stream count and, once no market data streams are active, releases
+// every stream started on the client, which stops the client.
+func (ws *WebSocketStreamer) StopStreaming() error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.activeStreams > 0 {
+		ws.activeStreams--
+	}
+	if ws.activeStreams > 0 {
+		return nil
+	}
+	var err error
+	for ; ws.clientStreams > 0; ws.clientStreams-- {
+		if stopErr := ws.Client.StopStreaming(); stopErr != nil {
+			err = stopErr
+		}
+	}
+	return err
+}
@@ -3,32 +3,103 @@ package clients
 import (
 	"bytes"
 	"net/http"
+	"net/url"
 )
 
+// Content types of request bodies sent through RestClient.Do.
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeForm = "application/x-www-form-urlencoded"
+)
+
+// Request is an outgoing REST request, passed to the RequestSigner before it is sent.
+type Request struct {
+	Method string
+	URL    *url.URL
+	Header http.Header
+	Body   []byte
+}
+
+// RequestSigner authenticates requests for an exchange's scheme. Signers may set headers and rewrite
+// the query string or body, e.g. to add a timestamp, nonce or signature.
+type RequestSigner interface {
+	Sign(req *Request) error
+}
+
+// RequestSignerFunc adapts a function to a RequestSigner.
+type RequestSignerFunc func(req *Request) error
+
+// Sign calls f(req).
+func (f RequestSignerFunc) Sign(req *Request) error {
+	return f(req)
+}
+
+// BearerTokenSigner sends the API key as a bearer token.
+type BearerTokenSigner struct {
+	APIKey string
+}
+
+// Sign sets the Authorization header.
+func (s BearerTokenSigner) Sign(req *Request) error {
+	req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	return nil
+}
+
 type RestClient struct {
 	baseURL    string
 	httpClient *http.Client
-	apiKey     string
+	signer     RequestSigner
 }
 
+// NewRestClient initializes a RestClient that authenticates with the API key as a bearer token.
 func NewRestClient(baseURL, apiKey string) *RestClient {
+	return NewSignedRestClient(baseURL, BearerTokenSigner{APIKey: apiKey})
+}
+
+// NewSignedRestClient initializes a RestClient that authenticates every request with signer.
+func NewSignedRestClient(baseURL string, signer RequestSigner) *RestClient {
 	return &RestClient{
 		baseURL:    baseURL,
 		httpClient: &http.Client{},
-		apiKey:     apiKey,
+		signer:     signer,
 	}
 }
 
-// DoRequest sends a request to the specified endpoint with the given method and body.
+// DoRequest sends a request to the specified endpoint with the given method and JSON body.
 func (rc *RestClient) DoRequest(method, endpoint string, body *bytes.Buffer) (*http.Response, error) {
-	url := rc.baseURL + endpoint
-	req, err := http.NewRequest(method, url, body)
+	var payload []byte
+	if body != nil {
+		payload = body.Bytes()
+	}
+	return rc.Do(method, endpoint, ContentTypeJSON, payload)
+}
+
+// Do signs and sends a request to the specified endpoint, which may include a query string.
+// The Content-Type header is set to contentType when there is a body.
+func (rc *RestClient) Do(method, endpoint, contentType string, body []byte) (*http.Response, error) {
+	requestURL, err := url.Parse(rc.baseURL + endpoint)
 	if err != nil {
 		return nil, err
 	}
+	req := &Request{
+		Method: method,
+		URL:    requestURL,
+		Header: make(http.Header),
+		Body:   body,
+	}
+	if len(body) > 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if rc.signer != nil {
+		if err := rc.signer.Sign(req); err != nil {
+			return nil, err
+		}
+	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+rc.apiKey)
-
-	return rc.httpClient.Do(req)
+	httpReq, err := http.NewRequest(req.Method, req.URL.String(), bytes.NewReader(req.Body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header = req.Header
+	return rc.httpClient.Do(httpReq)
 }
@@ -1,6 +1,7 @@
 package clients
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -9,6 +10,12 @@ import (
 	"github.com/gorilla/websocket"
 )
 
+// ErrNotConnected is returned by SendJSON before the connection has been opened.
+var ErrNotConnected = errors.New("WebSocket is not connected")
+
+// reconnectDelay is how long the read loop waits before retrying a failed reconnection.
+const reconnectDelay = time.Second
+
 // WebSocketClient manages a WebSocket connection with automatic reconnection, ping/pong handling, and rate limiting.
 type WebSocketClient struct {
 	conn               *websocket.Conn
@@ -17,15 +24,20 @@ type WebSocketClient struct {
 	pingInterval       time.Duration
 	pongTimeout        time.Duration
 	rateLimit          int
-	messageTicker      *time.Ticker
+	messageTicker      *time.Ticker // Paces outgoing messages to rateLimit per second
 	maxStreams         int
 	activeStreams      int
-	mu                 sync.Mutex
+	handlers           []func(string, []byte) // Receive every message read by the single read loop
+	onConnect          func(send func(v interface{}) error) error
+	mu                 sync.Mutex // Guards conn, activeStreams, handlers and onConnect
+	writeMu            sync.Mutex // Serializes writes, since a connection supports one concurrent writer
 	stopCh             chan struct{}
+	stopOnce           sync.Once
 	reconnectCh        chan struct{}
 }
 
 // NewWebSocketClient initializes a new WebSocketClient with configuration options.
+// The connection is opened by Connect or by the first call to StartStreaming.
 func NewWebSocketClient(url string, connectionLifetime, pingInterval, pongTimeout time.Duration, rateLimit, maxStreams int) *WebSocketClient {
 	client := &WebSocketClient{
 		url:                url,
@@ -42,18 +54,43 @@ func NewWebSocketClient(url string, connectionLifetime, pingInterval, pongTimeou
 	return client
 }
 
+// OnConnect sets a function run after every connection and reconnection, before any message is read,
+// e.g. to send subscription requests. Messages sent through send are paced by the rate limit.
+func (c *WebSocketClient) OnConnect(fn func(send func(v interface{}) error) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onConnect = fn
+}
+
 // Connect opens a WebSocket connection and sets up the ping/pong handlers.
 func (c *WebSocketClient) Connect() error {
-	var err error
-	c.conn, _, err = websocket.DefaultDialer.Dial(c.url, nil)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.connect()
+}
+
+// connect dials the server and runs the OnConnect function; c.mu must be held.
+func (c *WebSocketClient) connect() error {
+	conn, _, err := websocket.DefaultDialer.Dial(c.url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to connect to WebSocket: %w", err)
 	}
 
-	// Set up WebSocket ping/pong handlers
-	c.conn.SetPongHandler(func(string) error {
-		return c.conn.WriteControl(websocket.PongMessage, []byte{}, time.Now().Add(c.pongTimeout))
+	// Expect a pong or another message within a ping interval plus the pong timeout
+	readTimeout := c.pingInterval + c.pongTimeout
+	conn.SetReadDeadline(time.Now().Add(readTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(readTimeout))
 	})
+
+	if c.onConnect != nil {
+		send := func(v interface{}) error { return c.writeJSON(conn, v) }
+		if err := c.onConnect(send); err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to initialize WebSocket connection: %w", err)
+		}
+	}
+	c.conn = conn
 	return nil
 }
 
@@ -61,12 +98,40 @@ func (c *WebSocketClient) GetConnectionUrl() string {
 	return c.url
 }
 
+// SendJSON writes a JSON message on the open connection, waiting for the rate limit.
+func (c *WebSocketClient) SendJSON(v interface{}) error {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+
+	if conn == nil {
+		return ErrNotConnected
+	}
+	return c.writeJSON(conn, v)
+}
+
+// writeJSON writes a JSON message on conn once the rate limit allows another message.
+func (c *WebSocketClient) writeJSON(conn *websocket.Conn, v interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	<-c.messageTicker.C
+	return conn.WriteJSON(v)
+}
+
 // manageConnection handles automatic reconnection, ping/pong, and connection lifetime limits.
 func (c *WebSocketClient) manageConnection() {
 	go c.pingHandler()
 	go c.reconnectionHandler()
 }
 
+// currentConn returns the open connection, or nil if there is none.
+func (c *WebSocketClient) currentConn() *websocket.Conn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn
+}
+
 // pingHandler sends periodic pings to keep the connection alive.
 func (c *WebSocketClient) pingHandler() {
 	ticker := time.NewTicker(c.pingInterval)
@@ -75,12 +140,14 @@ func (c *WebSocketClient) pingHandler() {
 	for {
 		select {
 		case <-ticker.C:
-			c.mu.Lock()
-			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+			conn := c.currentConn()
+			if conn == nil {
+				continue
+			}
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.pongTimeout)); err != nil {
 				log.Println("Ping failed, reconnecting:", err)
-				c.reconnect()
+				c.reconnect(conn)
 			}
-			c.mu.Unlock()
 		case <-c.stopCh:
 			return
 		}
@@ -95,28 +162,45 @@ func (c *WebSocketClient) reconnectionHandler() {
 	for {
 		select {
 		case <-ticker.C:
-			log.Println("Reconnecting WebSocket due to connection lifetime expiry")
-			c.reconnect()
+			if conn := c.currentConn(); conn != nil {
+				log.Println("Reconnecting WebSocket due to connection lifetime expiry")
+				c.reconnect(conn)
+			}
 		case <-c.stopCh:
 			return
 		}
 	}
 }
 
-// reconnect safely reconnects the WebSocket connection.
-func (c *WebSocketClient) reconnect() {
+// reconnect safely replaces the connection old, unless it has already been replaced or the client stopped.
+func (c *WebSocketClient) reconnect(old *websocket.Conn) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	_ = c.conn.Close()
-	if err := c.Connect(); err != nil {
+	if c.conn != old || c.stopped() {
+		return nil
+	}
+	_ = old.Close()
+	if err := c.connect(); err != nil {
 		log.Println("Failed to reconnect:", err)
-	} else {
-		log.Println("Reconnected successfully")
+		return err
+	}
+	log.Println("Reconnected successfully")
+	return nil
+}
+
+// stopped reports whether StopStreaming has closed the client.
+func (c *WebSocketClient) stopped() bool {
+	select {
+	case <-c.stopCh:
+		return true
+	default:
+		return false
 	}
 }
 
 // StartStreaming manages stream subscriptions, enforcing a limit on the maximum number of streams.
+// It connects first if Connect has not been called; every message read is then passed to each handler.
 func (c *WebSocketClient) StartStreaming(handler func(string, []byte)) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -124,25 +208,51 @@ func (c *WebSocketClient) StartStreaming(handler func(string, []byte)) error {
 	if c.activeStreams >= c.maxStreams {
 		return fmt.Errorf("max streams limit reached")
 	}
+	if c.conn == nil {
+		if err := c.connect(); err != nil {
+			return err
+		}
+	}
 
 	c.activeStreams++
-	go func() {
-		for {
-			select {
-			case <-c.stopCh:
+	c.handlers = append(c.handlers, handler)
+	if len(c.handlers) == 1 {
+		// A connection supports a single concurrent reader, shared by every stream
+		go c.readMessages()
+	}
+	return nil
+}
+
+// readMessages reads messages until the client stops, reconnecting after read errors.
+func (c *WebSocketClient) readMessages() {
+	for {
+		conn := c.currentConn()
+		if conn == nil || c.stopped() {
+			return
+		}
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if c.stopped() {
 				return
-			case <-c.messageTicker.C:
-				_, message, err := c.conn.ReadMessage()
-				if err != nil {
-					log.Println("Error reading WebSocket message:", err)
-					c.reconnect()
-					continue
+			}
+			log.Println("Error reading WebSocket message:", err)
+			if err := c.reconnect(conn); err != nil {
+				select {
+				case <-c.stopCh:
+					return
+				case <-time.After(reconnectDelay):
 				}
-				handler(c.url, message)
 			}
+			continue
 		}
-	}()
-	return nil
+
+		c.mu.Lock()
+		handlers := c.handlers
+		c.mu.Unlock()
+		for _, handler := range handlers {
+			handler(c.url, message)
+		}
+	}
 }
 
 // StopStreaming decreases the active stream count and closes the connection if no streams remain.
@@ -153,8 +263,10 @@ func (c *WebSocketClient) StopStreaming() error {
 	if c.activeStreams > 0 {
 		c.activeStreams--
 		if c.activeStreams == 0 {
-			close(c.stopCh)
-			return c.conn.Close()
+			c.stopOnce.Do(func() { close(c.stopCh) })
+			if c.conn != nil {
+				return c.conn.Close()
+			}
 		}
 	}
 	return nil
@@ -0,0 +1,230 @@
+// Command tradingbot runs, validates and backtests trading bots described by a config file.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/bigmeech/tradingbot/internal/backtest"
+	"github.com/bigmeech/tradingbot/pkg/tradingbot"
+	"github.com/rs/zerolog"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Exit codes returned by the CLI.
+const (
+	exitOK      = 0
+	exitFailure = 1 // The command ran but failed, e.g. an invalid config or a connector error
+	exitUsage   = 2 // The command line itself was invalid
+)
+
+const usage = `Usage: tradingbot <command> [flags]
+
+Commands:
+  run       Run a bot from a config file until interrupted
+  backtest  Replay recorded ticks through the strategies in a config file
+  validate  Check a config file without running it
+  list      List the available connectors, indicators and strategies
+
+Run "tradingbot <command> -h" for the flags of a command.
+`
+
+// errUsage marks errors caused by invalid command line arguments.
+var errUsage = errors.New("usage error")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command in args and returns the process exit code.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	var err error
+	switch args[0] {
+	case "run":
+		err = runCommand(ctx, args[1:], stderr)
+	case "backtest":
+		err = backtestCommand(args[1:], stdout, stderr)
+	case "validate":
+		err = validateCommand(args[1:], stdout, stderr)
+	case "list":
+		err = listCommand(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errUsage):
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	default:
+		fmt.Fprintf(stderr, "tradingbot %s: %v\n", args[0], err)
+		return exitFailure
+	}
+}
+
+// newFlagSet creates the flag set of a command, reporting parse errors as usage errors.
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	return flags
+}
+
+// parseFlags parses a command's flags and checks that the config flag was given.
+func parseFlags(flags *flag.FlagSet, args []string, configPath *string) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("%w: unexpected arguments %v", errUsage, flags.Args())
+	}
+	if configPath != nil && *configPath == "" {
+		return fmt.Errorf("%w: -config is required", errUsage)
+	}
+	return nil
+}
+
+// newLogger writes human readable logs to stderr.
+func newLogger(stderr io.Writer) zerolog.Logger {
+	return zerolog.New(zerolog.ConsoleWriter{Out: stderr}).With().Timestamp().Logger()
+}
+
+// runCommand runs a bot from a config file until ctx is cancelled by SIGINT or SIGTERM, then stops it gracefully.
+func runCommand(ctx context.Context, args []string, stderr io.Writer) error {
+	flags := newFlagSet("run", stderr)
+	configPath := flags.String("config", "", "path to the YAML or JSON bot config (required)")
+	debug := flags.Bool("debug", false, "log every tick")
+	shutdownTimeout := flags.Duration("shutdown-timeout", 30*time.Second, "how long to wait for in-flight ticks, order cancellation and store flushes on shutdown")
+	if err := parseFlags(flags, args, configPath); err != nil {
+		return err
+	}
+
+	cfg, err := tradingbot.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	cfg.Debug = cfg.Debug || *debug
+
+	logger := newLogger(stderr)
+	bot, err := tradingbot.NewBotFromConfig(cfg, logger)
+	if err != nil {
+		return err
+	}
+	if err := bot.Start(ctx); err != nil {
+		return err
+	}
+	logger.Info().Int("Connectors", len(cfg.Connectors)).Msg("Bot started; press Ctrl+C to stop")
+
+	<-ctx.Done()
+	stopCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	return bot.Close(stopCtx)
+}
+
+// backtestCommand replays a recorded tick file through the indicators and strategies in a config file.
+func backtestCommand(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("backtest", stderr)
+	configPath := flags.String("config", "", "path to the YAML or JSON bot config (required)")
+	dataPath := flags.String("data", "", "recorded ticks as CSV (time,trading_pair,price,volume) or JSON lines (required)")
+	market := flags.String("market", "", "configured market to replay the ticks as (defaults to the first connector)")
+	capital := flags.Float64("capital", 10000, "starting cash of the simulated broker")
+	fee := flags.Float64("fee", 0.001, "fee rate charged on each fill, e.g. 0.001 for 0.1%")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	if err := parseFlags(flags, args, configPath); err != nil {
+		return err
+	}
+	if *dataPath == "" {
+		return fmt.Errorf("%w: -data is required", errUsage)
+	}
+
+	cfg, err := tradingbot.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	marketName := *market
+	if marketName == "" {
+		marketName = cfg.Connectors[0].Name
+	}
+
+	ticks, err := backtest.LoadTicks(*dataPath)
+	if err != nil {
+		return err
+	}
+	bot, err := tradingbot.NewBacktestBotFromConfig(cfg, zerolog.New(stderr).Level(zerolog.WarnLevel))
+	if err != nil {
+		return err
+	}
+	replay := backtest.NewReplayConnector("backtest://"+*dataPath, ticks, backtest.NewSimulatedBroker(*capital, *fee))
+	report, err := bot.Backtest(marketName, replay)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			*backtest.Report
+			MaxDrawdown float64
+		}{report, report.MaxDrawdown()})
+	}
+	fmt.Fprintf(stdout, "Ticks replayed:   %d\n", len(ticks))
+	fmt.Fprintf(stdout, "Trades:           %d\n", len(report.Trades))
+	fmt.Fprintf(stdout, "Initial capital:  %.2f\n", report.InitialCapital)
+	fmt.Fprintf(stdout, "Final equity:     %.2f\n", report.FinalEquity)
+	fmt.Fprintf(stdout, "PnL:              %.2f\n", report.PnL)
+	fmt.Fprintf(stdout, "Max drawdown:     %.2f%%\n", report.MaxDrawdown()*100)
+	return nil
+}
+
+// validateCommand checks a config file and reports every problem found.
+func validateCommand(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("validate", stderr)
+	configPath := flags.String("config", "", "path to the YAML or JSON bot config (required)")
+	if err := parseFlags(flags, args, configPath); err != nil {
+		return err
+	}
+
+	if _, err := tradingbot.LoadConfig(*configPath); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "%s is valid\n", *configPath)
+	return nil
+}
+
+// listCommand prints the connectors, indicators and strategies that configs can refer to.
+func listCommand(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("list", stderr)
+	if err := parseFlags(flags, args, nil); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Connectors: %s\n", strings.Join(tradingbot.ConnectorTypes(), ", "))
+	fmt.Fprintf(stdout, "Indicators: %s\n", strings.Join(tradingbot.IndicatorTypes(), ", "))
+	fmt.Fprintf(stdout, "Strategies: %s\n", strings.Join(tradingbot.StrategyTypes(), ", "))
+	return nil
+}
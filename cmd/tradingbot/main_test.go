@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testConfig = `connectors:
+  - name: Local
+indicators:
+  - market: Local
+    pair: BTC/USDT
+    type: SMA
+    period: 2
+  - market: Local
+    pair: BTC/USDT
+    type: SMA
+    period: 4
+strategies:
+  - market: Local
+    pair: BTC/USDT
+    type: MA_Crossover
+`
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func runCLI(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_Usage(t *testing.T) {
+	if code, _, _ := runCLI(); code != exitUsage {
+		t.Errorf("expected exit code %d without a command, got %d", exitUsage, code)
+	}
+	if code, _, stderr := runCLI("deploy"); code != exitUsage || !strings.Contains(stderr, "unknown command") {
+		t.Errorf("expected unknown command usage error, got %d: %s", code, stderr)
+	}
+	if code, _, stderr := runCLI("validate"); code != exitUsage || !strings.Contains(stderr, "-config is required") {
+		t.Errorf("expected missing -config usage error, got %d: %s", code, stderr)
+	}
+	if code, _, _ := runCLI("list", "-bogus"); code != exitUsage {
+		t.Errorf("expected exit code %d for an unknown flag, got %d", exitUsage, code)
+	}
+}
+
+func TestRun_Validate(t *testing.T) {
+	valid := writeFile(t, "bot.yaml", testConfig)
+	if code, stdout, stderr := runCLI("validate", "-config", valid); code != exitOK || !strings.Contains(stdout, "is valid") {
+		t.Errorf("expected valid config, got %d: %s%s", code, stdout, stderr)
+	}
+
+	invalid := writeFile(t, "bot.yaml", strings.Replace(testConfig, "MA_Crossover", "Martingale", 1))
+	code, _, stderr := runCLI("validate", "-config", invalid)
+	if code != exitFailure || !strings.Contains(stderr, `unknown strategy type "Martingale"`) {
+		t.Errorf("expected invalid config to fail with exit code %d, got %d: %s", exitFailure, code, stderr)
+	}
+}
+
+func TestRun_List(t *testing.T) {
+	code, stdout, _ := runCLI("list")
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, got %d", exitOK, code)
+	}
+	for _, want := range []string{"Connectors:", "Binance", "Indicators:", "BollingerBands", "Strategies:", "MA_Crossover"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected list output to contain %q, got:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestRun_Backtest(t *testing.T) {
+	config := writeFile(t, "bot.yaml", testConfig)
+
+	var data strings.Builder
+	data.WriteString("time,trading_pair,price,volume\n")
+	prices := []float64{100, 99, 98, 97, 99, 102, 105, 108, 104, 100, 96, 92}
+	for i, price := range prices {
+		fmt.Fprintf(&data, "%d,BTC/USDT,%g,1\n", 1700000000000+int64(i)*1000, price)
+	}
+	ticks := writeFile(t, "ticks.csv", data.String())
+
+	code, stdout, stderr := runCLI("backtest", "-config", config, "-data", ticks, "-capital", "1000", "-fee", "0")
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+	for _, want := range []string{"Ticks replayed:   12", "Initial capital:  1000.00", "Final equity:", "Max drawdown:"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected report to contain %q, got:\n%s", want, stdout)
+		}
+	}
+
+	code, stdout, stderr = runCLI("backtest", "-config", config, "-data", ticks, "-json")
+	if code != exitOK || !strings.Contains(stdout, `"MaxDrawdown"`) {
+		t.Errorf("expected JSON report, got %d: %s%s", code, stdout, stderr)
+	}
+
+	if code, _, _ := runCLI("backtest", "-config", config, "-data", filepath.Join(t.TempDir(), "missing.csv")); code != exitFailure {
+		t.Errorf("expected exit code %d for a missing data file, got %d", exitFailure, code)
+	}
+}
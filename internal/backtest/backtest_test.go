@@ -0,0 +1,201 @@
+package backtest
+
+import (
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestReadCSVTicks(t *testing.T) {
+	input := "time,trading_pair,price,volume\n1000,BTC/USDT,100.5,2\n2000,BTC/USDT,101,1.5\n"
+	ticks, err := ReadCSVTicks(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(ticks) != 2 {
+		t.Fatalf("Expected 2 ticks, got %v", len(ticks))
+	}
+	expected := Tick{TradingPair: "BTC/USDT", Price: 101, Volume: 1.5, Time: 2000}
+	if ticks[1] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, ticks[1])
+	}
+
+	if _, err := ReadCSVTicks(strings.NewReader("time,trading_pair,price,volume\nabc,BTC/USDT,1,1\n")); err == nil {
+		t.Error("Expected error for invalid time")
+	}
+}
+
+func TestReadJSONTicks(t *testing.T) {
+	input := `{"time":1000,"trading_pair":"ETH/USDT","price":2000,"volume":3}
+
+{"time":2000,"trading_pair":"ETH/USDT","price":2010,"volume":1,"bid":2009.5,"ask":2010.5,"side":"sell","trade_id":"42"}`
+	ticks, err := ReadJSONTicks(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(ticks) != 2 || ticks[0].Price != 2000 || ticks[1].Time != 2000 {
+		t.Errorf("Unexpected ticks %+v", ticks)
+	}
+	data := ticks[1].MarketData()
+	if data.Spread() != 1 || data.Side != types.OrderSideSell || data.TradeID != "42" || data.ReceiveTime != 2000 {
+		t.Errorf("Expected the optional details to be replayed, got %+v", data)
+	}
+}
+
+// priceStore is a large store that only returns prices.
+type priceStore struct {
+	ticks []types.MarketData
+}
+
+func (s *priceStore) RecordTick(market, tradingPair string, data *types.MarketData) error {
+	s.ticks = append(s.ticks, *data)
+	return nil
+}
+
+func (s *priceStore) QueryPriceHistory(market, tradingPair string, period int) []float64 {
+	prices := []float64{}
+	for _, data := range s.ticks[max(len(s.ticks)-period, 0):] {
+		prices = append(prices, data.Price)
+	}
+	return prices
+}
+
+// tickStore is a large store that also returns whole ticks, by count and by time.
+type tickStore struct {
+	priceStore
+}
+
+func (s *tickStore) QueryTicks(market, tradingPair string, before int64, count int) []types.MarketData {
+	return append([]types.MarketData(nil), s.ticks[max(len(s.ticks)-count, 0):]...)
+}
+
+func (s *tickStore) QueryTicksRange(market, tradingPair string, start, end int64) ([]types.MarketData, error) {
+	ticks := []types.MarketData{}
+	for _, data := range s.ticks {
+		if data.Time >= start && (end == 0 || data.Time < end) {
+			ticks = append(ticks, data)
+		}
+	}
+	return ticks, nil
+}
+
+func TestTicksFromStore(t *testing.T) {
+	recorded := []types.MarketData{
+		{Price: 100, Volume: 1, Time: 1000},
+		{Price: 101, Volume: 2, Time: 2000, Bid: 100.5, Ask: 101.5, Side: types.OrderSideBuy, TradeID: "7"},
+		{Price: 102, Volume: 3, Time: 3000},
+	}
+	prices, ticks := &priceStore{ticks: recorded}, &tickStore{priceStore{ticks: recorded}}
+
+	fromPrices := TicksFromStore(prices, "Binance", "BTC/USDT", 2)
+	if len(fromPrices) != 2 || fromPrices[0] != (Tick{TradingPair: "BTC/USDT", Price: 101}) {
+		t.Errorf("Expected only the prices from a store without whole ticks, got %+v", fromPrices)
+	}
+
+	fromTicks := TicksFromStore(ticks, "Binance", "BTC/USDT", 2)
+	expected := Tick{TradingPair: "BTC/USDT", Price: 101, Volume: 2, Time: 2000, Bid: 100.5, Ask: 101.5, Side: types.OrderSideBuy, TradeID: "7"}
+	if len(fromTicks) != 2 || fromTicks[0] != expected || fromTicks[1].Time != 3000 {
+		t.Errorf("Expected the whole ticks, got %+v", fromTicks)
+	}
+
+	inRange, err := TicksFromStoreRange(ticks, "Binance", "BTC/USDT", 1000, 3000)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(inRange) != 2 || inRange[0].Time != 1000 || inRange[1] != expected {
+		t.Errorf("Expected the ticks from 1000 up to 3000, got %+v", inRange)
+	}
+	if _, err := TicksFromStoreRange(prices, "Binance", "BTC/USDT", 1000, 3000); err == nil {
+		t.Error("Expected an error for a store that cannot query ticks by time")
+	}
+}
+
+func TestSimulatedBroker_ExecuteOrder(t *testing.T) {
+	broker := NewSimulatedBroker(1000, 0.01)
+
+	// Orders are rejected before any price has been replayed
+	if _, err := broker.ExecuteOrder(types.OrderTypeMarket, types.OrderSideBuy, "BTC/USDT", 1, 0); err == nil {
+		t.Fatal("Expected error without a replayed price")
+	}
+
+	broker.UpdatePrice("BTC/USDT", 100, 1)
+	if _, err := broker.ExecuteOrder(types.OrderTypeMarket, types.OrderSideBuy, "BTC/USDT", 2, 0); err != nil {
+		t.Fatalf("Expected market buy to fill, got %v", err)
+	}
+	broker.RecordEquity(1)
+
+	// A buy limit below the market is not marketable
+	if _, err := broker.ExecuteOrder(types.OrderTypeLimit, types.OrderSideBuy, "BTC/USDT", 1, 90); err == nil {
+		t.Error("Expected non-marketable limit order to be rejected")
+	}
+	if _, err := broker.ExecuteOrder(types.OrderTypeStopLoss, types.OrderSideSell, "BTC/USDT", 1, 90); err == nil {
+		t.Error("Expected unsupported order type to be rejected")
+	}
+
+	broker.UpdatePrice("BTC/USDT", 110, 2)
+	if _, err := broker.ExecuteOrder(types.OrderTypeLimit, types.OrderSideSell, "BTC/USDT", 2, 105); err != nil {
+		t.Fatalf("Expected marketable limit sell to fill, got %v", err)
+	}
+	broker.RecordEquity(2)
+
+	report := broker.Report()
+	if len(report.Trades) != 2 {
+		t.Fatalf("Expected 2 trades, got %v", len(report.Trades))
+	}
+	if report.Trades[1].Price != 105 {
+		t.Errorf("Expected limit sell to fill at 105, got %v", report.Trades[1].Price)
+	}
+
+	// Bought 2 @ 100 and sold 2 @ 105 with 1% fees on both fills
+	expectedPnL := 2*(105-100) - (200 * 0.01) - (210 * 0.01)
+	if math.Abs(report.PnL-expectedPnL) > 1e-9 {
+		t.Errorf("Expected PnL %v, got %v", expectedPnL, report.PnL)
+	}
+	if len(report.EquityCurve) != 2 {
+		t.Errorf("Expected 2 equity points, got %v", len(report.EquityCurve))
+	}
+}
+
+func TestReplayConnector_StreamMarketData(t *testing.T) {
+	ticks := []Tick{
+		{TradingPair: "BTC/USDT", Price: 100, Time: 1},
+		{TradingPair: "BTC/USDT", Price: 120, Time: 2},
+		{TradingPair: "BTC/USDT", Price: 90, Time: 3},
+	}
+	replay := NewReplayConnector("backtest", ticks, NewSimulatedBroker(1000, 0))
+
+	var seen []float64
+	err := replay.StreamMarketData(func(ctx *types.TickContext) {
+		seen = append(seen, ctx.MarketData.Price)
+		if ctx.MarketData.Time == 1 {
+			if _, err := ctx.ExecuteOrder(types.OrderTypeMarket, types.OrderSideBuy, 1, 0); err != nil {
+				t.Errorf("Expected buy to fill, got %v", err)
+			}
+		}
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	select {
+	case <-replay.Done():
+	default:
+		t.Fatal("Expected Done to be closed after the replay")
+	}
+
+	if len(seen) != len(ticks) {
+		t.Fatalf("Expected %v ticks, got %v", len(ticks), len(seen))
+	}
+	report := replay.Report()
+	if report.PnL != -10 {
+		t.Errorf("Expected PnL -10, got %v", report.PnL)
+	}
+	if report.Positions["BTC/USDT"] != 1 {
+		t.Errorf("Expected open position of 1, got %v", report.Positions["BTC/USDT"])
+	}
+	// Equity peaked at 1020 and fell to 990
+	if drawdown := report.MaxDrawdown(); math.Abs(drawdown-30.0/1020.0) > 1e-9 {
+		t.Errorf("Expected max drawdown %v, got %v", 30.0/1020.0, drawdown)
+	}
+}
@@ -0,0 +1,177 @@
+package backtest
+
+import (
+	"fmt"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"sort"
+	"sync"
+)
+
+// Trade records a simulated fill produced during a backtest.
+type Trade struct {
+	Time        int64
+	TradingPair string
+	Side        types.OrderSide
+	OrderType   types.OrderType
+	Amount      float64
+	Price       float64
+	Fee         float64
+}
+
+// EquityPoint is the account value at the time of a replayed tick.
+type EquityPoint struct {
+	Time   int64
+	Equity float64
+}
+
+// SimulatedBroker fills orders against replayed prices and keeps track of cash and positions.
+type SimulatedBroker struct {
+	mu             sync.Mutex
+	initialCapital float64
+	cash           float64
+	feeRate        float64            // Fee charged on the notional of each fill, e.g. 0.001 for 0.1%
+	positions      map[string]float64 // Base asset quantity held per trading pair
+	lastPrices     map[string]float64 // Most recent replayed price per trading pair
+	lastTime       int64
+	trades         []Trade
+	equityCurve    []EquityPoint
+	nextOrderID    int64
+}
+
+// NewSimulatedBroker initializes a SimulatedBroker with starting capital and a proportional fee rate.
+func NewSimulatedBroker(initialCapital, feeRate float64) *SimulatedBroker {
+	return &SimulatedBroker{
+		initialCapital: initialCapital,
+		cash:           initialCapital,
+		feeRate:        feeRate,
+		positions:      make(map[string]float64),
+		lastPrices:     make(map[string]float64),
+	}
+}
+
+// UpdatePrice sets the current replayed price for a trading pair.
+func (b *SimulatedBroker) UpdatePrice(tradingPair string, price float64, timestamp int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastPrices[tradingPair] = price
+	b.lastTime = timestamp
+}
+
+// ExecuteOrder fills market orders at the current replayed price and limit orders at their limit price
+// when it is marketable. Other order types are not supported in backtests and are rejected.
+func (b *SimulatedBroker) ExecuteOrder(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64) (*types.Order, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if amount <= 0 {
+		return nil, fmt.Errorf("invalid order amount %v", amount)
+	}
+	lastPrice, ok := b.lastPrices[tradingPair]
+	if !ok {
+		return nil, fmt.Errorf("no replayed price for %s", tradingPair)
+	}
+
+	b.nextOrderID++
+	order := &types.Order{
+		ClientOrderID:   types.NewClientOrderID(),
+		ExchangeOrderID: fmt.Sprintf("backtest-%d", b.nextOrderID),
+		TradingPair:     tradingPair,
+		Type:            orderType,
+		Side:            side,
+		Status:          types.OrderStatusNew,
+		Price:           price,
+		Quantity:        amount,
+		CreatedAt:       b.lastTime,
+		UpdatedAt:       b.lastTime,
+	}
+
+	fillPrice := lastPrice
+	switch orderType {
+	case types.OrderTypeMarket:
+	case types.OrderTypeLimit:
+		if (side == types.OrderSideBuy && price < lastPrice) || (side == types.OrderSideSell && price > lastPrice) {
+			order.Status = types.OrderStatusRejected
+			return order, fmt.Errorf("limit %s order at %v is not marketable at %v", side, price, lastPrice)
+		}
+		fillPrice = price
+	default:
+		order.Status = types.OrderStatusRejected
+		return order, fmt.Errorf("order type %s is not supported in backtests", orderType)
+	}
+
+	notional := amount * fillPrice
+	fee := notional * b.feeRate
+	switch side {
+	case types.OrderSideBuy:
+		b.cash -= notional + fee
+		b.positions[tradingPair] += amount
+	case types.OrderSideSell:
+		b.cash += notional - fee
+		b.positions[tradingPair] -= amount
+	default:
+		order.Status = types.OrderStatusRejected
+		return order, fmt.Errorf("invalid order side %q", side)
+	}
+
+	b.trades = append(b.trades, Trade{
+		Time:        b.lastTime,
+		TradingPair: tradingPair,
+		Side:        side,
+		OrderType:   orderType,
+		Amount:      amount,
+		Price:       fillPrice,
+		Fee:         fee,
+	})
+	order.AddFill(types.Fill{
+		TradeID:  order.ExchangeOrderID,
+		Price:    fillPrice,
+		Quantity: amount,
+		Fee:      fee,
+		Time:     b.lastTime,
+	})
+	return order, nil
+}
+
+// RecordEquity appends the current account value to the equity curve.
+func (b *SimulatedBroker) RecordEquity(timestamp int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.equityCurve = append(b.equityCurve, EquityPoint{Time: timestamp, Equity: b.equity()})
+}
+
+// equity marks all open positions to their last replayed price. Callers must hold b.mu.
+func (b *SimulatedBroker) equity() float64 {
+	// Sum in a fixed order so results are reproducible bit for bit
+	pairs := make([]string, 0, len(b.positions))
+	for pair := range b.positions {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+
+	equity := b.cash
+	for _, pair := range pairs {
+		equity += b.positions[pair] * b.lastPrices[pair]
+	}
+	return equity
+}
+
+// Report summarizes the trades, equity curve and profit and loss so far.
+func (b *SimulatedBroker) Report() *Report {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	finalEquity := b.equity()
+	positions := make(map[string]float64, len(b.positions))
+	for pair, quantity := range b.positions {
+		positions[pair] = quantity
+	}
+
+	return &Report{
+		Trades:         append([]Trade(nil), b.trades...),
+		EquityCurve:    append([]EquityPoint(nil), b.equityCurve...),
+		Positions:      positions,
+		InitialCapital: b.initialCapital,
+		FinalEquity:    finalEquity,
+		PnL:            finalEquity - b.initialCapital,
+	}
+}
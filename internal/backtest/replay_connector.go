@@ -0,0 +1,99 @@
+package backtest
+
+import (
+	"fmt"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"sync"
+)
+
+// ReplayConnector implements types.Connector by replaying recorded ticks in order and
+// filling orders through a SimulatedBroker instead of an exchange.
+type ReplayConnector struct {
+	identifier string
+	ticks      []Tick
+	broker     *SimulatedBroker
+	done       chan struct{}
+	stopCh     chan struct{}
+	stopOnce   sync.Once
+}
+
+// NewReplayConnector initializes a ReplayConnector for the given ticks and broker.
+// The identifier is reported as the MarketUrl of every replayed tick.
+func NewReplayConnector(identifier string, ticks []Tick, broker *SimulatedBroker) *ReplayConnector {
+	return &ReplayConnector{
+		identifier: identifier,
+		ticks:      ticks,
+		broker:     broker,
+		done:       make(chan struct{}),
+		stopCh:     make(chan struct{}),
+	}
+}
+
+// StreamMarketData replays every tick synchronously, so strategies see the data in a deterministic order.
+// Done is closed once the replay finishes or is stopped.
+func (rc *ReplayConnector) StreamMarketData(handler func(ctx *types.TickContext)) error {
+	defer close(rc.done)
+
+	for _, tick := range rc.ticks {
+		select {
+		case <-rc.stopCh:
+			return nil
+		default:
+		}
+
+		rc.broker.UpdatePrice(tick.TradingPair, tick.Price, tick.Time)
+		tradingPair := tick.TradingPair
+		handler(&types.TickContext{
+			MarketUrl:   rc.identifier,
+			TradingPair: tradingPair,
+			MarketData:  tick.MarketData(),
+			Indicators:  make(map[string]float64),
+			ExecuteOrder: func(orderType types.OrderType, side types.OrderSide, amount, price float64) (*types.Order, error) {
+				return rc.ExecuteOrder(orderType, side, tradingPair, amount, price)
+			},
+		})
+		rc.broker.RecordEquity(tick.Time)
+	}
+	return nil
+}
+
+// StopStreaming ends the replay before the next tick.
+func (rc *ReplayConnector) StopStreaming() error {
+	rc.stopOnce.Do(func() { close(rc.stopCh) })
+	return nil
+}
+
+// ExecuteOrder fills the order against the replayed prices.
+func (rc *ReplayConnector) ExecuteOrder(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64) (*types.Order, error) {
+	return rc.broker.ExecuteOrder(orderType, side, tradingPair, amount, price)
+}
+
+// CancelOrder always fails, since backtest orders are filled or rejected immediately and never rest.
+func (rc *ReplayConnector) CancelOrder(tradingPair, orderID string) (*types.Order, error) {
+	return nil, fmt.Errorf("order %s is not open; backtest orders never rest", orderID)
+}
+
+// AmendOrder always fails, since backtest orders are filled or rejected immediately and never rest.
+func (rc *ReplayConnector) AmendOrder(tradingPair, orderID string, amount, price float64) (*types.Order, error) {
+	return nil, fmt.Errorf("order %s is not open; backtest orders never rest", orderID)
+}
+
+// GetOpenOrders returns no orders, since backtest orders never rest.
+func (rc *ReplayConnector) GetOpenOrders(tradingPair string) ([]*types.Order, error) {
+	return nil, nil
+}
+
+// GetIdentifier returns the identifier the connector was created with.
+func (rc *ReplayConnector) GetIdentifier() string {
+	return rc.identifier
+}
+
+// Done returns a channel that is closed when the replay has finished.
+func (rc *ReplayConnector) Done() <-chan struct{} {
+	return rc.done
+}
+
+// Report returns the broker's report for the ticks replayed so far.
+func (rc *ReplayConnector) Report() *Report {
+	return rc.broker.Report()
+}
@@ -0,0 +1,27 @@
+package backtest
+
+// Report is the result of a backtest run.
+type Report struct {
+	Trades         []Trade            // Every simulated fill in execution order
+	EquityCurve    []EquityPoint      // Account value after each replayed tick
+	Positions      map[string]float64 // Open base asset quantity per trading pair at the end of the run
+	InitialCapital float64
+	FinalEquity    float64 // Cash plus open positions marked to the last replayed price
+	PnL            float64 // FinalEquity minus InitialCapital
+}
+
+// MaxDrawdown returns the largest peak-to-trough decline of the equity curve as a fraction of the peak.
+func (r *Report) MaxDrawdown() float64 {
+	peak, maxDrawdown := 0.0, 0.0
+	for _, point := range r.EquityCurve {
+		if point.Equity > peak {
+			peak = point.Equity
+		}
+		if peak > 0 {
+			if drawdown := (peak - point.Equity) / peak; drawdown > maxDrawdown {
+				maxDrawdown = drawdown
+			}
+		}
+	}
+	return maxDrawdown
+}
@@ -0,0 +1,174 @@
+package backtest
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/bigmeech/tradingbot/pkg/models"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Tick is a single recorded market data point for a trading pair.
+type Tick struct {
+	TradingPair string  `json:"trading_pair"`
+	Price       float64 `json:"price"`
+	Volume      float64 `json:"volume"`
+	Time        int64   `json:"time"` // Unix milliseconds
+
+	// Optional details, read from JSON ticks only
+	Bid     float64         `json:"bid,omitempty"`
+	Ask     float64         `json:"ask,omitempty"`
+	Side    types.OrderSide `json:"side,omitempty"`
+	TradeID string          `json:"trade_id,omitempty"`
+}
+
+// MarketData converts the recorded tick into the MarketData handed to middleware. Replayed ticks are
+// received at the time they were recorded.
+func (t Tick) MarketData() *types.MarketData {
+	return &types.MarketData{
+		Price:       t.Price,
+		Volume:      t.Volume,
+		Time:        t.Time,
+		Bid:         t.Bid,
+		Ask:         t.Ask,
+		Side:        t.Side,
+		TradeID:     t.TradeID,
+		ReceiveTime: t.Time,
+	}
+}
+
+// LoadTicks reads recorded ticks from a CSV file (time,trading_pair,price,volume)
+// or a JSON lines file, chosen by the file extension. Ticks are returned in file order.
+func LoadTicks(path string) ([]Tick, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tick file: %w", err)
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ReadCSVTicks(file)
+	case ".json", ".jsonl", ".ndjson":
+		return ReadJSONTicks(file)
+	default:
+		return nil, fmt.Errorf("unsupported tick file format %q", filepath.Ext(path))
+	}
+}
+
+// ReadCSVTicks parses ticks from CSV with a header row of time,trading_pair,price,volume.
+func ReadCSVTicks(r io.Reader) ([]Tick, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+
+	if _, err := reader.Read(); err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	var ticks []Tick
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV record: %w", err)
+		}
+
+		timestamp, err := strconv.ParseInt(record[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q: %w", record[0], err)
+		}
+		price, err := strconv.ParseFloat(record[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid price %q: %w", record[2], err)
+		}
+		volume, err := strconv.ParseFloat(record[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid volume %q: %w", record[3], err)
+		}
+
+		ticks = append(ticks, Tick{
+			TradingPair: record[1],
+			Price:       price,
+			Volume:      volume,
+			Time:        timestamp,
+		})
+	}
+	return ticks, nil
+}
+
+// ReadJSONTicks parses ticks from JSON lines, one Tick object per line.
+func ReadJSONTicks(r io.Reader) ([]Tick, error) {
+	var ticks []Tick
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var tick Tick
+		if err := json.Unmarshal([]byte(text), &tick); err != nil {
+			return nil, fmt.Errorf("invalid tick on line %d: %w", line, err)
+		}
+		ticks = append(ticks, tick)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read JSON ticks: %w", err)
+	}
+	return ticks, nil
+}
+
+// TicksFromStore builds ticks for a market and trading pair from the most recent `period` ticks in a large store.
+// Stores implementing models.TickHistory return whole ticks; from other stores only prices are known, so volume
+// and time are left empty.
+func TicksFromStore(largeStore models.LargeStore, market, tradingPair string, period int) []Tick {
+	if history, ok := largeStore.(models.TickHistory); ok {
+		return ticksFromMarketData(tradingPair, history.QueryTicks(market, tradingPair, 0, period))
+	}
+	prices := largeStore.QueryPriceHistory(market, tradingPair, period)
+	ticks := make([]Tick, len(prices))
+	for i, price := range prices {
+		ticks[i] = Tick{TradingPair: tradingPair, Price: price}
+	}
+	return ticks
+}
+
+// TicksFromStoreRange builds ticks for a market and trading pair from the ticks in a large store with a time
+// from the start up to but not including the end, in Unix milliseconds. The store must implement
+// models.TickRangeHistory.
+func TicksFromStoreRange(largeStore models.LargeStore, market, tradingPair string, start, end int64) ([]Tick, error) {
+	history, ok := largeStore.(models.TickRangeHistory)
+	if !ok {
+		return nil, fmt.Errorf("store %T cannot query ticks by time", largeStore)
+	}
+	data, err := history.QueryTicksRange(market, tradingPair, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ticks of %s %s: %w", market, tradingPair, err)
+	}
+	return ticksFromMarketData(tradingPair, data), nil
+}
+
+// ticksFromMarketData converts stored market data of a trading pair into ticks.
+func ticksFromMarketData(tradingPair string, data []types.MarketData) []Tick {
+	ticks := make([]Tick, len(data))
+	for i, d := range data {
+		ticks[i] = Tick{
+			TradingPair: tradingPair,
+			Price:       d.Price,
+			Volume:      d.Volume,
+			Time:        d.Time,
+			Bid:         d.Bid,
+			Ask:         d.Ask,
+			Side:        d.Side,
+			TradeID:     d.TradeID,
+		}
+	}
+	return ticks
+}
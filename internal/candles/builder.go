@@ -0,0 +1,126 @@
+package candles
+
+import (
+	"fmt"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"sync"
+	"time"
+)
+
+// Builder aggregates ticks into OHLCV candles per market, trading pair and interval.
+// A candle is closed by the first tick that falls into a later interval, so intervals
+// without any ticks produce no candle.
+type Builder struct {
+	mu        sync.Mutex
+	intervals map[string]map[string][]time.Duration      // Intervals to build per market and trading pair
+	open      map[string]map[time.Duration]*types.Candle // Candle being built per market/trading pair key and interval
+}
+
+// NewBuilder initializes an empty Builder; intervals are added with AddInterval.
+func NewBuilder() *Builder {
+	return &Builder{
+		intervals: make(map[string]map[string][]time.Duration),
+		open:      make(map[string]map[time.Duration]*types.Candle),
+	}
+}
+
+// ValidateInterval returns an error unless interval is at least a millisecond, the resolution of tick times.
+func ValidateInterval(interval time.Duration) error {
+	if interval < time.Millisecond {
+		return fmt.Errorf("candle interval must be at least 1ms, got %v", interval)
+	}
+	return nil
+}
+
+// AddInterval starts building candles of the given interval for a market and trading pair.
+// Adding an interval that is already built has no effect; intervals shorter than a millisecond are rejected.
+func (b *Builder) AddInterval(marketName, tradingPair string, interval time.Duration) error {
+	if err := ValidateInterval(interval); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.intervals[marketName] == nil {
+		b.intervals[marketName] = make(map[string][]time.Duration)
+	}
+	for _, existing := range b.intervals[marketName][tradingPair] {
+		if existing == interval {
+			return nil
+		}
+	}
+	b.intervals[marketName][tradingPair] = append(b.intervals[marketName][tradingPair], interval)
+	return nil
+}
+
+// Intervals returns the intervals built for a market and trading pair.
+func (b *Builder) Intervals(marketName, tradingPair string) []time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]time.Duration(nil), b.intervals[marketName][tradingPair]...)
+}
+
+// Add aggregates a tick into the open candles of its market and trading pair and returns the
+// candles it closed, in the order their intervals were added. Ticks older than the open candle
+// are folded into it rather than reopening a closed interval.
+func (b *Builder) Add(marketName, tradingPair string, data *types.MarketData) []types.Candle {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	intervals := b.intervals[marketName][tradingPair]
+	if len(intervals) == 0 {
+		return nil
+	}
+	timestamp := data.Time
+	if timestamp == 0 {
+		timestamp = time.Now().UnixMilli()
+	}
+
+	key := marketName + ":" + tradingPair
+	if b.open[key] == nil {
+		b.open[key] = make(map[time.Duration]*types.Candle)
+	}
+
+	var closed []types.Candle
+	for _, interval := range intervals {
+		candle := b.open[key][interval]
+		if candle != nil && timestamp >= candle.CloseTime {
+			closed = append(closed, *candle)
+			candle = nil
+		}
+		if candle == nil {
+			openTime := timestamp - timestamp%interval.Milliseconds()
+			candle = &types.Candle{
+				TradingPair: tradingPair,
+				Interval:    interval,
+				OpenTime:    openTime,
+				CloseTime:   openTime + interval.Milliseconds(),
+				Open:        data.Price,
+				High:        data.Price,
+				Low:         data.Price,
+			}
+			b.open[key][interval] = candle
+		}
+		if data.Price > candle.High {
+			candle.High = data.Price
+		}
+		if data.Price < candle.Low {
+			candle.Low = data.Price
+		}
+		candle.Close = data.Price
+		candle.Volume += data.Volume
+		candle.Trades++
+	}
+	return closed
+}
+
+// Current returns a copy of the candle still being built for a market, trading pair and interval.
+func (b *Builder) Current(marketName, tradingPair string, interval time.Duration) (types.Candle, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	candle, ok := b.open[marketName+":"+tradingPair][interval]
+	if !ok {
+		return types.Candle{}, false
+	}
+	return *candle, true
+}
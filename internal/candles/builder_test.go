@@ -0,0 +1,71 @@
+package candles
+
+import (
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"testing"
+	"time"
+)
+
+func TestBuilder_Add(t *testing.T) {
+	builder := NewBuilder()
+	builder.AddInterval("Binance", "BTC/USDT", time.Minute)
+	builder.AddInterval("Binance", "BTC/USDT", 5*time.Minute)
+	builder.AddInterval("Binance", "BTC/USDT", time.Minute)
+
+	ticks := []types.MarketData{
+		{Price: 100, Volume: 1, Time: 0*60000 + 1000},
+		{Price: 105, Volume: 2, Time: 0*60000 + 20000},
+		{Price: 95, Volume: 1, Time: 0*60000 + 40000},
+		{Price: 101, Volume: 3, Time: 0*60000 + 59999},
+		{Price: 102, Volume: 1, Time: 1*60000 + 5000}, // Closes the first 1m candle
+		{Price: 110, Volume: 1, Time: 5*60000 + 0},    // Closes the second 1m candle and the 5m candle
+	}
+
+	var closed []types.Candle
+	for i := range ticks {
+		closed = append(closed, builder.Add("Binance", "BTC/USDT", &ticks[i])...)
+	}
+	if len(closed) != 3 {
+		t.Fatalf("Expected 3 closed candles, got %+v", closed)
+	}
+
+	first := closed[0]
+	expected := types.Candle{TradingPair: "BTC/USDT", Interval: time.Minute, OpenTime: 0, CloseTime: 60000,
+		Open: 100, High: 105, Low: 95, Close: 101, Volume: 7, Trades: 4}
+	if first != expected {
+		t.Errorf("Expected %+v, got %+v", expected, first)
+	}
+	if closed[1].Interval != time.Minute || closed[1].OpenTime != 60000 || closed[1].Close != 102 {
+		t.Errorf("Unexpected second 1m candle %+v", closed[1])
+	}
+	if five := closed[2]; five.Interval != 5*time.Minute || five.High != 105 || five.Close != 102 || five.Trades != 5 {
+		t.Errorf("Unexpected 5m candle %+v", five)
+	}
+
+	current, ok := builder.Current("Binance", "BTC/USDT", time.Minute)
+	if !ok || current.OpenTime != 5*60000 || current.Open != 110 {
+		t.Errorf("Expected the open 1m candle to start at 110, got %+v", current)
+	}
+	if len(builder.Intervals("Binance", "BTC/USDT")) != 2 {
+		t.Errorf("Expected duplicate intervals to be ignored, got %v", builder.Intervals("Binance", "BTC/USDT"))
+	}
+	if candles := builder.Add("Kraken", "BTC/USDT", &ticks[0]); candles != nil {
+		t.Errorf("Expected no candles for a market without intervals, got %v", candles)
+	}
+}
+
+func TestBuilder_AddIntervalRejectsSubMillisecond(t *testing.T) {
+	builder := NewBuilder()
+	for _, interval := range []time.Duration{0, -time.Minute, 500 * time.Microsecond} {
+		if err := builder.AddInterval("Binance", "BTC/USDT", interval); err == nil {
+			t.Errorf("Expected interval %v to be rejected", interval)
+		}
+	}
+	if err := builder.AddInterval("Binance", "BTC/USDT", time.Millisecond); err != nil {
+		t.Errorf("Expected a 1ms interval to be accepted, got %v", err)
+	}
+	if intervals := builder.Intervals("Binance", "BTC/USDT"); len(intervals) != 1 {
+		t.Errorf("Expected only the 1ms interval to be built, got %v", intervals)
+	}
+	builder.Add("Binance", "BTC/USDT", &types.MarketData{Price: 100, Time: 1})
+}
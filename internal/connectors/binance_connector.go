@@ -2,49 +2,207 @@ package connectors
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/bigmeech/tradingbot/adapters"
 	"github.com/bigmeech/tradingbot/clients"
 	"github.com/bigmeech/tradingbot/pkg/types"
+	"io"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
 // BinanceConnector encapsulates Binance-specific streaming and order execution functionality.
 type BinanceConnector struct {
-	streamer *adapters.WebSocketStreamer
-	executor *adapters.RestExecutor
+	streamer   *adapters.WebSocketStreamer
+	executor   *adapters.RestExecutor
+	restClient *clients.RestClient
+
+	mu            sync.Mutex
+	subscriptions []string          // Stream names, e.g. "btcusdt@trade", sent on every (re)connection
+	pairs         map[string]string // Trading pair name per Binance symbol, e.g. "BTCUSDT" -> "BTC/USDT"
+	requestID     int64
+	depthHandler  func(update *types.OrderBookUpdate) // Handler passed to StreamDepth, which also receives resync snapshots
+}
+
+// BinanceStream is the type of a Binance market data stream.
+type BinanceStream string
+
+const (
+	// BinanceTradeStream streams every individual trade.
+	BinanceTradeStream BinanceStream = "trade"
+
+	// BinanceAggTradeStream streams trades aggregated by taker order and price.
+	BinanceAggTradeStream BinanceStream = "aggTrade"
+
+	// BinanceBookTickerStream streams changes to the best bid and ask. Ticks carry the mid price and no volume.
+	BinanceBookTickerStream BinanceStream = "bookTicker"
+
+	// BinanceDepthStream streams order book diffs every second for StreamDepth. It produces no ticks.
+	BinanceDepthStream BinanceStream = "depth"
+
+	// BinanceDepth100msStream streams order book diffs every 100 milliseconds for StreamDepth. It produces no ticks.
+	BinanceDepth100msStream BinanceStream = "depth@100ms"
+)
+
+// binanceDepthSnapshotLimit is the number of levels per side fetched when resyncing an order book.
+const binanceDepthSnapshotLimit = 1000
+
+// BinanceKlineStream returns the candlestick stream of an interval such as "1m" or "1h".
+// Only closed candles produce ticks, carrying the close price, the candle volume and the close time.
+func BinanceKlineStream(interval string) BinanceStream {
+	return BinanceStream("kline_" + interval)
 }
 
 // NewBinanceConnector initializes a BinanceConnector with Binance-specific WebSocket and REST clients.
-func NewBinanceConnector(wsURL, restURL, apiKey string) *BinanceConnector {
+// REST requests are signed with apiSecret, which is only needed for trading. wsURL is either a raw stream endpoint such as wss://stream.binance.com:9443/ws or a combined stream
+// endpoint ending in /stream; streams are chosen with Subscribe.
+func NewBinanceConnector(wsURL, restURL, apiKey, apiSecret string) *BinanceConnector {
 	// Set up a WebSocket client with Binance constraints
 	wsClient := clients.NewWebSocketClient(
 		wsURL,
 		24*time.Hour,   // Connection lifetime
 		3*time.Minute,  // Ping interval
 		10*time.Minute, // Pong timeout
-		10,             // Rate limit: 10 messages per second
-		200,            // Stream limit: 200 streams per connection
+		5,              // Rate limit: 5 incoming messages per second per connection
+		1024,           // Stream limit: 1024 streams per connection
 	)
 
-	// Initialize WebSocketStreamer with Binance-specific parser and stream limit
-	streamer := adapters.NewWebSocketStreamer(wsClient, binanceMessageParser, 200)
+	bc := &BinanceConnector{pairs: make(map[string]string)}
+
+	// Initialize WebSocketStreamer with Binance-specific parser and stream limit, resubscribing on every connection
+	bc.streamer = adapters.NewWebSocketStreamer(wsClient, bc.parseMessage, 1024)
+	wsClient.OnConnect(bc.resubscribe)
+
+	// Initialize RestExecutor with Binance-specific request formatter and a REST client signing requests
+	bc.restClient = clients.NewSignedRestClient(restURL, NewBinanceSigner(apiKey, apiSecret))
+	bc.executor = adapters.NewRestExecutor(bc.restClient, adapters.ExchangeAPI{
+		FormatOrder:      binanceRequestFormatter,
+		ParseOrder:       binanceOrderParser,
+		FormatCancel:     binanceCancelRequestFormatter,
+		ParseCancel:      binanceOrderParser,
+		FormatAmend:      binanceAmendRequestFormatter,
+		ParseAmend:       binanceAmendParser,
+		FormatOpenOrders: binanceOpenOrdersRequestFormatter,
+		ParseOpenOrders:  binanceOpenOrdersParser,
+		FormatQueryOrder: binanceQueryOrderRequestFormatter,
+		ParseQueryOrder:  binanceOrderParser,
+	})
+
+	return bc
+}
+
+// Subscribe adds a stream for each trading pair. Pairs may be given as Binance symbols ("BTCUSDT") or with
+// a separator ("BTC/USDT", "btc-usdt"); ticks are reported under the name given here. Subscriptions made
+// before StreamMarketData are sent once connected, and all subscriptions are renewed after a reconnection.
+func (bc *BinanceConnector) Subscribe(stream BinanceStream, tradingPairs ...string) error {
+	bc.mu.Lock()
+	var added []string
+	for _, tradingPair := range tradingPairs {
+		symbol := binanceSymbol(tradingPair)
+		bc.pairs[symbol] = tradingPair
+		name := binanceStreamName(symbol, stream)
+		if !containsString(bc.subscriptions, name) {
+			bc.subscriptions = append(bc.subscriptions, name)
+			added = append(added, name)
+		}
+	}
+	bc.mu.Unlock()
+
+	return bc.sendIfConnected("SUBSCRIBE", added)
+}
+
+// Unsubscribe removes a stream for each trading pair.
+func (bc *BinanceConnector) Unsubscribe(stream BinanceStream, tradingPairs ...string) error {
+	bc.mu.Lock()
+	var removed []string
+	for _, tradingPair := range tradingPairs {
+		name := binanceStreamName(binanceSymbol(tradingPair), stream)
+		for i, subscription := range bc.subscriptions {
+			if subscription == name {
+				bc.subscriptions = append(bc.subscriptions[:i], bc.subscriptions[i+1:]...)
+				removed = append(removed, name)
+				break
+			}
+		}
+	}
+	bc.mu.Unlock()
+
+	return bc.sendIfConnected("UNSUBSCRIBE", removed)
+}
+
+// Subscriptions returns the names of the subscribed streams, e.g. "btcusdt@trade".
+func (bc *BinanceConnector) Subscriptions() []string {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	return append([]string(nil), bc.subscriptions...)
+}
+
+// sendIfConnected sends a subscription request for streams if the WebSocket is open. Otherwise the
+// subscriptions are sent by resubscribe when the connection opens.
+func (bc *BinanceConnector) sendIfConnected(method string, streams []string) error {
+	if len(streams) == 0 {
+		return nil
+	}
+	err := bc.streamer.Client.SendJSON(bc.subscriptionRequest(method, streams))
+	if errors.Is(err, clients.ErrNotConnected) {
+		return nil
+	}
+	return err
+}
+
+// resubscribe sends every subscription on a new connection.
+func (bc *BinanceConnector) resubscribe(send func(v interface{}) error) error {
+	streams := bc.Subscriptions()
+	if len(streams) == 0 {
+		return nil
+	}
+	return send(bc.subscriptionRequest("SUBSCRIBE", streams))
+}
+
+// binanceSubscriptionRequest is a SUBSCRIBE or UNSUBSCRIBE request for market data streams.
+type binanceSubscriptionRequest struct {
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+	ID     int64    `json:"id"`
+}
+
+// subscriptionRequest builds a subscription request with the next request ID.
+func (bc *BinanceConnector) subscriptionRequest(method string, streams []string) binanceSubscriptionRequest {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.requestID++
+	return binanceSubscriptionRequest{Method: method, Params: streams, ID: bc.requestID}
+}
 
-	// Initialize RestExecutor with Binance-specific request formatter and REST client
-	restClient := clients.NewRestClient(restURL, apiKey)
-	executor := adapters.NewRestExecutor(restClient, binanceRequestFormatter)
+// binanceSymbol converts a trading pair to a Binance symbol, e.g. "BTC/USDT" -> "BTCUSDT".
+func binanceSymbol(tradingPair string) string {
+	return strings.ToUpper(strings.NewReplacer("/", "", "-", "", "_", "").Replace(tradingPair))
+}
 
-	return &BinanceConnector{
-		streamer: streamer,
-		executor: executor,
+// binanceStreamName returns the name of a symbol's stream, e.g. "btcusdt@trade".
+func binanceStreamName(symbol string, stream BinanceStream) string {
+	return strings.ToLower(symbol) + "@" + string(stream)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
 	}
+	return false
 }
 
 // StreamMarketData begins streaming Binance market data and processes each tick.
 func (bc *BinanceConnector) StreamMarketData(handler func(ctx *types.TickContext)) error {
 	return bc.streamer.StartStreaming(func(ctx *types.TickContext) {
 		// Wrap ExecuteOrder function in TickContext
-		ctx.ExecuteOrder = func(orderType types.OrderType, side types.OrderSide, amount, price float64) error {
+		ctx.ExecuteOrder = func(orderType types.OrderType, side types.OrderSide, amount, price float64) (*types.Order, error) {
 			return bc.ExecuteOrder(orderType, side, ctx.TradingPair, amount, price)
 		}
 		handler(ctx)
@@ -56,47 +214,476 @@ func (bc *BinanceConnector) StopStreaming() error {
 	return bc.streamer.StopStreaming()
 }
 
-// binanceMessageParser parses Binance WebSocket messages into MarketData.
+// GetIdentifier returns the WebSocket URL as the unique identifier for BinanceConnector.
+func (bc *BinanceConnector) GetIdentifier() string {
+	return bc.streamer.Client.GetConnectionUrl()
+}
+
+// parseMessage parses a Binance WebSocket message, reporting the tick under the subscribed trading pair name.
+func (bc *BinanceConnector) parseMessage(message []byte) (*types.MarketData, string, error) {
+	marketData, symbol, err := binanceMessageParser(message)
+	if err != nil {
+		return nil, "", err
+	}
+	return marketData, bc.tradingPair(symbol), nil
+}
+
+// tradingPair returns the subscribed trading pair name of a Binance symbol, or the symbol if it was not subscribed.
+func (bc *BinanceConnector) tradingPair(symbol string) string {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	if tradingPair, ok := bc.pairs[symbol]; ok {
+		return tradingPair
+	}
+	return symbol
+}
+
+// StreamDepth passes the diffs of subscribed depth streams to handler until StopStreaming. Binance streams no
+// snapshots, so local books request one with ResyncDepth before applying the first diff.
+func (bc *BinanceConnector) StreamDepth(handler func(update *types.OrderBookUpdate)) error {
+	bc.mu.Lock()
+	bc.depthHandler = handler
+	bc.mu.Unlock()
+
+	return bc.streamer.StreamMessages(func(url string, data []byte) {
+		update, err := binanceDepthParser(data)
+		if err != nil || update == nil {
+			return
+		}
+		update.TradingPair = bc.tradingPair(update.TradingPair)
+		handler(update)
+	})
+}
+
+// ResyncDepth fetches a snapshot of a trading pair's book over REST and passes it to the StreamDepth handler.
+func (bc *BinanceConnector) ResyncDepth(tradingPair string) error {
+	bc.mu.Lock()
+	handler := bc.depthHandler
+	bc.mu.Unlock()
+	if handler == nil {
+		return errors.New("binance depth is not streaming")
+	}
+
+	query := url.Values{}
+	query.Set("symbol", binanceSymbol(tradingPair))
+	query.Set("limit", strconv.Itoa(binanceDepthSnapshotLimit))
+	resp, err := bc.restClient.Do("GET", "/api/v3/depth?"+query.Encode(), "", nil)
+	if err != nil {
+		return fmt.Errorf("failed to fetch order book snapshot: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read order book snapshot: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("order book snapshot failed with status %d: %s", resp.StatusCode, body)
+	}
+
+	snapshot, err := binanceDepthSnapshotParser(body)
+	if err != nil {
+		return fmt.Errorf("failed to parse order book snapshot: %w", err)
+	}
+	snapshot.TradingPair = tradingPair
+	handler(snapshot)
+	return nil
+}
+
+// errBinanceNoTick is returned for messages that carry no tick, such as subscription responses and open klines.
+var errBinanceNoTick = errors.New("binance message carries no tick")
+
+// binanceFields holds a Binance stream payload by exact key. Payloads use keys that differ only in case,
+// such as "e" (event type) and "E" (event time), which encoding/json would match case-insensitively.
+type binanceFields map[string]json.RawMessage
+
+// has reports whether the payload contains key.
+func (f binanceFields) has(key string) bool {
+	_, ok := f[key]
+	return ok
+}
+
+// string returns a string field, or "" if it is missing or not a string.
+func (f binanceFields) string(key string) string {
+	var value string
+	json.Unmarshal(f[key], &value)
+	return value
+}
+
+// float returns a decimal field encoded as a string, or zero if it is missing or malformed.
+func (f binanceFields) float(key string) float64 {
+	return parseFloat(f.string(key))
+}
+
+// int returns an integer field, or zero if it is missing or not a number.
+func (f binanceFields) int(key string) int64 {
+	var value int64
+	json.Unmarshal(f[key], &value)
+	return value
+}
+
+// object returns a nested object field, or nil if it is missing.
+func (f binanceFields) object(key string) binanceFields {
+	var value binanceFields
+	json.Unmarshal(f[key], &value)
+	return value
+}
+
+// binanceMessageParser parses a Binance trade, aggTrade, bookTicker or kline message, from a raw stream or
+// wrapped in a combined stream envelope, into MarketData and the Binance symbol it belongs to.
 func binanceMessageParser(message []byte) (*types.MarketData, string, error) {
-	var parsedData map[string]interface{}
-	if err := json.Unmarshal(message, &parsedData); err != nil {
+	var fields binanceFields
+	if err := json.Unmarshal(message, &fields); err != nil {
 		return nil, "", err
 	}
+	if fields.has("stream") && fields.has("data") {
+		fields = fields.object("data")
+	}
 
-	price, _ := parsedData["price"].(float64)
-	volume, _ := parsedData["volume"].(float64)
-	tradingPair, _ := parsedData["symbol"].(string)
+	if errorFields := fields.object("error"); errorFields != nil {
+		log.Printf("Binance rejected request %d: %d %s\n", fields.int("id"), errorFields.int("code"), errorFields.string("msg"))
+		return nil, "", fmt.Errorf("binance error %d: %s", errorFields.int("code"), errorFields.string("msg"))
+	}
 
-	if price == 0 || volume == 0 {
+	var marketData *types.MarketData
+	switch fields.string("e") {
+	case "trade", "aggTrade":
+		marketData = &types.MarketData{
+			Price:   fields.float("p"),
+			Volume:  fields.float("q"),
+			Time:    fields.int("E"),
+			Side:    types.OrderSideBuy,
+			TradeID: strconv.FormatInt(fields.int("t"), 10),
+		}
+		if fields.string("e") == "aggTrade" {
+			marketData.TradeID = strconv.FormatInt(fields.int("a"), 10)
+		}
+		// m is set when the buyer was the maker, so the seller was the aggressor
+		if string(fields["m"]) == "true" {
+			marketData.Side = types.OrderSideSell
+		}
+	case "kline":
+		kline := fields.object("k")
+		if kline == nil || string(kline["x"]) != "true" {
+			return nil, "", errBinanceNoTick // Only closed candles are reported
+		}
+		marketData = &types.MarketData{
+			Price:  kline.float("c"),
+			Volume: kline.float("v"),
+			Time:   kline.int("T"),
+		}
+	case "":
+		// bookTicker payloads have no event type or event time; subscription responses have an id
+		if fields.has("id") || !fields.has("b") || !fields.has("a") {
+			return nil, "", errBinanceNoTick
+		}
+		now := time.Now().UnixMilli()
+		marketData = &types.MarketData{
+			Price:       (fields.float("b") + fields.float("a")) / 2,
+			Bid:         fields.float("b"),
+			Ask:         fields.float("a"),
+			Time:        now,
+			ReceiveTime: now,
+		}
+	default:
+		return nil, "", errBinanceNoTick
+	}
+
+	symbol := fields.string("s")
+	if symbol == "" || marketData.Price <= 0 {
 		return nil, "", fmt.Errorf("invalid data in WebSocket message")
 	}
+	return marketData, symbol, nil
+}
+
+// levels returns a list of [price, quantity] pairs encoded as strings, as sent in depth payloads.
+func (f binanceFields) levels(key string) []types.PriceLevel {
+	var raw [][]string
+	json.Unmarshal(f[key], &raw)
+	return binanceLevels(raw)
+}
+
+// binanceLevels converts [price, quantity] string pairs to price levels.
+func binanceLevels(raw [][]string) []types.PriceLevel {
+	levels := make([]types.PriceLevel, 0, len(raw))
+	for _, level := range raw {
+		if len(level) < 2 {
+			continue
+		}
+		levels = append(levels, types.PriceLevel{Price: parseFloat(level[0]), Quantity: parseFloat(level[1])})
+	}
+	return levels
+}
+
+// binanceDepthParser parses a diff depth message, from a raw stream or wrapped in a combined stream envelope,
+// into an update for the Binance symbol. Other messages return a nil update.
+func binanceDepthParser(message []byte) (*types.OrderBookUpdate, error) {
+	var fields binanceFields
+	if err := json.Unmarshal(message, &fields); err != nil {
+		return nil, err
+	}
+	if fields.has("stream") && fields.has("data") {
+		fields = fields.object("data")
+	}
+	if fields.string("e") != "depthUpdate" {
+		return nil, nil
+	}
+	return &types.OrderBookUpdate{
+		TradingPair:   fields.string("s"),
+		Bids:          fields.levels("b"),
+		Asks:          fields.levels("a"),
+		FirstSequence: fields.int("U"),
+		LastSequence:  fields.int("u"),
+		Time:          fields.int("E"),
+	}, nil
+}
 
-	return &types.MarketData{
-		Price:  price,
-		Volume: volume,
-	}, tradingPair, nil
+// binanceDepthSnapshotParser parses a REST order book snapshot.
+func binanceDepthSnapshotParser(body []byte) (*types.OrderBookUpdate, error) {
+	var resp struct {
+		LastUpdateID int64      `json:"lastUpdateId"`
+		Bids         [][]string `json:"bids"`
+		Asks         [][]string `json:"asks"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	return &types.OrderBookUpdate{
+		Snapshot:     true,
+		Bids:         binanceLevels(resp.Bids),
+		Asks:         binanceLevels(resp.Asks),
+		LastSequence: resp.LastUpdateID,
+		Time:         time.Now().UnixMilli(),
+	}, nil
 }
 
 // ExecuteOrder places an order on Binance with the specified type and side.
-func (bc *BinanceConnector) ExecuteOrder(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64) error {
-	return bc.executor.ExecuteOrder(orderType, side, tradingPair, amount, price)
+func (bc *BinanceConnector) ExecuteOrder(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64) (*types.Order, error) {
+	order, err := bc.executor.ExecuteOrder(orderType, side, tradingPair, amount, price)
+	return withTradingPair(order, tradingPair), err
+}
+
+// CancelOrder cancels an open order on Binance.
+func (bc *BinanceConnector) CancelOrder(tradingPair, orderID string) (*types.Order, error) {
+	order, err := bc.executor.CancelOrder(tradingPair, orderID)
+	return withTradingPair(order, tradingPair), err
+}
+
+// AmendOrder replaces an open order on Binance with a new amount and price.
+func (bc *BinanceConnector) AmendOrder(tradingPair, orderID string, amount, price float64) (*types.Order, error) {
+	order, err := bc.executor.AmendOrder(tradingPair, orderID, amount, price)
+	return withTradingPair(order, tradingPair), err
+}
+
+// GetOpenOrders lists the open orders on Binance for a trading pair.
+func (bc *BinanceConnector) GetOpenOrders(tradingPair string) ([]*types.Order, error) {
+	orders, err := bc.executor.GetOpenOrders(tradingPair)
+	for _, order := range orders {
+		withTradingPair(order, tradingPair)
+	}
+	return orders, err
+}
+
+// GetOrder looks up the current state of an order on Binance, so fills of resting orders can be polled for.
+func (bc *BinanceConnector) GetOrder(tradingPair, orderID string) (*types.Order, error) {
+	order, err := bc.executor.GetOrder(tradingPair, orderID)
+	return withTradingPair(order, tradingPair), err
+}
+
+// withTradingPair reports an order under the caller's trading pair name rather than the Binance symbol,
+// so positions and risk checks use the same name as the ticks.
+func withTradingPair(order *types.Order, tradingPair string) *types.Order {
+	if order != nil {
+		order.TradingPair = tradingPair
+	}
+	return order
+}
+
+// binanceOrderType converts an OrderType to a Binance order type, e.g. "stop-loss-limit" -> "STOP_LOSS_LIMIT".
+func binanceOrderType(orderType types.OrderType) string {
+	return strings.ToUpper(strings.ReplaceAll(string(orderType), "-", "_"))
 }
 
 // binanceRequestFormatter formats requests for the Binance REST API.
-func binanceRequestFormatter(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64) (string, string, interface{}, error) {
+func binanceRequestFormatter(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64, clientOrderID string) (string, string, interface{}, error) {
 	endpoint := "/api/v3/order"
 	method := "POST"
-	orderData := map[string]interface{}{
-		"symbol":   tradingPair,
-		"side":     string(side),      // Use the side directly as "BUY" or "SELL"
-		"type":     string(orderType), // Order type (e.g., "MARKET", "LIMIT")
-		"quantity": amount,
+	params := url.Values{}
+	params.Set("symbol", binanceSymbol(tradingPair))
+	params.Set("side", strings.ToUpper(string(side)))
+	params.Set("type", binanceOrderType(orderType))
+	params.Set("quantity", formatDecimal(amount))
+	params.Set("newClientOrderId", clientOrderID)
+	params.Set("newOrderRespType", "FULL") // Include fills in the response
+
+	setBinancePrices(params, orderType, price)
+
+	return endpoint, method, params, nil
+}
+
+// setBinancePrices sets the prices an order type requires: the stopPrice that triggers stop-loss and
+// take-profit orders, and the price of limit orders, resting until cancelled. The limit variants of stop-loss
+// and take-profit orders are given the one price as both their trigger and their limit.
+func setBinancePrices(params url.Values, orderType types.OrderType, price float64) {
+	if triggeredOrder(orderType) {
+		params.Set("stopPrice", formatDecimal(price))
+	}
+	if limitOrder(orderType) {
+		params.Set("price", formatDecimal(price))
+		params.Set("timeInForce", "GTC")
+	}
+}
+
+// binanceOrderResponse is the FULL response returned by Binance when placing an order.
+type binanceOrderResponse struct {
+	Symbol              string `json:"symbol"`
+	OrderID             int64  `json:"orderId"`
+	ClientOrderID       string `json:"clientOrderId"`
+	TransactTime        int64  `json:"transactTime"`
+	Price               string `json:"price"`
+	OrigQty             string `json:"origQty"`
+	ExecutedQty         string `json:"executedQty"`
+	CummulativeQuoteQty string `json:"cummulativeQuoteQty"`
+	Status              string `json:"status"`
+	Type                string `json:"type"`
+	Side                string `json:"side"`
+	Time                int64  `json:"time"`       // Creation time, reported by order queries
+	UpdateTime          int64  `json:"updateTime"` // Last update time, reported by order queries
+	Fills               []struct {
+		Price           string `json:"price"`
+		Qty             string `json:"qty"`
+		Commission      string `json:"commission"`
+		CommissionAsset string `json:"commissionAsset"`
+		TradeID         int64  `json:"tradeId"`
+	} `json:"fills"`
+}
+
+// binanceOrderStatuses maps Binance order statuses to OrderStatus.
+var binanceOrderStatuses = map[string]types.OrderStatus{
+	"NEW":              types.OrderStatusNew,
+	"PARTIALLY_FILLED": types.OrderStatusPartiallyFilled,
+	"FILLED":           types.OrderStatusFilled,
+	"CANCELED":         types.OrderStatusCancelled,
+	"PENDING_CANCEL":   types.OrderStatusCancelled,
+	"EXPIRED":          types.OrderStatusCancelled,
+	"EXPIRED_IN_MATCH": types.OrderStatusCancelled,
+	"REJECTED":         types.OrderStatusRejected,
+}
+
+// binanceOrderParser parses a Binance order response into an Order.
+func binanceOrderParser(body []byte) (*types.Order, error) {
+	var resp binanceOrderResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	return resp.toOrder(), nil
+}
+
+// toOrder converts a Binance order response into an Order.
+func (resp *binanceOrderResponse) toOrder() *types.Order {
+	createdAt, updatedAt := resp.TransactTime, resp.TransactTime
+	if resp.Time != 0 {
+		createdAt, updatedAt = resp.Time, resp.UpdateTime
+	}
+
+	order := &types.Order{
+		ClientOrderID:   resp.ClientOrderID,
+		ExchangeOrderID: strconv.FormatInt(resp.OrderID, 10),
+		TradingPair:     resp.Symbol,
+		Type:            types.OrderType(strings.ReplaceAll(strings.ToLower(resp.Type), "_", "-")),
+		Side:            types.OrderSide(strings.ToLower(resp.Side)),
+		Status:          binanceOrderStatuses[resp.Status],
+		Price:           parseFloat(resp.Price),
+		Quantity:        parseFloat(resp.OrigQty),
+		FilledQuantity:  parseFloat(resp.ExecutedQty),
+		CreatedAt:       createdAt,
+		UpdatedAt:       updatedAt,
+	}
+	if order.FilledQuantity > 0 {
+		order.AveragePrice = parseFloat(resp.CummulativeQuoteQty) / order.FilledQuantity
+	}
+	for _, fill := range resp.Fills {
+		order.Fills = append(order.Fills, types.Fill{
+			TradeID:  strconv.FormatInt(fill.TradeID, 10),
+			Price:    parseFloat(fill.Price),
+			Quantity: parseFloat(fill.Qty),
+			Fee:      parseFloat(fill.Commission),
+			FeeAsset: fill.CommissionAsset,
+			Time:     resp.TransactTime,
+		})
+		order.Fee += parseFloat(fill.Commission)
+		order.FeeAsset = fill.CommissionAsset
 	}
+	return order
+}
+
+// binanceCancelRequestFormatter formats a request to cancel an order on Binance.
+func binanceCancelRequestFormatter(tradingPair, orderID string) (string, string, interface{}, error) {
+	query := url.Values{}
+	query.Set("symbol", binanceSymbol(tradingPair))
+	query.Set("orderId", orderID)
+	return "/api/v3/order?" + query.Encode(), "DELETE", nil, nil
+}
 
-	// Include price for order types that require it (e.g., LIMIT)
-	if orderType == types.OrderTypeLimit || orderType == types.OrderTypeStopLossLimit || orderType == types.OrderTypeTakeProfitLimit {
-		orderData["price"] = price
+// binanceAmendRequestFormatter formats a cancel/replace request that swaps an open order for a new one.
+func binanceAmendRequestFormatter(original *types.Order, amount, price float64, clientOrderID string) (string, string, interface{}, error) {
+	endpoint := "/api/v3/order/cancelReplace"
+	method := "POST"
+	params := url.Values{}
+	params.Set("symbol", binanceSymbol(original.TradingPair))
+	params.Set("side", strings.ToUpper(string(original.Side)))
+	params.Set("type", binanceOrderType(original.Type))
+	params.Set("cancelReplaceMode", "STOP_ON_FAILURE") // Keep the original order if it cannot be cancelled
+	params.Set("cancelOrderId", original.ExchangeOrderID)
+	params.Set("quantity", formatDecimal(amount))
+	setBinancePrices(params, original.Type, price)
+	params.Set("newClientOrderId", clientOrderID)
+	params.Set("newOrderRespType", "FULL")
+	return endpoint, method, params, nil
+}
+
+// binanceAmendResponse is the response returned by Binance's cancel/replace endpoint.
+type binanceAmendResponse struct {
+	CancelResult     string               `json:"cancelResult"`
+	NewOrderResult   string               `json:"newOrderResult"`
+	NewOrderResponse binanceOrderResponse `json:"newOrderResponse"`
+}
+
+// binanceAmendParser parses a cancel/replace response into the replacement Order.
+func binanceAmendParser(body []byte) (*types.Order, error) {
+	var resp binanceAmendResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	if resp.NewOrderResult != "SUCCESS" {
+		return &types.Order{Status: types.OrderStatusRejected}, fmt.Errorf("binance replacement order failed: cancel %s, new order %s", resp.CancelResult, resp.NewOrderResult)
 	}
+	return resp.NewOrderResponse.toOrder(), nil
+}
+
+// binanceQueryOrderRequestFormatter formats a request to look up an order on Binance.
+func binanceQueryOrderRequestFormatter(tradingPair, orderID string) (string, string, interface{}, error) {
+	query := url.Values{}
+	query.Set("symbol", binanceSymbol(tradingPair))
+	query.Set("orderId", orderID)
+	return "/api/v3/order?" + query.Encode(), "GET", nil, nil
+}
 
-	return endpoint, method, orderData, nil
+// binanceOpenOrdersRequestFormatter formats a request to list open orders on Binance.
+func binanceOpenOrdersRequestFormatter(tradingPair string) (string, string, interface{}, error) {
+	query := url.Values{}
+	query.Set("symbol", binanceSymbol(tradingPair))
+	return "/api/v3/openOrders?" + query.Encode(), "GET", nil, nil
+}
+
+// binanceOpenOrdersParser parses Binance's open orders response.
+func binanceOpenOrdersParser(body []byte) ([]*types.Order, error) {
+	var resp []binanceOrderResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	orders := make([]*types.Order, len(resp))
+	for i := range resp {
+		orders[i] = resp[i].toOrder()
+	}
+	return orders, nil
 }
@@ -0,0 +1,160 @@
+package connectors
+
+import (
+	"fmt"
+	"github.com/bigmeech/tradingbot/clients"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBinanceSigner_Signature(t *testing.T) {
+	// Example from the Binance API documentation
+	signer := NewBinanceSigner("vmPUZE6mv9SD5VNHk4HlWFsOr6aKE2zvsw0MuIgwCIPy6utIco14y7Ju91duEh8A", "NhqPtmdSJYdKjVHjA7PZj4Mge3R5YNiP1e3UZjInClVN65XAbvqqM6A7H5fATj0j")
+	payload := "symbol=LTCBTC&side=BUY&type=LIMIT&timeInForce=GTC&quantity=1&price=0.1&recvWindow=5000&timestamp=1499827319559"
+	if got, want := signer.signature(payload), "c8db56825ae71d6d79447849e617115f4a920fa2acdcab2b053c4b2838bd6b71"; got != want {
+		t.Errorf("Expected signature %s, got %s", want, got)
+	}
+}
+
+func TestBinanceSigner_Sign(t *testing.T) {
+	signer := NewBinanceSigner("key", "secret")
+	signer.RecvWindow = 10 * time.Second
+	signer.now = func() time.Time { return time.UnixMilli(1700000000000) }
+
+	// Without a body, parameters and the signature go in the query string
+	req := &clients.Request{Method: "GET", URL: mustParseURL(t, "https://api.binance.com/api/v3/openOrders?symbol=BTCUSDT"), Header: make(http.Header)}
+	if err := signer.Sign(req); err != nil {
+		t.Fatalf("Expected signing to succeed, got %v", err)
+	}
+	signed := "symbol=BTCUSDT&timestamp=1700000000000&recvWindow=10000"
+	if want := signed + "&signature=" + signer.signature(signed); req.URL.RawQuery != want {
+		t.Errorf("Expected query %s, got %s", want, req.URL.RawQuery)
+	}
+	if req.Header.Get("X-MBX-APIKEY") != "key" {
+		t.Errorf("Expected the API key header, got %v", req.Header)
+	}
+
+	// With a body, the signature covers the query followed by the body and is appended to the body
+	req = &clients.Request{Method: "POST", URL: mustParseURL(t, "https://api.binance.com/api/v3/order?symbol=BTCUSDT"), Header: make(http.Header), Body: []byte("side=BUY&quantity=1")}
+	if err := signer.Sign(req); err != nil {
+		t.Fatalf("Expected signing to succeed, got %v", err)
+	}
+	body := "side=BUY&quantity=1&timestamp=1700000000000&recvWindow=10000"
+	if want := body + "&signature=" + signer.signature("symbol=BTCUSDT"+body); string(req.Body) != want {
+		t.Errorf("Expected body %s, got %s", want, req.Body)
+	}
+
+	// Market data endpoints only carry the API key
+	req = &clients.Request{Method: "GET", URL: mustParseURL(t, "https://api.binance.com/api/v3/depth?symbol=BTCUSDT"), Header: make(http.Header)}
+	if err := signer.Sign(req); err != nil || req.URL.RawQuery != "symbol=BTCUSDT" {
+		t.Errorf("Expected an unsigned market data request, got %s, %v", req.URL.RawQuery, err)
+	}
+
+	if err := NewBinanceSigner("key", "").Sign(&clients.Request{URL: mustParseURL(t, "https://api.binance.com/api/v3/order"), Header: make(http.Header)}); err == nil {
+		t.Error("Expected signing without a secret to fail")
+	}
+}
+
+func TestBinanceConnector_SignedOrder(t *testing.T) {
+	const apiKey, apiSecret = "test-key", "test-secret"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-MBX-APIKEY") != apiKey {
+			http.Error(w, `{"code":-2014,"msg":"API-key format invalid."}`, http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+
+		// Verify the signature as Binance does: HMAC-SHA256 of the query string followed by the body
+		payload := r.URL.RawQuery + string(body)
+		index := strings.LastIndex(payload, "&signature=")
+		if index < 0 || NewBinanceSigner(apiKey, apiSecret).signature(payload[:index]) != payload[index+len("&signature="):] {
+			http.Error(w, `{"code":-1022,"msg":"Signature for this request is not valid."}`, http.StatusBadRequest)
+			return
+		}
+
+		params, _ := url.ParseQuery(string(body))
+		if r.Header.Get("Content-Type") != clients.ContentTypeForm || params.Get("timestamp") == "" || params.Get("recvWindow") != "5000" {
+			http.Error(w, `{"code":-1102,"msg":"Mandatory parameter was not sent."}`, http.StatusBadRequest)
+			return
+		}
+		if params.Get("symbol") != "BTCUSDT" || params.Get("side") != "BUY" || params.Get("type") != "LIMIT" || params.Get("price") != "35000.5" || params.Get("timeInForce") != "GTC" {
+			http.Error(w, `{"code":-1100,"msg":"Illegal characters found in a parameter."}`, http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `{"symbol":"BTCUSDT","orderId":7,"clientOrderId":%q,"transactTime":1700000000000,"price":"35000.50","origQty":"0.25","executedQty":"0","cummulativeQuoteQty":"0","status":"NEW","type":"LIMIT","side":"BUY","fills":[]}`, params.Get("newClientOrderId"))
+	}))
+	defer server.Close()
+
+	connector := NewBinanceConnector("ws://127.0.0.1:0/ws", server.URL, apiKey, apiSecret)
+	order, err := connector.ExecuteOrder(types.OrderTypeLimit, types.OrderSideBuy, "BTC/USDT", 0.25, 35000.5)
+	if err != nil {
+		t.Fatalf("Expected the signed order to be accepted, got %v", err)
+	}
+	if order.ExchangeOrderID != "7" || order.Status != types.OrderStatusNew || order.TradingPair != "BTC/USDT" {
+		t.Errorf("Unexpected order %+v", order)
+	}
+
+	// A wrong secret is rejected by the server
+	connector = NewBinanceConnector("ws://127.0.0.1:0/ws", server.URL, apiKey, "wrong-secret")
+	if _, err := connector.ExecuteOrder(types.OrderTypeLimit, types.OrderSideBuy, "BTC/USDT", 0.25, 35000.5); err == nil || !strings.Contains(err.Error(), "Signature") {
+		t.Errorf("Expected an invalid signature error, got %v", err)
+	}
+}
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", raw, err)
+	}
+	return parsed
+}
+
+func TestBinanceRequestFormatter(t *testing.T) {
+	tests := []struct {
+		orderType types.OrderType
+		stopPrice string // Expected stopPrice parameter, empty if it must be omitted
+		price     string // Expected price parameter, sent with timeInForce, empty if both must be omitted
+	}{
+		{types.OrderTypeMarket, "", ""},
+		{types.OrderTypeLimit, "", "35000.5"},
+		{types.OrderTypeStopLoss, "35000.5", ""},
+		{types.OrderTypeStopLossLimit, "35000.5", "35000.5"},
+		{types.OrderTypeTakeProfit, "35000.5", ""},
+		{types.OrderTypeTakeProfitLimit, "35000.5", "35000.5"},
+	}
+	for _, test := range tests {
+		_, _, body, err := binanceRequestFormatter(test.orderType, types.OrderSideSell, "BTC/USDT", 0.25, 35000.5, "tb0011223344556677")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.orderType, err)
+		}
+		checkBinancePrices(t, string(test.orderType), body.(url.Values), test.stopPrice, test.price)
+
+		original := &types.Order{ExchangeOrderID: "7", TradingPair: "BTC/USDT", Type: test.orderType, Side: types.OrderSideSell}
+		_, _, body, err = binanceAmendRequestFormatter(original, 0.25, 35000.5, "tb0011223344556677")
+		if err != nil {
+			t.Fatalf("%s amendment: unexpected error: %v", test.orderType, err)
+		}
+		checkBinancePrices(t, string(test.orderType)+" amendment", body.(url.Values), test.stopPrice, test.price)
+	}
+}
+
+// checkBinancePrices checks the price parameters of a Binance order request, which must be omitted where empty.
+func checkBinancePrices(t *testing.T, name string, params url.Values, stopPrice, price string) {
+	t.Helper()
+	timeInForce := ""
+	if price != "" {
+		timeInForce = "GTC"
+	}
+	for key, expected := range map[string]string{"stopPrice": stopPrice, "price": price, "timeInForce": timeInForce} {
+		if _, ok := params[key]; params.Get(key) != expected || ok != (expected != "") {
+			t.Errorf("%s: expected %s %q, got %v", name, key, expected, params)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package connectors
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBinanceMessageParser(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		symbol  string
+		want    types.MarketData
+		noTick  bool
+	}{
+		{
+			name:    "trade",
+			message: `{"e":"trade","E":1672515782136,"s":"BNBBTC","t":12345,"p":"0.00100000","q":"100.00000000","T":1672515782134,"m":true,"M":true}`,
+			symbol:  "BNBBTC",
+			want:    types.MarketData{Price: 0.001, Volume: 100, Time: 1672515782136, Side: types.OrderSideSell, TradeID: "12345"},
+		},
+		{
+			name:    "aggregate trade",
+			message: `{"e":"aggTrade","E":1672515782136,"s":"BTCUSDT","a":26129,"p":"16800.50","q":"0.25","f":100,"l":105,"T":1672515782134,"m":true,"M":true}`,
+			symbol:  "BTCUSDT",
+			want:    types.MarketData{Price: 16800.5, Volume: 0.25, Time: 1672515782136, Side: types.OrderSideSell, TradeID: "26129"},
+		},
+		{
+			name:    "closed kline",
+			message: `{"e":"kline","E":1672515782136,"s":"BTCUSDT","k":{"t":1672515720000,"T":1672515779999,"s":"BTCUSDT","i":"1m","o":"16790.0","c":"16805.0","h":"16810.0","l":"16785.0","v":"12.5","n":100,"x":true}}`,
+			symbol:  "BTCUSDT",
+			want:    types.MarketData{Price: 16805, Volume: 12.5, Time: 1672515779999},
+		},
+		{
+			name:    "open kline",
+			message: `{"e":"kline","E":1672515782136,"s":"BTCUSDT","k":{"T":1672515779999,"c":"16805.0","v":"12.5","x":false}}`,
+			noTick:  true,
+		},
+		{
+			name:    "combined stream envelope",
+			message: `{"stream":"ethusdt@trade","data":{"e":"trade","E":1672515782200,"s":"ETHUSDT","t":1,"p":"1200.10","q":"2"}}`,
+			symbol:  "ETHUSDT",
+			want:    types.MarketData{Price: 1200.1, Volume: 2, Time: 1672515782200, Side: types.OrderSideBuy, TradeID: "1"},
+		},
+		{
+			name:    "subscription response",
+			message: `{"result":null,"id":1}`,
+			noTick:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			marketData, symbol, err := binanceMessageParser([]byte(test.message))
+			if test.noTick {
+				if !errors.Is(err, errBinanceNoTick) {
+					t.Errorf("Expected no tick, got %+v, %v", marketData, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if symbol != test.symbol || *marketData != test.want {
+				t.Errorf("Expected %s %+v, got %s %+v", test.symbol, test.want, symbol, *marketData)
+			}
+		})
+	}
+}
+
+func TestBinanceMessageParser_BookTicker(t *testing.T) {
+	before := time.Now().UnixMilli()
+	marketData, symbol, err := binanceMessageParser([]byte(`{"u":400900217,"s":"BNBUSDT","b":"25.35","B":"31.21","a":"25.37","A":"40.66"}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if symbol != "BNBUSDT" || marketData.Price < 25.36-1e-9 || marketData.Price > 25.36+1e-9 {
+		t.Errorf("Expected BNBUSDT at the 25.36 mid price, got %s %v", symbol, marketData.Price)
+	}
+	if marketData.Bid != 25.35 || marketData.Ask != 25.37 {
+		t.Errorf("Expected bid 25.35 and ask 25.37, got %v and %v", marketData.Bid, marketData.Ask)
+	}
+	if marketData.Time < before || marketData.ReceiveTime != marketData.Time {
+		t.Errorf("Expected the receive time, got %v and %v", marketData.Time, marketData.ReceiveTime)
+	}
+}
+
+func TestBinanceMessageParser_ErrorResponse(t *testing.T) {
+	_, _, err := binanceMessageParser([]byte(`{"error":{"code":2,"msg":"Invalid request: unknown variant"},"id":3}`))
+	if err == nil || !strings.Contains(err.Error(), "unknown variant") {
+		t.Errorf("Expected the Binance error, got %v", err)
+	}
+}
+
+func TestBinanceConnector_StreamsSubscribedTrades(t *testing.T) {
+	requests := make(chan binanceSubscriptionRequest, 4)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Failed to upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		// Answer each subscription, then send a trade for the first subscribed stream
+		for {
+			var request binanceSubscriptionRequest
+			if err := conn.ReadJSON(&request); err != nil {
+				return
+			}
+			requests <- request
+			conn.WriteJSON(map[string]interface{}{"result": nil, "id": request.ID})
+			if request.Method == "SUBSCRIBE" {
+				conn.WriteMessage(websocket.TextMessage, []byte(`{"stream":"btcusdt@trade","data":{"e":"trade","E":1700000000123,"s":"BTCUSDT","t":1,"p":"35000.10","q":"0.5"}}`))
+			}
+		}
+	}))
+	defer server.Close()
+
+	connector := NewBinanceConnector("ws"+strings.TrimPrefix(server.URL, "http")+"/stream", server.URL, "key", "secret")
+	if err := connector.Subscribe(BinanceTradeStream, "BTC/USDT"); err != nil {
+		t.Fatalf("Expected subscribing before connecting to succeed, got %v", err)
+	}
+
+	ticks := make(chan *types.TickContext, 1)
+	if err := connector.StreamMarketData(func(ctx *types.TickContext) { ticks <- ctx }); err != nil {
+		t.Fatalf("Expected streaming to start, got %v", err)
+	}
+	defer connector.StopStreaming()
+
+	select {
+	case request := <-requests:
+		if request.Method != "SUBSCRIBE" || len(request.Params) != 1 || request.Params[0] != "btcusdt@trade" {
+			t.Errorf("Expected a SUBSCRIBE for btcusdt@trade, got %+v", request)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a subscription request on connect")
+	}
+
+	select {
+	case tick := <-ticks:
+		if tick.TradingPair != "BTC/USDT" {
+			t.Errorf("Expected the tick under the subscribed name BTC/USDT, got %s", tick.TradingPair)
+		}
+		if tick.MarketData.Price != 35000.1 || tick.MarketData.Volume != 0.5 || tick.MarketData.Time != 1700000000123 {
+			t.Errorf("Unexpected market data %+v", tick.MarketData)
+		}
+		if tick.MarketData.ReceiveTime < time.Now().Add(-time.Minute).UnixMilli() {
+			t.Errorf("Expected the tick to be stamped with its receive time, got %v", tick.MarketData.ReceiveTime)
+		}
+		if tick.ExecuteOrder == nil {
+			t.Error("Expected ExecuteOrder to be bound")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a trade tick")
+	}
+
+	// Unsubscribing while connected sends the request straight away
+	if err := connector.Unsubscribe(BinanceTradeStream, "BTCUSDT"); err != nil {
+		t.Fatalf("Expected unsubscribing to succeed, got %v", err)
+	}
+	select {
+	case request := <-requests:
+		encoded, _ := json.Marshal(request.Params)
+		if request.Method != "UNSUBSCRIBE" || string(encoded) != `["btcusdt@trade"]` {
+			t.Errorf("Expected an UNSUBSCRIBE for btcusdt@trade, got %+v", request)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected an unsubscribe request")
+	}
+	if len(connector.Subscriptions()) != 0 {
+		t.Errorf("Expected no subscriptions left, got %v", connector.Subscriptions())
+	}
+}
+
+func TestBinanceDepthParser(t *testing.T) {
+	update, err := binanceDepthParser([]byte(`{"stream":"bnbbtc@depth","data":{"e":"depthUpdate","E":1672515782136,"s":"BNBBTC","U":157,"u":160,"b":[["0.0024","10"]],"a":[["0.0026","100"],["0.0027","0"]]}}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := &types.OrderBookUpdate{
+		TradingPair:   "BNBBTC",
+		Bids:          []types.PriceLevel{{Price: 0.0024, Quantity: 10}},
+		Asks:          []types.PriceLevel{{Price: 0.0026, Quantity: 100}, {Price: 0.0027}},
+		FirstSequence: 157,
+		LastSequence:  160,
+		Time:          1672515782136,
+	}
+	if !reflect.DeepEqual(update, want) {
+		t.Errorf("Expected %+v, got %+v", want, update)
+	}
+
+	if update, err := binanceDepthParser([]byte(`{"e":"trade","E":1,"s":"BNBBTC","p":"1","q":"1"}`)); update != nil || err != nil {
+		t.Errorf("Expected no update for a trade, got %+v, %v", update, err)
+	}
+}
+
+func TestBinanceConnector_ResyncDepth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/depth" || r.URL.Query().Get("symbol") != "BTCUSDT" || r.URL.Query().Get("limit") != "1000" {
+			http.Error(w, `{"code":-1121,"msg":"Invalid symbol."}`, http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"lastUpdateId":1027024,"bids":[["35000.00","1.5"]],"asks":[["35001.00","2"]]}`))
+	}))
+	defer server.Close()
+
+	connector := NewBinanceConnector("ws://127.0.0.1:0/ws", server.URL, "key", "secret")
+	if err := connector.ResyncDepth("BTC/USDT"); err == nil {
+		t.Error("Expected resyncing before streaming depth to fail")
+	}
+
+	var snapshot *types.OrderBookUpdate
+	connector.depthHandler = func(update *types.OrderBookUpdate) { snapshot = update }
+	if err := connector.ResyncDepth("BTC/USDT"); err != nil {
+		t.Fatalf("Expected the snapshot to be fetched, got %v", err)
+	}
+	if snapshot == nil || !snapshot.Snapshot || snapshot.TradingPair != "BTC/USDT" || snapshot.LastSequence != 1027024 {
+		t.Fatalf("Unexpected snapshot %+v", snapshot)
+	}
+	if len(snapshot.Bids) != 1 || snapshot.Bids[0].Price != 35000 || snapshot.Asks[0].Quantity != 2 {
+		t.Errorf("Unexpected levels %+v %+v", snapshot.Bids, snapshot.Asks)
+	}
+
+	if err := connector.ResyncDepth("NOPE"); err == nil || !strings.Contains(err.Error(), "Invalid symbol") {
+		t.Errorf("Expected the REST error, got %v", err)
+	}
+}
@@ -2,21 +2,69 @@ package connectors
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/bigmeech/tradingbot/adapters"
 	"github.com/bigmeech/tradingbot/clients"
+	"github.com/bigmeech/tradingbot/internal/orderbook"
 	"github.com/bigmeech/tradingbot/pkg/types"
+	"hash/crc32"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
+// krakenBookDepth is the number of price levels per side requested for book subscriptions, which is also
+// the number of levels covered by Kraken's book checksums.
+const krakenBookDepth = 10
+
 // KrakenConnector encapsulates Kraken-specific streaming and order execution functionality.
 type KrakenConnector struct {
 	streamer *adapters.WebSocketStreamer
 	executor *adapters.RestExecutor
+
+	mu            sync.Mutex
+	subscriptions []krakenSubscription       // Channel subscriptions, sent on every (re)connection
+	pairs         map[string]string          // Trading pair name per Kraken symbol, e.g. "BTC/USD" -> "btc-usd"
+	books         map[string]*orderbook.Book // Local order book per symbol for book subscriptions
+	precisions    map[string]krakenPrecision // Decimals of the prices and quantities streamed per symbol
+	unverified    map[string]bool            // Symbols whose snapshot failed its checksum, which are not verified
+	requestID     int64
+}
+
+// krakenPrecision is the number of decimals Kraken formats a symbol's prices and quantities with.
+type krakenPrecision struct {
+	price, qty int
+}
+
+// KrakenChannel is a Kraken WebSocket v2 market data channel.
+type KrakenChannel string
+
+const (
+	// KrakenTickerChannel streams top of book and 24 hour statistics. Ticks carry the last trade price and no volume.
+	KrakenTickerChannel KrakenChannel = "ticker"
+
+	// KrakenTradeChannel streams trades. Each message becomes one tick at the last trade price with the summed volume.
+	KrakenTradeChannel KrakenChannel = "trade"
+
+	// KrakenBookChannel streams changes to the order book. Ticks carry the mid price of the best bid and ask and no volume.
+	KrakenBookChannel KrakenChannel = "book"
+)
+
+// krakenSubscription is a channel subscribed for a Kraken symbol.
+type krakenSubscription struct {
+	Channel KrakenChannel
+	Symbol  string
 }
 
 // NewKrakenConnector initializes a KrakenConnector with Kraken-specific WebSocket and REST clients.
-func NewKrakenConnector(wsURL, restURL, apiKey string) *KrakenConnector {
+// wsURL is the WebSocket v2 endpoint, wss://ws.kraken.com/v2; channels are chosen with Subscribe.
+// Private REST requests are signed with apiSecret, the base64 encoded private key, which is only needed for trading.
+func NewKrakenConnector(wsURL, restURL, apiKey, apiSecret string) *KrakenConnector {
 	// Set up a WebSocket client with Kraken constraints
 	wsClient := clients.NewWebSocketClient(
 		wsURL,
@@ -27,24 +75,193 @@ func NewKrakenConnector(wsURL, restURL, apiKey string) *KrakenConnector {
 		200,            // Stream limit: 200 streams per connection
 	)
 
-	// Initialize WebSocketStreamer with Kraken-specific parser and stream limit
-	streamer := adapters.NewWebSocketStreamer(wsClient, krakenMessageParser, 200)
+	kc := &KrakenConnector{
+		pairs:      make(map[string]string),
+		books:      make(map[string]*orderbook.Book),
+		precisions: make(map[string]krakenPrecision),
+		unverified: make(map[string]bool),
+	}
+
+	// Initialize WebSocketStreamer with Kraken-specific parser and stream limit, resubscribing on every connection
+	kc.streamer = adapters.NewWebSocketStreamer(wsClient, kc.parseMessage, 200)
+	wsClient.OnConnect(kc.resubscribe)
+
+	// Initialize RestExecutor with Kraken-specific request formatter and a REST client signing requests
+	restClient := clients.NewSignedRestClient(restURL, NewKrakenSigner(apiKey, apiSecret))
+	kc.executor = adapters.NewRestExecutor(restClient, adapters.ExchangeAPI{
+		FormatOrder:      krakenRequestFormatter,
+		ParseOrder:       krakenOrderParser,
+		FormatCancel:     krakenCancelRequestFormatter,
+		ParseCancel:      krakenCancelParser,
+		FormatAmend:      krakenAmendRequestFormatter,
+		ParseAmend:       krakenAmendParser,
+		FormatOpenOrders: krakenOpenOrdersRequestFormatter,
+		ParseOpenOrders:  krakenOpenOrdersParser,
+		FormatQueryOrder: krakenQueryOrderRequestFormatter,
+		ParseQueryOrder:  krakenQueryOrderParser,
+	})
+
+	return kc
+}
+
+// Subscribe adds a channel for each trading pair. Pairs may be given as Kraken symbols ("BTC/USD") or with
+// another separator ("btc-usd"); ticks are reported under the name given here. Subscriptions made before
+// StreamMarketData are sent once connected, and all subscriptions are renewed after a reconnection.
+func (kc *KrakenConnector) Subscribe(channel KrakenChannel, tradingPairs ...string) error {
+	kc.mu.Lock()
+	var added []string
+	for _, tradingPair := range tradingPairs {
+		symbol := krakenSymbol(tradingPair)
+		kc.pairs[symbol] = tradingPair
+		subscription := krakenSubscription{Channel: channel, Symbol: symbol}
+		if !kc.subscribed(subscription) {
+			kc.subscriptions = append(kc.subscriptions, subscription)
+			added = append(added, symbol)
+		}
+	}
+	kc.mu.Unlock()
+
+	return kc.sendIfConnected("subscribe", channel, added)
+}
 
-	// Initialize RestExecutor with Kraken-specific request formatter and REST client
-	restClient := clients.NewRestClient(restURL, apiKey)
-	executor := adapters.NewRestExecutor(restClient, krakenRequestFormatter)
+// Unsubscribe removes a channel for each trading pair.
+func (kc *KrakenConnector) Unsubscribe(channel KrakenChannel, tradingPairs ...string) error {
+	kc.mu.Lock()
+	var removed []string
+	for _, tradingPair := range tradingPairs {
+		symbol := krakenSymbol(tradingPair)
+		for i, subscription := range kc.subscriptions {
+			if subscription == (krakenSubscription{Channel: channel, Symbol: symbol}) {
+				kc.subscriptions = append(kc.subscriptions[:i], kc.subscriptions[i+1:]...)
+				removed = append(removed, symbol)
+				break
+			}
+		}
+		if channel == KrakenBookChannel {
+			delete(kc.books, symbol)
+			delete(kc.unverified, symbol)
+		}
+	}
+	kc.mu.Unlock()
 
-	return &KrakenConnector{
-		streamer: streamer,
-		executor: executor,
+	return kc.sendIfConnected("unsubscribe", channel, removed)
+}
+
+// Subscriptions returns the subscribed channels as "channel:symbol", e.g. "trade:BTC/USD".
+func (kc *KrakenConnector) Subscriptions() []string {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	subscriptions := make([]string, 0, len(kc.subscriptions))
+	for _, subscription := range kc.subscriptions {
+		subscriptions = append(subscriptions, string(subscription.Channel)+":"+subscription.Symbol)
 	}
+	return subscriptions
 }
 
-// StartStreaming begins streaming Kraken market data and processes each tick.
-func (kc *KrakenConnector) StartStreaming(handler types.MarketDataHandler) error {
-	// Wrap the handler to provide Kraken-specific order execution
+// subscribed reports whether subscription exists. The caller must hold mu.
+func (kc *KrakenConnector) subscribed(subscription krakenSubscription) bool {
+	for _, existing := range kc.subscriptions {
+		if existing == subscription {
+			return true
+		}
+	}
+	return false
+}
+
+// sendIfConnected sends a subscription request for symbols if the WebSocket is open. Otherwise the
+// subscriptions are sent by resubscribe when the connection opens.
+func (kc *KrakenConnector) sendIfConnected(method string, channel KrakenChannel, symbols []string) error {
+	if len(symbols) == 0 {
+		return nil
+	}
+	err := kc.streamer.Client.SendJSON(kc.subscriptionRequest(method, channel, symbols))
+	if errors.Is(err, clients.ErrNotConnected) {
+		return nil
+	}
+	return err
+}
+
+// resubscribe sends every subscription on a new connection, one request per channel. Local order books
+// are dropped, as Kraken sends a fresh snapshot for each book subscription.
+func (kc *KrakenConnector) resubscribe(send func(v interface{}) error) error {
+	kc.mu.Lock()
+	var channels []KrakenChannel
+	symbols := make(map[KrakenChannel][]string)
+	for _, subscription := range kc.subscriptions {
+		if _, ok := symbols[subscription.Channel]; !ok {
+			channels = append(channels, subscription.Channel)
+		}
+		symbols[subscription.Channel] = append(symbols[subscription.Channel], subscription.Symbol)
+	}
+	kc.books = make(map[string]*orderbook.Book)
+	kc.unverified = make(map[string]bool)
+	kc.mu.Unlock()
+
+	for _, channel := range channels {
+		if err := send(kc.subscriptionRequest("subscribe", channel, symbols[channel])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// krakenSubscriptionRequest is a subscribe or unsubscribe request for a WebSocket v2 channel.
+type krakenSubscriptionRequest struct {
+	Method string                   `json:"method"`
+	Params krakenSubscriptionParams `json:"params"`
+	ReqID  int64                    `json:"req_id"`
+}
+
+// krakenSubscriptionParams are the parameters of a subscription request.
+type krakenSubscriptionParams struct {
+	Channel KrakenChannel `json:"channel"`
+	Symbol  []string      `json:"symbol"`
+	Depth   int           `json:"depth,omitempty"` // Book levels per side, book channel only
+}
+
+// subscriptionRequest builds a subscription request with the next request ID.
+func (kc *KrakenConnector) subscriptionRequest(method string, channel KrakenChannel, symbols []string) krakenSubscriptionRequest {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	kc.requestID++
+	params := krakenSubscriptionParams{Channel: channel, Symbol: symbols}
+	if channel == KrakenBookChannel && method == "subscribe" {
+		params.Depth = krakenBookDepth
+	}
+	return krakenSubscriptionRequest{Method: method, Params: params, ReqID: kc.requestID}
+}
+
+// krakenSymbol converts a trading pair to a Kraken WebSocket v2 symbol, e.g. "xbt-usd" -> "BTC/USD".
+func krakenSymbol(tradingPair string) string {
+	symbol := strings.ToUpper(strings.NewReplacer("-", "/", "_", "/").Replace(tradingPair))
+	if base, quote, ok := strings.Cut(symbol, "/"); ok && base == "XBT" {
+		symbol = "BTC/" + quote
+	}
+	return symbol
+}
+
+// krakenRESTPair converts a trading pair to the pair name used by Kraken's REST API, e.g. "BTC/USD" -> "BTCUSD".
+func krakenRESTPair(tradingPair string) string {
+	return strings.ToUpper(strings.NewReplacer("/", "", "-", "", "_", "").Replace(tradingPair))
+}
+
+// krakenSamePair reports whether two trading pairs name the same Kraken pair, e.g. "XBTUSD" and "BTC/USD".
+func krakenSamePair(a, b string) bool {
+	normalize := func(tradingPair string) string {
+		pair := krakenRESTPair(tradingPair)
+		if strings.HasPrefix(pair, "XBT") {
+			pair = "BTC" + strings.TrimPrefix(pair, "XBT")
+		}
+		return pair
+	}
+	return normalize(a) == normalize(b)
+}
+
+// StreamMarketData begins streaming Kraken market data and processes each tick.
+func (kc *KrakenConnector) StreamMarketData(handler func(ctx *types.TickContext)) error {
 	return kc.streamer.StartStreaming(func(ctx *types.TickContext) {
-		ctx.ExecuteOrder = func(orderType types.OrderType, side types.OrderSide, amount, price float64) error {
+		// Wrap ExecuteOrder function in TickContext
+		ctx.ExecuteOrder = func(orderType types.OrderType, side types.OrderSide, amount, price float64) (*types.Order, error) {
 			return kc.ExecuteOrder(orderType, side, ctx.TradingPair, amount, price)
 		}
 		handler(ctx)
@@ -56,49 +273,626 @@ func (kc *KrakenConnector) StopStreaming() error {
 	return kc.streamer.StopStreaming()
 }
 
-// krakenMessageParser parses Kraken WebSocket messages into MarketData.
-func krakenMessageParser(message []byte) (*types.MarketData, string, error) {
-	var parsedData map[string]interface{}
-	if err := json.Unmarshal(message, &parsedData); err != nil {
+// GetIdentifier returns the WebSocket URL as the unique identifier for KrakenConnector.
+func (kc *KrakenConnector) GetIdentifier() string {
+	return kc.streamer.Client.GetConnectionUrl()
+}
+
+// errKrakenNoTick is returned for messages that carry no tick, such as heartbeats, acknowledgements and trade snapshots.
+var errKrakenNoTick = errors.New("kraken message carries no tick")
+
+// krakenMessage is a Kraken WebSocket v2 message: either a channel message or a response to a request.
+type krakenMessage struct {
+	Channel string          `json:"channel"`
+	Type    string          `json:"type"` // "snapshot" or "update"
+	Data    json.RawMessage `json:"data"`
+	Method  string          `json:"method"`
+	Success *bool           `json:"success"`
+	Error   string          `json:"error"`
+	ReqID   int64           `json:"req_id"`
+}
+
+// krakenTrade is an entry of the trade channel.
+type krakenTrade struct {
+	Symbol    string  `json:"symbol"`
+	Side      string  `json:"side"`
+	Price     float64 `json:"price"`
+	Qty       float64 `json:"qty"`
+	TradeID   int64   `json:"trade_id"`
+	Timestamp string  `json:"timestamp"` // RFC 3339
+}
+
+// krakenTicker is an entry of the ticker channel.
+type krakenTicker struct {
+	Symbol    string  `json:"symbol"`
+	Bid       float64 `json:"bid"`
+	Ask       float64 `json:"ask"`
+	Last      float64 `json:"last"`
+	Timestamp string  `json:"timestamp"` // RFC 3339, not sent by every API version
+}
+
+// krakenBookLevel is a price level of the book channel. A zero quantity removes the level. The numbers are
+// kept as sent, since their decimals are needed to verify checksums.
+type krakenBookLevel struct {
+	Price json.Number `json:"price"`
+	Qty   json.Number `json:"qty"`
+}
+
+// krakenBookEntry is an entry of the book channel.
+type krakenBookEntry struct {
+	Symbol    string            `json:"symbol"`
+	Bids      []krakenBookLevel `json:"bids"`
+	Asks      []krakenBookLevel `json:"asks"`
+	Checksum  *uint32           `json:"checksum"`  // CRC32 of the best levels after the entry is applied
+	Timestamp string            `json:"timestamp"` // RFC 3339, sent with updates
+}
+
+// parseMessage parses a Kraken WebSocket v2 message, reporting the tick under the subscribed trading pair name.
+func (kc *KrakenConnector) parseMessage(message []byte) (*types.MarketData, string, error) {
+	var msg krakenMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
 		return nil, "", err
 	}
+	if msg.Method != "" {
+		if msg.Success != nil && !*msg.Success {
+			log.Printf("Kraken rejected %s request %d: %s\n", msg.Method, msg.ReqID, msg.Error)
+			return nil, "", fmt.Errorf("kraken %s error: %s", msg.Method, msg.Error)
+		}
+		return nil, "", errKrakenNoTick
+	}
 
-	// Assuming Kraken’s WebSocket data includes price, volume, and symbol
-	price, _ := parsedData["price"].(float64)
-	volume, _ := parsedData["volume"].(float64)
-	tradingPair, _ := parsedData["symbol"].(string)
-
-	if price == 0 || volume == 0 {
+	var marketData *types.MarketData
+	var symbol string
+	var err error
+	switch KrakenChannel(msg.Channel) {
+	case KrakenTradeChannel:
+		marketData, symbol, err = parseKrakenTrades(msg)
+	case KrakenTickerChannel:
+		marketData, symbol, err = parseKrakenTicker(msg)
+	case KrakenBookChannel:
+		marketData, symbol, err = kc.applyBook(msg)
+	default:
+		return nil, "", errKrakenNoTick // Heartbeats and status messages
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if symbol == "" || marketData.Price <= 0 {
 		return nil, "", fmt.Errorf("invalid data in WebSocket message")
 	}
 
-	return &types.MarketData{
-		Price:  price,
-		Volume: volume,
-	}, tradingPair, nil
+	return marketData, kc.tradingPair(symbol), nil
+}
+
+// parseKrakenTrades combines the trades of a trade update into one tick. Snapshots repeat recent trades
+// that were already streamed, or that happened before the subscription, so they carry no tick.
+func parseKrakenTrades(msg krakenMessage) (*types.MarketData, string, error) {
+	if msg.Type != "update" {
+		return nil, "", errKrakenNoTick
+	}
+	var trades []krakenTrade
+	if err := json.Unmarshal(msg.Data, &trades); err != nil {
+		return nil, "", err
+	}
+	if len(trades) == 0 {
+		return nil, "", errKrakenNoTick
+	}
+
+	// Kraken sends the trades of one symbol per message; the tick takes its side and trade ID from the last one
+	marketData := &types.MarketData{}
+	for _, trade := range trades {
+		marketData.Price = trade.Price
+		marketData.Volume += trade.Qty
+		marketData.Time = krakenTime(trade.Timestamp)
+		marketData.Side = types.OrderSide(trade.Side)
+		marketData.TradeID = strconv.FormatInt(trade.TradeID, 10)
+	}
+	return marketData, trades[0].Symbol, nil
+}
+
+// parseKrakenTicker converts a ticker snapshot or update into a tick at the last trade price with the best bid and ask.
+func parseKrakenTicker(msg krakenMessage) (*types.MarketData, string, error) {
+	var tickers []krakenTicker
+	if err := json.Unmarshal(msg.Data, &tickers); err != nil {
+		return nil, "", err
+	}
+	if len(tickers) == 0 {
+		return nil, "", errKrakenNoTick
+	}
+	ticker := tickers[len(tickers)-1]
+	return &types.MarketData{Price: ticker.Last, Bid: ticker.Bid, Ask: ticker.Ask, Time: krakenTime(ticker.Timestamp)}, ticker.Symbol, nil
+}
+
+// applyBook applies a book snapshot or update to the local order book and returns a tick at its mid price.
+// A book that fails its checksum goes out of sync and its subscription is renewed for a new snapshot.
+func (kc *KrakenConnector) applyBook(msg krakenMessage) (*types.MarketData, string, error) {
+	entries, err := krakenBookEntries(msg)
+	if err != nil {
+		return nil, "", err
+	}
+
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	var marketData *types.MarketData
+	var symbol string
+	for _, entry := range entries {
+		update := entry.update(msg.Type == "snapshot")
+		book := kc.books[entry.Symbol]
+		if book == nil {
+			book = orderbook.NewBook(entry.Symbol, krakenBookDepth)
+			kc.books[entry.Symbol] = book
+		}
+		if err := book.Apply(update); err != nil || !book.Synced() {
+			continue // Updates are meaningless without the snapshot they follow
+		}
+		if !kc.verifyBook(book, entry, update.Snapshot) {
+			book.MarkOutOfSync()
+			go kc.resyncBook(entry.Symbol)
+			continue
+		}
+		top := book.Snapshot(1)
+		bid, _ := top.BestBid()
+		ask, _ := top.BestAsk()
+		marketData = &types.MarketData{Price: top.MidPrice(), Bid: bid.Price, Ask: ask.Price, Time: update.Time}
+		symbol = entry.Symbol
+	}
+	if marketData == nil {
+		return nil, "", errKrakenNoTick
+	}
+	return marketData, symbol, nil
+}
+
+// verifyBook compares the checksum of a book with the one Kraken sent with the entry applied to it. Prices
+// and quantities are formatted with the most decimals seen for the symbol, since Kraken sends numbers
+// rather than strings. If a snapshot fails, the decimals cannot be right, so the symbol is no longer
+// verified until the next connection. The caller must hold mu.
+func (kc *KrakenConnector) verifyBook(book *orderbook.Book, entry krakenBookEntry, snapshot bool) bool {
+	precision := kc.precisions[entry.Symbol]
+	for _, levels := range [][]krakenBookLevel{entry.Bids, entry.Asks} {
+		for _, level := range levels {
+			precision.price = max(precision.price, krakenDecimals(level.Price))
+			precision.qty = max(precision.qty, krakenDecimals(level.Qty))
+		}
+	}
+	kc.precisions[entry.Symbol] = precision
+
+	if entry.Checksum == nil || kc.unverified[entry.Symbol] {
+		return true
+	}
+	if krakenChecksum(book.Snapshot(krakenBookDepth), precision) == *entry.Checksum {
+		return true
+	}
+	if snapshot {
+		log.Printf("Kraken book snapshot of %s failed its checksum; not verifying its updates\n", entry.Symbol)
+		kc.unverified[entry.Symbol] = true
+		return true
+	}
+	log.Printf("Kraken book of %s failed its checksum; resubscribing\n", entry.Symbol)
+	return false
+}
+
+// resyncBook renews the book subscription of a symbol that failed its checksum.
+func (kc *KrakenConnector) resyncBook(symbol string) {
+	if err := kc.ResyncDepth(symbol); err != nil {
+		log.Printf("Failed to resync Kraken book of %s: %v\n", symbol, err)
+	}
+}
+
+// OrderBook returns a copy of the best levels of a trading pair's local order book, kept from its book
+// channel subscription, or nil if it has no synced book.
+func (kc *KrakenConnector) OrderBook(tradingPair string, levels int) *types.OrderBook {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	book := kc.books[krakenSymbol(tradingPair)]
+	if book == nil {
+		return nil
+	}
+	snapshot := book.Snapshot(levels)
+	if snapshot != nil {
+		snapshot.TradingPair = tradingPair
+	}
+	return snapshot
+}
+
+// krakenChecksum computes Kraken's CRC32 checksum of a book: the price and quantity of each of the best
+// asks then the best bids, without the decimal point or leading zeros, concatenated.
+func krakenChecksum(book *types.OrderBook, precision krakenPrecision) uint32 {
+	var builder strings.Builder
+	for _, side := range [][]types.PriceLevel{book.Asks, book.Bids} {
+		for i, level := range side {
+			if i == krakenBookDepth {
+				break
+			}
+			builder.WriteString(krakenChecksumValue(level.Price, precision.price))
+			builder.WriteString(krakenChecksumValue(level.Quantity, precision.qty))
+		}
+	}
+	return crc32.ChecksumIEEE([]byte(builder.String()))
+}
+
+// krakenChecksumValue formats a price or quantity for a checksum, e.g. 0.00500 with 5 decimals -> "500".
+func krakenChecksumValue(value float64, decimals int) string {
+	formatted := strconv.FormatFloat(value, 'f', decimals, 64)
+	return strings.TrimLeft(strings.Replace(formatted, ".", "", 1), "0")
+}
+
+// krakenDecimals returns the number of decimals a number was sent with, e.g. 2 for 0.50.
+func krakenDecimals(number json.Number) int {
+	text := string(number)
+	if strings.ContainsAny(text, "eE") {
+		value, _ := number.Float64()
+		text = strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		return len(text) - i - 1
+	}
+	return 0
+}
+
+// krakenBookEntries decodes the entries of a book message.
+func krakenBookEntries(msg krakenMessage) ([]krakenBookEntry, error) {
+	var entries []krakenBookEntry
+	if err := json.Unmarshal(msg.Data, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// update converts a book entry into an order book update for its Kraken symbol. Kraken does not number
+// book updates, so the update carries no sequence numbers.
+func (entry krakenBookEntry) update(snapshot bool) *types.OrderBookUpdate {
+	update := &types.OrderBookUpdate{
+		TradingPair: entry.Symbol,
+		Snapshot:    snapshot,
+		Bids:        make([]types.PriceLevel, 0, len(entry.Bids)),
+		Asks:        make([]types.PriceLevel, 0, len(entry.Asks)),
+		Time:        krakenTime(entry.Timestamp),
+	}
+	for _, level := range entry.Bids {
+		update.Bids = append(update.Bids, level.priceLevel())
+	}
+	for _, level := range entry.Asks {
+		update.Asks = append(update.Asks, level.priceLevel())
+	}
+	return update
+}
+
+// priceLevel converts a book level to a PriceLevel.
+func (level krakenBookLevel) priceLevel() types.PriceLevel {
+	price, _ := level.Price.Float64()
+	qty, _ := level.Qty.Float64()
+	return types.PriceLevel{Price: price, Quantity: qty}
+}
+
+// krakenBookUpdates converts the entries of a book message into order book updates for Kraken symbols.
+func krakenBookUpdates(msg krakenMessage) ([]*types.OrderBookUpdate, error) {
+	entries, err := krakenBookEntries(msg)
+	if err != nil {
+		return nil, err
+	}
+	updates := make([]*types.OrderBookUpdate, 0, len(entries))
+	for _, entry := range entries {
+		updates = append(updates, entry.update(msg.Type == "snapshot"))
+	}
+	return updates, nil
+}
+
+// StreamDepth passes the snapshots and updates of subscribed book channels to handler until StopStreaming.
+// The framework reads the connector's own books through OrderBook instead, which are verified against
+// Kraken's checksums.
+func (kc *KrakenConnector) StreamDepth(handler func(update *types.OrderBookUpdate)) error {
+	return kc.streamer.StreamMessages(func(url string, data []byte) {
+		var msg krakenMessage
+		if err := json.Unmarshal(data, &msg); err != nil || KrakenChannel(msg.Channel) != KrakenBookChannel {
+			return
+		}
+		updates, err := krakenBookUpdates(msg)
+		if err != nil {
+			return
+		}
+		for _, update := range updates {
+			update.TradingPair = kc.tradingPair(update.TradingPair)
+			handler(update)
+		}
+	})
+}
+
+// ResyncDepth renews a trading pair's book subscription, for which Kraken sends a new snapshot.
+func (kc *KrakenConnector) ResyncDepth(tradingPair string) error {
+	symbol := []string{krakenSymbol(tradingPair)}
+	if err := kc.sendIfConnected("unsubscribe", KrakenBookChannel, symbol); err != nil {
+		return err
+	}
+	return kc.sendIfConnected("subscribe", KrakenBookChannel, symbol)
+}
+
+// tradingPair returns the subscribed trading pair name of a Kraken symbol, or the symbol if it was not subscribed.
+func (kc *KrakenConnector) tradingPair(symbol string) string {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	if tradingPair, ok := kc.pairs[symbol]; ok {
+		return tradingPair
+	}
+	return symbol
+}
+
+// krakenTime converts an RFC 3339 timestamp to Unix milliseconds, using the receive time if it is missing or malformed.
+func krakenTime(timestamp string) int64 {
+	parsed, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return time.Now().UnixMilli()
+	}
+	return parsed.UnixMilli()
 }
 
 // ExecuteOrder places an order on Kraken with the specified type and side.
-func (kc *KrakenConnector) ExecuteOrder(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64) error {
-	return kc.executor.ExecuteOrder(orderType, side, tradingPair, amount, price)
+func (kc *KrakenConnector) ExecuteOrder(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64) (*types.Order, error) {
+	order, err := kc.executor.ExecuteOrder(orderType, side, tradingPair, amount, price)
+	return withTradingPair(order, tradingPair), err
+}
+
+// CancelOrder cancels an open order on Kraken.
+func (kc *KrakenConnector) CancelOrder(tradingPair, orderID string) (*types.Order, error) {
+	order, err := kc.executor.CancelOrder(tradingPair, orderID)
+	return withTradingPair(order, tradingPair), err
+}
+
+// AmendOrder replaces an open order on Kraken with a new amount and price.
+func (kc *KrakenConnector) AmendOrder(tradingPair, orderID string, amount, price float64) (*types.Order, error) {
+	order, err := kc.executor.AmendOrder(tradingPair, orderID, amount, price)
+	return withTradingPair(order, tradingPair), err
+}
+
+// GetOpenOrders lists the open orders of a trading pair on Kraken. Kraken lists the open orders of every
+// pair, so the orders of other pairs are left out.
+func (kc *KrakenConnector) GetOpenOrders(tradingPair string) ([]*types.Order, error) {
+	orders, err := kc.executor.GetOpenOrders(tradingPair)
+	if err != nil {
+		return nil, err
+	}
+	pairOrders := make([]*types.Order, 0, len(orders))
+	for _, order := range orders {
+		if krakenSamePair(order.TradingPair, tradingPair) {
+			pairOrders = append(pairOrders, withTradingPair(order, tradingPair))
+		}
+	}
+	return pairOrders, nil
+}
+
+// GetOrder looks up the current state of an order on Kraken, so fills of resting orders can be polled for.
+func (kc *KrakenConnector) GetOrder(tradingPair, orderID string) (*types.Order, error) {
+	order, err := kc.executor.GetOrder(tradingPair, orderID)
+	return withTradingPair(order, tradingPair), err
 }
 
 // krakenRequestFormatter formats requests for the Kraken REST API.
 // This function prepares the endpoint, HTTP method, and request body to place an order.
-func krakenRequestFormatter(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64) (string, string, interface{}, error) {
+func krakenRequestFormatter(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64, clientOrderID string) (string, string, interface{}, error) {
 	endpoint := "/0/private/AddOrder"
 	method := "POST"
-	orderData := map[string]interface{}{
-		"pair":      tradingPair,
-		"type":      string(side),      // "buy" or "sell"
-		"ordertype": string(orderType), // Order type, e.g., "market", "limit"
-		"volume":    amount,
+	params := url.Values{}
+	params.Set("pair", krakenRESTPair(tradingPair))
+	params.Set("type", string(side))           // "buy" or "sell"
+	params.Set("ordertype", string(orderType)) // Order type, e.g., "market", "limit"
+	params.Set("volume", formatDecimal(amount))
+	params.Set("cl_ord_id", clientOrderID)
+	setKrakenPrices(params, orderType, price)
+
+	return endpoint, method, params, nil
+}
+
+// setKrakenPrices sets the prices an order type requires. Every type but market needs a price: the limit price,
+// or the trigger price of stop-loss and take-profit orders. Their limit variants also need price2, the limit,
+// and are given the one price as both their trigger and their limit.
+func setKrakenPrices(params url.Values, orderType types.OrderType, price float64) {
+	if orderType != types.OrderTypeMarket {
+		params.Set("price", formatDecimal(price))
+	}
+	if triggeredOrder(orderType) && limitOrder(orderType) {
+		params.Set("price2", formatDecimal(price))
+	}
+}
+
+// krakenOrderResponse is the response returned by Kraken's AddOrder endpoint.
+type krakenOrderResponse struct {
+	Error  []string `json:"error"`
+	Result struct {
+		Descr struct {
+			Order string `json:"order"`
+		} `json:"descr"`
+		TxID []string `json:"txid"`
+	} `json:"result"`
+}
+
+// krakenOrderParser parses a Kraken AddOrder response into an Order.
+// Kraken reports failures in the error array with a 200 status code, so these become rejected orders.
+func krakenOrderParser(body []byte) (*types.Order, error) {
+	var resp krakenOrderResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	if err := krakenError(resp.Error); err != nil {
+		return &types.Order{Status: types.OrderStatusRejected}, err
+	}
+
+	order := &types.Order{Status: types.OrderStatusNew}
+	if len(resp.Result.TxID) > 0 {
+		order.ExchangeOrderID = resp.Result.TxID[0]
+	}
+	return order, nil
+}
+
+// krakenError converts Kraken's error array into an error, or nil if it is empty.
+func krakenError(errors []string) error {
+	if len(errors) == 0 {
+		return nil
+	}
+	return fmt.Errorf("kraken error: %s", strings.Join(errors, ", "))
+}
+
+// krakenCancelRequestFormatter formats a request to cancel an order on Kraken.
+func krakenCancelRequestFormatter(tradingPair, orderID string) (string, string, interface{}, error) {
+	return "/0/private/CancelOrder", "POST", url.Values{"txid": {orderID}}, nil
+}
+
+// krakenCancelParser parses a Kraken CancelOrder response.
+func krakenCancelParser(body []byte) (*types.Order, error) {
+	var resp struct {
+		Error  []string `json:"error"`
+		Result struct {
+			Count int `json:"count"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	if err := krakenError(resp.Error); err != nil {
+		return nil, err
+	}
+	if resp.Result.Count == 0 {
+		return nil, fmt.Errorf("kraken cancelled no orders")
+	}
+	return &types.Order{Status: types.OrderStatusCancelled}, nil
+}
+
+// krakenAmendRequestFormatter formats an EditOrder request, which cancels the original order and places a new one.
+func krakenAmendRequestFormatter(original *types.Order, amount, price float64, clientOrderID string) (string, string, interface{}, error) {
+	endpoint := "/0/private/EditOrder"
+	method := "POST"
+	params := url.Values{}
+	params.Set("txid", original.ExchangeOrderID)
+	params.Set("pair", krakenRESTPair(original.TradingPair))
+	params.Set("volume", formatDecimal(amount))
+	setKrakenPrices(params, original.Type, price)
+	return endpoint, method, params, nil
+}
+
+// krakenAmendParser parses a Kraken EditOrder response into the replacement Order.
+func krakenAmendParser(body []byte) (*types.Order, error) {
+	var resp struct {
+		Error  []string `json:"error"`
+		Result struct {
+			Status string `json:"status"`
+			TxID   string `json:"txid"`
+			Volume string `json:"volume"`
+			Price  string `json:"price"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
 	}
+	if err := krakenError(resp.Error); err != nil {
+		return &types.Order{Status: types.OrderStatusRejected}, err
+	}
+	return &types.Order{
+		ExchangeOrderID: resp.Result.TxID,
+		Status:          types.OrderStatusNew,
+		Quantity:        parseFloat(resp.Result.Volume),
+		Price:           parseFloat(resp.Result.Price),
+	}, nil
+}
+
+// krakenQueryOrderRequestFormatter formats a request to look up an order on Kraken.
+func krakenQueryOrderRequestFormatter(tradingPair, orderID string) (string, string, interface{}, error) {
+	params := url.Values{}
+	params.Set("txid", orderID)
+	return "/0/private/QueryOrders", "POST", params, nil
+}
 
-	// Include price for order types that require it (e.g., LIMIT)
-	if orderType == types.OrderTypeLimit || orderType == types.OrderTypeStopLossLimit || orderType == types.OrderTypeTakeProfitLimit {
-		orderData["price"] = price
+// krakenQueryOrderParser parses Kraken's QueryOrders response for a single order.
+func krakenQueryOrderParser(body []byte) (*types.Order, error) {
+	var resp struct {
+		Error  []string                   `json:"error"`
+		Result map[string]krakenOpenOrder `json:"result"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	if err := krakenError(resp.Error); err != nil {
+		return nil, err
+	}
+	for txid, entry := range resp.Result {
+		return entry.toOrder(txid), nil
 	}
+	return nil, fmt.Errorf("kraken returned no order")
+}
+
+// krakenOpenOrdersRequestFormatter formats a request to list open orders on Kraken.
+func krakenOpenOrdersRequestFormatter(tradingPair string) (string, string, interface{}, error) {
+	return "/0/private/OpenOrders", "POST", url.Values{}, nil
+}
 
-	return endpoint, method, orderData, nil
+// krakenOpenOrder is a single entry of Kraken's OpenOrders and QueryOrders responses.
+type krakenOpenOrder struct {
+	ClientOrderID string  `json:"cl_ord_id"`
+	Status        string  `json:"status"`
+	OpenTime      float64 `json:"opentm"`  // Unix seconds with fractional part
+	CloseTime     float64 `json:"closetm"` // Unix seconds with fractional part, set once the order is closed
+	Descr         struct {
+		Pair      string `json:"pair"`
+		Type      string `json:"type"`
+		OrderType string `json:"ordertype"`
+		Price     string `json:"price"`
+	} `json:"descr"`
+	Volume       string `json:"vol"`
+	VolumeExec   string `json:"vol_exec"`
+	Fee          string `json:"fee"`
+	AveragePrice string `json:"price"`
+}
+
+// krakenOrderStatuses maps the statuses of closed Kraken orders to OrderStatus. Pending and open orders are
+// new or partially filled, depending on their executed volume.
+var krakenOrderStatuses = map[string]types.OrderStatus{
+	"closed":   types.OrderStatusFilled,
+	"canceled": types.OrderStatusCancelled,
+	"expired":  types.OrderStatusCancelled,
+}
+
+// toOrder converts a Kraken order entry with its transaction ID into an Order.
+func (entry *krakenOpenOrder) toOrder(txid string) *types.Order {
+	order := &types.Order{
+		ClientOrderID:   entry.ClientOrderID,
+		ExchangeOrderID: txid,
+		TradingPair:     entry.Descr.Pair,
+		Type:            types.OrderType(entry.Descr.OrderType),
+		Side:            types.OrderSide(entry.Descr.Type),
+		Status:          types.OrderStatusNew,
+		Price:           parseFloat(entry.Descr.Price),
+		Quantity:        parseFloat(entry.Volume),
+		FilledQuantity:  parseFloat(entry.VolumeExec),
+		AveragePrice:    parseFloat(entry.AveragePrice),
+		Fee:             parseFloat(entry.Fee),
+		CreatedAt:       int64(entry.OpenTime * 1000),
+		UpdatedAt:       int64(entry.CloseTime * 1000),
+	}
+	if status, ok := krakenOrderStatuses[entry.Status]; ok {
+		order.Status = status
+	} else if order.FilledQuantity > 0 {
+		order.Status = types.OrderStatusPartiallyFilled
+	}
+	return order
+}
+
+// krakenOpenOrdersParser parses Kraken's OpenOrders response.
+func krakenOpenOrdersParser(body []byte) ([]*types.Order, error) {
+	var resp struct {
+		Error  []string `json:"error"`
+		Result struct {
+			Open map[string]krakenOpenOrder `json:"open"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	if err := krakenError(resp.Error); err != nil {
+		return nil, err
+	}
+
+	orders := make([]*types.Order, 0, len(resp.Result.Open))
+	for txid, open := range resp.Result.Open {
+		orders = append(orders, open.toOrder(txid))
+	}
+	// Map iteration order is random, so return the oldest orders first
+	sort.Slice(orders, func(i, j int) bool { return orders[i].CreatedAt < orders[j].CreatedAt })
+	return orders, nil
 }
@@ -0,0 +1,168 @@
+package connectors
+
+import (
+	"github.com/bigmeech/tradingbot/clients"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKrakenSigner_Signature(t *testing.T) {
+	// Example from the Kraken API documentation
+	signer := NewKrakenSigner("key", "kQH5HW/8p1uGOVjbgWA7FunAmGO8lsSUXNsu3eow76sz84Q18fWxnyRzBHCd3pd5nE9qa99HAZtuZuj6F1huXg==")
+	signature, err := signer.signature("/0/private/AddOrder", "1616492376594", "nonce=1616492376594&ordertype=limit&pair=XBTUSD&price=37500&type=buy&volume=1.25")
+	if err != nil {
+		t.Fatalf("Expected signing to succeed, got %v", err)
+	}
+	if want := "4/dpxb3iT4tp/ZCVEwSnEsLxx0bqyhLpdfOpc6fn7OR8+UClSV5n9E6aSS8MPtnRfp32bAb0nmbRn6H8ndwLUQ=="; signature != want {
+		t.Errorf("Expected signature %s, got %s", want, signature)
+	}
+}
+
+func TestKrakenSigner_Sign(t *testing.T) {
+	signer := NewKrakenSigner("key", "c2VjcmV0")
+	signer.now = func() time.Time { return time.UnixMilli(1700000000000) }
+
+	// The nonce is added to the form body and increases even when the clock does not
+	req := &clients.Request{Method: "POST", URL: mustParseURL(t, "https://api.kraken.com/0/private/CancelOrder"), Header: make(http.Header), Body: []byte("txid=OABC")}
+	if err := signer.Sign(req); err != nil {
+		t.Fatalf("Expected signing to succeed, got %v", err)
+	}
+	if string(req.Body) != "txid=OABC&nonce=1700000000000" {
+		t.Errorf("Unexpected body %s", req.Body)
+	}
+	want, _ := signer.signature("/0/private/CancelOrder", "1700000000000", string(req.Body))
+	if req.Header.Get("API-Key") != "key" || req.Header.Get("API-Sign") != want {
+		t.Errorf("Unexpected headers %v", req.Header)
+	}
+
+	req = &clients.Request{Method: "POST", URL: mustParseURL(t, "https://api.kraken.com/0/private/OpenOrders"), Header: make(http.Header)}
+	if err := signer.Sign(req); err != nil {
+		t.Fatalf("Expected signing to succeed, got %v", err)
+	}
+	if string(req.Body) != "nonce=1700000000001" || req.Header.Get("Content-Type") != clients.ContentTypeForm {
+		t.Errorf("Expected a form body with the next nonce, got %s %v", req.Body, req.Header)
+	}
+
+	// Public endpoints are not signed
+	req = &clients.Request{Method: "GET", URL: mustParseURL(t, "https://api.kraken.com/0/public/Ticker?pair=XBTUSD"), Header: make(http.Header)}
+	if err := signer.Sign(req); err != nil || req.Header.Get("API-Sign") != "" || len(req.Body) != 0 {
+		t.Errorf("Expected an unsigned public request, got %v %s %v", req.Header, req.Body, err)
+	}
+
+	for _, secret := range []string{"", "not base64!"} {
+		req := &clients.Request{URL: mustParseURL(t, "https://api.kraken.com/0/private/AddOrder"), Header: make(http.Header)}
+		if err := NewKrakenSigner("key", secret).Sign(req); err == nil {
+			t.Errorf("Expected signing with secret %q to fail", secret)
+		}
+	}
+}
+
+func TestKrakenConnector_SignedOrder(t *testing.T) {
+	const apiKey, apiSecret = "test-key", "dGVzdC1zZWNyZXQ="
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		params, _ := url.ParseQuery(string(body))
+
+		// Verify the signature as Kraken does
+		want, _ := NewKrakenSigner(apiKey, apiSecret).signature(r.URL.Path, params.Get("nonce"), string(body))
+		if r.Header.Get("API-Key") != apiKey || r.Header.Get("API-Sign") != want {
+			w.Write([]byte(`{"error":["EAPI:Invalid signature"]}`))
+			return
+		}
+		if r.Header.Get("Content-Type") != clients.ContentTypeForm || r.URL.Path != "/0/private/AddOrder" {
+			w.Write([]byte(`{"error":["EGeneral:Invalid arguments"]}`))
+			return
+		}
+		if params.Get("pair") != "BTCUSD" || params.Get("type") != "buy" || params.Get("ordertype") != "limit" || params.Get("volume") != "0.25" || params.Get("price") != "35000.5" {
+			w.Write([]byte(`{"error":["EGeneral:Invalid arguments"]}`))
+			return
+		}
+		w.Write([]byte(`{"error":[],"result":{"descr":{"order":"buy 0.25 XBTUSD @ limit 35000.5"},"txid":["OU22CG-KLAF2-FWUDD7"]}}`))
+	}))
+	defer server.Close()
+
+	connector := NewKrakenConnector("ws://127.0.0.1:0/v2", server.URL, apiKey, apiSecret)
+	order, err := connector.ExecuteOrder(types.OrderTypeLimit, types.OrderSideBuy, "BTC/USD", 0.25, 35000.5)
+	if err != nil {
+		t.Fatalf("Expected the signed order to be accepted, got %v", err)
+	}
+	if order.ExchangeOrderID != "OU22CG-KLAF2-FWUDD7" || order.Status != types.OrderStatusNew || order.TradingPair != "BTC/USD" {
+		t.Errorf("Unexpected order %+v", order)
+	}
+
+	// A wrong secret is rejected by the server
+	connector = NewKrakenConnector("ws://127.0.0.1:0/v2", server.URL, apiKey, "d3Jvbmc=")
+	if _, err := connector.ExecuteOrder(types.OrderTypeLimit, types.OrderSideBuy, "BTC/USD", 0.25, 35000.5); err == nil || !strings.Contains(err.Error(), "Invalid signature") {
+		t.Errorf("Expected an invalid signature error, got %v", err)
+	}
+}
+
+func TestKrakenRequestFormatter(t *testing.T) {
+	tests := []struct {
+		orderType types.OrderType
+		price     string // Expected price parameter, empty if it must be omitted
+		price2    string // Expected limit of triggered limit orders, empty if it must be omitted
+	}{
+		{types.OrderTypeMarket, "", ""},
+		{types.OrderTypeLimit, "35000.5", ""},
+		{types.OrderTypeStopLoss, "35000.5", ""},
+		{types.OrderTypeStopLossLimit, "35000.5", "35000.5"},
+		{types.OrderTypeTakeProfit, "35000.5", ""},
+		{types.OrderTypeTakeProfitLimit, "35000.5", "35000.5"},
+	}
+	for _, test := range tests {
+		endpoint, method, body, err := krakenRequestFormatter(test.orderType, types.OrderSideSell, "BTC/USD", 0.25, 35000.5, "tb0011223344556677")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.orderType, err)
+		}
+		params := body.(url.Values)
+		if endpoint != "/0/private/AddOrder" || method != "POST" || params.Get("ordertype") != string(test.orderType) || params.Get("volume") != "0.25" {
+			t.Errorf("%s: unexpected request %s %s %v", test.orderType, method, endpoint, params)
+		}
+		if _, ok := params["price"]; params.Get("price") != test.price || ok != (test.price != "") {
+			t.Errorf("%s: expected price %q, got %v", test.orderType, test.price, params)
+		}
+		if _, ok := params["price2"]; params.Get("price2") != test.price2 || ok != (test.price2 != "") {
+			t.Errorf("%s: expected price2 %q, got %v", test.orderType, test.price2, params)
+		}
+
+		// Amendments keep the original's type, so they send the same prices
+		original := &types.Order{ExchangeOrderID: "OQCLML-BW3P3-BUCMWZ", TradingPair: "BTC/USD", Type: test.orderType}
+		_, _, body, err = krakenAmendRequestFormatter(original, 0.5, 35000.5, "")
+		if amend := body.(url.Values); err != nil || amend.Get("price") != test.price || amend.Get("price2") != test.price2 {
+			t.Errorf("%s: expected amended prices %q/%q, got %v, err %v", test.orderType, test.price, test.price2, amend, err)
+		}
+	}
+}
+
+func TestKrakenConnector_GetOpenOrdersFiltersByPair(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"error":[],"result":{"open":{
+			"OQCLML-BW3P3-BUCMWZ": {"status": "open", "opentm": 1688666559.8974,
+				"descr": {"pair": "XBTUSD", "type": "buy", "ordertype": "limit", "price": "30010.0"}, "vol": "1.25", "vol_exec": "0"},
+			"OB5VMB-B4U2U-DK2WRW": {"status": "open", "opentm": 1688665899.5699,
+				"descr": {"pair": "ETHUSD", "type": "sell", "ordertype": "limit", "price": "2000.0"}, "vol": "0.5", "vol_exec": "0"}
+		}}}`))
+	}))
+	defer server.Close()
+
+	connector := NewKrakenConnector("ws://127.0.0.1:0/v2", server.URL, "key", "dGVzdC1zZWNyZXQ=")
+	orders, err := connector.GetOpenOrders("BTC/USD")
+	if err != nil {
+		t.Fatalf("Expected open orders, got %v", err)
+	}
+	if len(orders) != 1 || orders[0].ExchangeOrderID != "OQCLML-BW3P3-BUCMWZ" || orders[0].TradingPair != "BTC/USD" {
+		t.Errorf("Expected only the BTC/USD order under the requested pair name, got %+v", orders)
+	}
+
+	orders, err = connector.GetOpenOrders("eth-usd")
+	if err != nil || len(orders) != 1 || orders[0].ExchangeOrderID != "OB5VMB-B4U2U-DK2WRW" || orders[0].TradingPair != "eth-usd" {
+		t.Errorf("Expected only the eth-usd order, got %+v, %v", orders, err)
+	}
+}
@@ -0,0 +1,233 @@
+package connectors
+
+import (
+	"errors"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"github.com/gorilla/websocket"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKrakenConnector_ParseMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		symbol  string
+		want    types.MarketData
+		noTick  bool
+	}{
+		{
+			name:    "trade update",
+			message: `{"channel":"trade","type":"update","data":[{"symbol":"BTC/USD","side":"buy","price":35000.1,"qty":0.5,"ord_type":"market","trade_id":1,"timestamp":"2023-11-14T22:13:20.123Z"},{"symbol":"BTC/USD","side":"sell","price":35000.2,"qty":0.25,"ord_type":"limit","trade_id":2,"timestamp":"2023-11-14T22:13:20.456Z"}]}`,
+			symbol:  "BTC/USD",
+			want:    types.MarketData{Price: 35000.2, Volume: 0.75, Time: 1700000000456, Side: types.OrderSideSell, TradeID: "2"},
+		},
+		{
+			name:    "trade snapshot",
+			message: `{"channel":"trade","type":"snapshot","data":[{"symbol":"BTC/USD","price":34000,"qty":1,"timestamp":"2023-11-14T22:00:00Z"}]}`,
+			noTick:  true,
+		},
+		{
+			name:    "ticker",
+			message: `{"channel":"ticker","type":"update","data":[{"symbol":"ETH/USD","bid":1999.5,"bid_qty":3,"ask":2000.5,"ask_qty":2,"last":2000.1,"volume":1500,"timestamp":"2023-11-14T22:13:20Z"}]}`,
+			symbol:  "ETH/USD",
+			want:    types.MarketData{Price: 2000.1, Bid: 1999.5, Ask: 2000.5, Time: 1700000000000},
+		},
+		{
+			name:    "subscription acknowledgement",
+			message: `{"method":"subscribe","req_id":1,"result":{"channel":"trade","symbol":"BTC/USD"},"success":true}`,
+			noTick:  true,
+		},
+		{
+			name:    "heartbeat",
+			message: `{"channel":"heartbeat"}`,
+			noTick:  true,
+		},
+		{
+			name:    "book update without a snapshot",
+			message: `{"channel":"book","type":"update","data":[{"symbol":"BTC/USD","bids":[{"price":34999,"qty":1}],"asks":[],"timestamp":"2023-11-14T22:13:20Z"}]}`,
+			noTick:  true,
+		},
+	}
+
+	connector := NewKrakenConnector("ws://127.0.0.1:0/v2", "http://127.0.0.1:0", "key", "")
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			marketData, symbol, err := connector.parseMessage([]byte(test.message))
+			if test.noTick {
+				if !errors.Is(err, errKrakenNoTick) {
+					t.Errorf("Expected no tick, got %+v, %v", marketData, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if symbol != test.symbol || *marketData != test.want {
+				t.Errorf("Expected %s %+v, got %s %+v", test.symbol, test.want, symbol, *marketData)
+			}
+		})
+	}
+}
+
+func TestKrakenConnector_ParseBook(t *testing.T) {
+	connector := NewKrakenConnector("ws://127.0.0.1:0/v2", "http://127.0.0.1:0", "key", "")
+	connector.Subscribe(KrakenBookChannel, "xbt-usd")
+
+	marketData, symbol, err := connector.parseMessage([]byte(`{"channel":"book","type":"snapshot","data":[{"symbol":"BTC/USD","bids":[{"price":34999.0,"qty":1.50000000},{"price":34998.5,"qty":2.00000000}],"asks":[{"price":35001.0,"qty":0.50000000},{"price":35002.5,"qty":3.00000000}],"checksum":3482135444}]}`))
+	if err != nil || symbol != "xbt-usd" || marketData.Price != 35000 {
+		t.Fatalf("Expected xbt-usd at the 35000 mid price, got %s %+v, %v", symbol, marketData, err)
+	}
+
+	// Removing the best ask moves the mid price to the next level
+	marketData, _, err = connector.parseMessage([]byte(`{"channel":"book","type":"update","data":[{"symbol":"BTC/USD","bids":[],"asks":[{"price":35001.0,"qty":0.00000000}],"checksum":3875716243,"timestamp":"2023-11-14T22:13:20Z"}]}`))
+	if err != nil || marketData.Price != 35000.75 || marketData.Bid != 34999 || marketData.Ask != 35002.5 || marketData.Time != 1700000000000 {
+		t.Errorf("Expected the 35000.75 mid price, got %+v, %v", marketData, err)
+	}
+	if book := connector.OrderBook("xbt-usd", 0); book == nil || book.TradingPair != "xbt-usd" || len(book.Asks) != 1 || len(book.Bids) != 2 {
+		t.Errorf("Expected the book under the subscribed name, got %+v", book)
+	}
+}
+
+func TestKrakenConnector_BookChecksumMismatch(t *testing.T) {
+	connector := NewKrakenConnector("ws://127.0.0.1:0/v2", "http://127.0.0.1:0", "key", "")
+	connector.Subscribe(KrakenBookChannel, "BTC/USD")
+	snapshot := []byte(`{"channel":"book","type":"snapshot","data":[{"symbol":"BTC/USD","bids":[{"price":34999,"qty":1},{"price":34998,"qty":2}],"asks":[{"price":35001,"qty":1},{"price":35002,"qty":3}],"checksum":756306020}]}`)
+	if _, _, err := connector.parseMessage(snapshot); err != nil {
+		t.Fatalf("Expected the snapshot to apply, got %v", err)
+	}
+
+	// An update whose checksum does not match means a change was missed, so the book waits for a new snapshot
+	_, _, err := connector.parseMessage([]byte(`{"channel":"book","type":"update","data":[{"symbol":"BTC/USD","bids":[{"price":34997,"qty":4}],"asks":[],"checksum":1}]}`))
+	if !errors.Is(err, errKrakenNoTick) {
+		t.Errorf("Expected no tick from a book that failed its checksum, got %v", err)
+	}
+	if book := connector.OrderBook("BTC/USD", 0); book != nil {
+		t.Errorf("Expected the book to be out of sync, got %+v", book)
+	}
+	if _, _, err := connector.parseMessage(snapshot); err != nil || connector.OrderBook("BTC/USD", 0) == nil {
+		t.Errorf("Expected the new snapshot to sync the book, got %v", err)
+	}
+}
+
+func TestKrakenChecksum(t *testing.T) {
+	book := &types.OrderBook{
+		Bids: []types.PriceLevel{{Price: 0.05005, Quantity: 0.5}, {Price: 0.05004, Quantity: 12}},
+		Asks: []types.PriceLevel{{Price: 0.05006, Quantity: 0.000005}},
+	}
+	want := crc32.ChecksumIEEE([]byte("5006" + "500" + "5005" + "50000000" + "5004" + "1200000000"))
+	if got := krakenChecksum(book, krakenPrecision{price: 5, qty: 8}); got != want {
+		t.Errorf("Expected checksum %d, got %d", want, got)
+	}
+	if krakenDecimals("0.50000000") != 8 || krakenDecimals("12") != 0 || krakenDecimals("5e-06") != 6 {
+		t.Error("Expected the decimals numbers were sent with")
+	}
+}
+
+func TestKrakenConnector_ParseError(t *testing.T) {
+	connector := NewKrakenConnector("ws://127.0.0.1:0/v2", "http://127.0.0.1:0", "key", "")
+	_, _, err := connector.parseMessage([]byte(`{"error":"Currency pair not supported DOGE/XYZ","method":"subscribe","req_id":4,"success":false}`))
+	if err == nil || !strings.Contains(err.Error(), "Currency pair not supported") {
+		t.Errorf("Expected the Kraken error, got %v", err)
+	}
+}
+
+func TestKrakenConnector_StreamsSubscribedTrades(t *testing.T) {
+	requests := make(chan krakenSubscriptionRequest, 4)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Failed to upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		// Acknowledge each request, then send a trade for subscriptions
+		for {
+			var request krakenSubscriptionRequest
+			if err := conn.ReadJSON(&request); err != nil {
+				return
+			}
+			requests <- request
+			conn.WriteJSON(map[string]interface{}{"method": request.Method, "req_id": request.ReqID, "success": true})
+			if request.Method == "subscribe" {
+				conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"trade","type":"update","data":[{"symbol":"BTC/USD","side":"buy","price":35000.1,"qty":0.5,"trade_id":1,"timestamp":"2023-11-14T22:13:20.123Z"}]}`))
+			}
+		}
+	}))
+	defer server.Close()
+
+	connector := NewKrakenConnector("ws"+strings.TrimPrefix(server.URL, "http")+"/v2", server.URL, "key", "")
+	if err := connector.Subscribe(KrakenTradeChannel, "btc-usd"); err != nil {
+		t.Fatalf("Expected subscribing before connecting to succeed, got %v", err)
+	}
+
+	ticks := make(chan *types.TickContext, 1)
+	if err := connector.StreamMarketData(func(ctx *types.TickContext) { ticks <- ctx }); err != nil {
+		t.Fatalf("Expected streaming to start, got %v", err)
+	}
+	defer connector.StopStreaming()
+
+	select {
+	case request := <-requests:
+		if request.Method != "subscribe" || request.Params.Channel != KrakenTradeChannel || len(request.Params.Symbol) != 1 || request.Params.Symbol[0] != "BTC/USD" {
+			t.Errorf("Expected a trade subscription for BTC/USD, got %+v", request)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a subscription request on connect")
+	}
+
+	select {
+	case tick := <-ticks:
+		if tick.TradingPair != "btc-usd" {
+			t.Errorf("Expected the tick under the subscribed name btc-usd, got %s", tick.TradingPair)
+		}
+		if tick.MarketData.Price != 35000.1 || tick.MarketData.Volume != 0.5 || tick.MarketData.Time != 1700000000123 {
+			t.Errorf("Unexpected market data %+v", tick.MarketData)
+		}
+		if tick.ExecuteOrder == nil {
+			t.Error("Expected ExecuteOrder to be bound")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a trade tick")
+	}
+
+	// Unsubscribing while connected sends the request straight away
+	if err := connector.Unsubscribe(KrakenTradeChannel, "BTC/USD"); err != nil {
+		t.Fatalf("Expected unsubscribing to succeed, got %v", err)
+	}
+	select {
+	case request := <-requests:
+		if request.Method != "unsubscribe" || request.Params.Channel != KrakenTradeChannel || request.Params.Symbol[0] != "BTC/USD" {
+			t.Errorf("Expected an unsubscribe for BTC/USD trades, got %+v", request)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected an unsubscribe request")
+	}
+	if len(connector.Subscriptions()) != 0 {
+		t.Errorf("Expected no subscriptions left, got %v", connector.Subscriptions())
+	}
+}
+
+func TestKrakenBookUpdates(t *testing.T) {
+	updates, err := krakenBookUpdates(krakenMessage{
+		Channel: "book",
+		Type:    "update",
+		Data:    []byte(`[{"symbol":"BTC/USD","bids":[{"price":34999,"qty":0}],"asks":[{"price":35002,"qty":1.5}],"checksum":3,"timestamp":"2023-11-14T22:13:20Z"}]`),
+	})
+	if err != nil || len(updates) != 1 {
+		t.Fatalf("Expected one update, got %+v, %v", updates, err)
+	}
+	update := updates[0]
+	if update.Snapshot || update.TradingPair != "BTC/USD" || update.Time != 1700000000000 {
+		t.Errorf("Unexpected update %+v", update)
+	}
+	if len(update.Bids) != 1 || update.Bids[0] != (types.PriceLevel{Price: 34999}) || update.Asks[0] != (types.PriceLevel{Price: 35002, Quantity: 1.5}) {
+		t.Errorf("Unexpected levels %+v %+v", update.Bids, update.Asks)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/bigmeech/tradingbot/clients"
 	"github.com/bigmeech/tradingbot/pkg/types"
 	"log"
+	"net/url"
 	"time"
 )
 
@@ -38,7 +39,18 @@ func NewLocalConnector(wsURL, restURL, apiKey string) *LocalConnector {
 
 	// Set up RestExecutor with a REST client for local order execution
 	restClient := clients.NewRestClient(restURL, apiKey)
-	executor := adapters.NewRestExecutor(restClient, localRequestFormatter)
+	executor := adapters.NewRestExecutor(restClient, adapters.ExchangeAPI{
+		FormatOrder:      localRequestFormatter,
+		ParseOrder:       localOrderParser,
+		FormatCancel:     localCancelRequestFormatter,
+		ParseCancel:      localOrderParser,
+		FormatAmend:      localAmendRequestFormatter,
+		ParseAmend:       localOrderParser,
+		FormatOpenOrders: localOpenOrdersRequestFormatter,
+		ParseOpenOrders:  localOpenOrdersParser,
+		FormatQueryOrder: localQueryOrderRequestFormatter,
+		ParseQueryOrder:  localOrderParser,
+	})
 
 	return &LocalConnector{
 		streamer: streamer,
@@ -50,7 +62,7 @@ func NewLocalConnector(wsURL, restURL, apiKey string) *LocalConnector {
 func (lc *LocalConnector) StreamMarketData(handler func(ctx *types.TickContext)) error {
 	return lc.streamer.StartStreaming(func(ctx *types.TickContext) {
 		// Wrap ExecuteOrder function in TickContext
-		ctx.ExecuteOrder = func(orderType types.OrderType, side types.OrderSide, amount, price float64) error {
+		ctx.ExecuteOrder = func(orderType types.OrderType, side types.OrderSide, amount, price float64) (*types.Order, error) {
 			return lc.ExecuteOrder(orderType, side, ctx.TradingPair, amount, price)
 		}
 		handler(ctx)
@@ -63,38 +75,71 @@ func (lc *LocalConnector) StopStreaming() error {
 }
 
 // ExecuteOrder places an order locally with the specified type and side.
-func (lc *LocalConnector) ExecuteOrder(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64) error {
+func (lc *LocalConnector) ExecuteOrder(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64) (*types.Order, error) {
 	return lc.executor.ExecuteOrder(orderType, side, tradingPair, amount, price)
 }
 
+// CancelOrder cancels an open order on the local exchange.
+func (lc *LocalConnector) CancelOrder(tradingPair, orderID string) (*types.Order, error) {
+	return lc.executor.CancelOrder(tradingPair, orderID)
+}
+
+// AmendOrder replaces an open order on the local exchange with a new amount and price.
+func (lc *LocalConnector) AmendOrder(tradingPair, orderID string, amount, price float64) (*types.Order, error) {
+	return lc.executor.AmendOrder(tradingPair, orderID, amount, price)
+}
+
+// GetOpenOrders lists the open orders on the local exchange for a trading pair.
+func (lc *LocalConnector) GetOpenOrders(tradingPair string) ([]*types.Order, error) {
+	return lc.executor.GetOpenOrders(tradingPair)
+}
+
+// GetOrder looks up the current state of an order on the local exchange.
+func (lc *LocalConnector) GetOrder(tradingPair, orderID string) (*types.Order, error) {
+	return lc.executor.GetOrder(tradingPair, orderID)
+}
+
 // GetIdentifier returns the WebSocket URL as the unique identifier for LocalConnector.
 func (lc *LocalConnector) GetIdentifier() string {
 	return lc.streamer.Client.GetConnectionUrl()
 }
 
+// localMessage is a tick sent by the local server. Only price, volume and symbol are required.
+type localMessage struct {
+	Symbol  string  `json:"symbol"`
+	Price   float64 `json:"price"`
+	Volume  float64 `json:"volume"`
+	Time    int64   `json:"time"` // Unix milliseconds
+	Bid     float64 `json:"bid"`
+	Ask     float64 `json:"ask"`
+	Side    string  `json:"side"`
+	TradeID string  `json:"trade_id"`
+}
+
 // localMessageParser parses WebSocket messages from the local server into MarketData.
 func localMessageParser(message []byte) (*types.MarketData, string, error) {
-	var parsedData map[string]interface{}
+	var parsedData localMessage
 	if err := json.Unmarshal(message, &parsedData); err != nil {
 		return nil, "", err
 	}
 
-	price, _ := parsedData["price"].(float64)
-	volume, _ := parsedData["volume"].(float64)
-	tradingPair, _ := parsedData["symbol"].(string)
-
-	if price == 0 || volume == 0 {
+	if parsedData.Price == 0 || parsedData.Volume == 0 {
 		return nil, "", fmt.Errorf("invalid data in WebSocket message")
 	}
 
 	return &types.MarketData{
-		Price:  price,
-		Volume: volume,
-	}, tradingPair, nil
+		Price:   parsedData.Price,
+		Volume:  parsedData.Volume,
+		Time:    parsedData.Time,
+		Bid:     parsedData.Bid,
+		Ask:     parsedData.Ask,
+		Side:    types.OrderSide(parsedData.Side),
+		TradeID: parsedData.TradeID,
+	}, parsedData.Symbol, nil
 }
 
 // localRequestFormatter formats requests for the local REST API.
-func localRequestFormatter(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64) (string, string, interface{}, error) {
+func localRequestFormatter(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64, clientOrderID string) (string, string, interface{}, error) {
 	endpoint := "/api/v1/order"
 	method := "POST"
 	orderData := map[string]interface{}{
@@ -102,12 +147,108 @@ func localRequestFormatter(orderType types.OrderType, side types.OrderSide, trad
 		"side":     string(side),      // Use "BUY" or "SELL"
 		"type":     string(orderType), // Order type (e.g., "MARKET", "LIMIT")
 		"quantity": amount,
+
+		"client_order_id": clientOrderID,
 	}
 
-	// Include price for order types that require it (e.g., LIMIT)
-	if orderType == types.OrderTypeLimit || orderType == types.OrderTypeStopLossLimit || orderType == types.OrderTypeTakeProfitLimit {
+	// Stop-loss and take-profit orders trigger at the stop price; limit orders and limit variants rest at the price
+	if triggeredOrder(orderType) {
+		orderData["stop_price"] = price
+	}
+	if limitOrder(orderType) {
 		orderData["price"] = price
 	}
 
 	return endpoint, method, orderData, nil
 }
+
+// localOrderResponse is the order representation returned by the local REST API.
+type localOrderResponse struct {
+	OrderID        string  `json:"order_id"`
+	ClientOrderID  string  `json:"client_order_id"`
+	Symbol         string  `json:"symbol"`
+	Side           string  `json:"side"`
+	Type           string  `json:"type"`
+	Status         string  `json:"status"` // One of the OrderStatus values, e.g. "filled"
+	Price          float64 `json:"price"`
+	Quantity       float64 `json:"quantity"`
+	FilledQuantity float64 `json:"filled_quantity"`
+	AveragePrice   float64 `json:"average_price"`
+	Fee            float64 `json:"fee"`
+}
+
+// toOrder converts a local REST API order into an Order.
+func (resp *localOrderResponse) toOrder() *types.Order {
+	return &types.Order{
+		ExchangeOrderID: resp.OrderID,
+		ClientOrderID:   resp.ClientOrderID,
+		TradingPair:     resp.Symbol,
+		Side:            types.OrderSide(resp.Side),
+		Type:            types.OrderType(resp.Type),
+		Status:          types.OrderStatus(resp.Status),
+		Price:           resp.Price,
+		Quantity:        resp.Quantity,
+		FilledQuantity:  resp.FilledQuantity,
+		AveragePrice:    resp.AveragePrice,
+		Fee:             resp.Fee,
+	}
+}
+
+// localOrderParser parses a local REST API order response into an Order.
+func localOrderParser(body []byte) (*types.Order, error) {
+	var resp localOrderResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	return resp.toOrder(), nil
+}
+
+// localCancelRequestFormatter formats a request to cancel an order on the local REST API.
+func localCancelRequestFormatter(tradingPair, orderID string) (string, string, interface{}, error) {
+	query := url.Values{}
+	query.Set("symbol", tradingPair)
+	query.Set("order_id", orderID)
+	return "/api/v1/order?" + query.Encode(), "DELETE", nil, nil
+}
+
+// localQueryOrderRequestFormatter formats a request to look up an order on the local REST API.
+func localQueryOrderRequestFormatter(tradingPair, orderID string) (string, string, interface{}, error) {
+	query := url.Values{}
+	query.Set("symbol", tradingPair)
+	query.Set("order_id", orderID)
+	return "/api/v1/order?" + query.Encode(), "GET", nil, nil
+}
+
+// localAmendRequestFormatter formats a request to replace an order on the local REST API.
+func localAmendRequestFormatter(original *types.Order, amount, price float64, clientOrderID string) (string, string, interface{}, error) {
+	endpoint := "/api/v1/order"
+	method := "PUT"
+	orderData := map[string]interface{}{
+		"order_id":        original.ExchangeOrderID,
+		"symbol":          original.TradingPair,
+		"quantity":        amount,
+		"price":           price,
+		"client_order_id": clientOrderID,
+	}
+	return endpoint, method, orderData, nil
+}
+
+// localOpenOrdersRequestFormatter formats a request to list open orders on the local REST API.
+func localOpenOrdersRequestFormatter(tradingPair string) (string, string, interface{}, error) {
+	query := url.Values{}
+	query.Set("symbol", tradingPair)
+	return "/api/v1/orders/open?" + query.Encode(), "GET", nil, nil
+}
+
+// localOpenOrdersParser parses the local REST API's open orders response.
+func localOpenOrdersParser(body []byte) ([]*types.Order, error) {
+	var resp []localOrderResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	orders := make([]*types.Order, len(resp))
+	for i := range resp {
+		orders[i] = resp[i].toOrder()
+	}
+	return orders, nil
+}
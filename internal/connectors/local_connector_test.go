@@ -0,0 +1,53 @@
+package connectors
+
+import (
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"testing"
+)
+
+func TestLocalMessageParser(t *testing.T) {
+	marketData, symbol, err := localMessageParser([]byte(`{"symbol":"BTC/USDT","price":35000,"volume":0.5,"time":1700000000000,"bid":34999,"ask":35001,"side":"buy","trade_id":"7"}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := types.MarketData{Price: 35000, Volume: 0.5, Time: 1700000000000, Bid: 34999, Ask: 35001, Side: types.OrderSideBuy, TradeID: "7"}
+	if symbol != "BTC/USDT" || *marketData != want {
+		t.Errorf("Expected BTC/USDT %+v, got %s %+v", want, symbol, *marketData)
+	}
+
+	// Only price, volume and symbol are required
+	if marketData, _, err := localMessageParser([]byte(`{"symbol":"BTC/USDT","price":35000,"volume":0.5}`)); err != nil || marketData.Time != 0 {
+		t.Errorf("Expected a tick without optional fields, got %+v, %v", marketData, err)
+	}
+	if _, _, err := localMessageParser([]byte(`{"symbol":"BTC/USDT","price":35000}`)); err == nil {
+		t.Error("Expected an error without a volume")
+	}
+}
+
+func TestLocalRequestFormatter(t *testing.T) {
+	tests := []struct {
+		orderType types.OrderType
+		stopPrice bool // Whether stop_price must be sent
+		price     bool // Whether price must be sent
+	}{
+		{types.OrderTypeMarket, false, false},
+		{types.OrderTypeLimit, false, true},
+		{types.OrderTypeStopLoss, true, false},
+		{types.OrderTypeStopLossLimit, true, true},
+		{types.OrderTypeTakeProfit, true, false},
+		{types.OrderTypeTakeProfitLimit, true, true},
+	}
+	for _, test := range tests {
+		_, _, body, err := localRequestFormatter(test.orderType, types.OrderSideBuy, "BTC/USDT", 0.5, 35000, "tb0011223344556677")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.orderType, err)
+		}
+		orderData := body.(map[string]interface{})
+		if stopPrice, ok := orderData["stop_price"]; ok != test.stopPrice || (ok && stopPrice != 35000.0) {
+			t.Errorf("%s: expected stop price %v, got %v", test.orderType, test.stopPrice, orderData)
+		}
+		if price, ok := orderData["price"]; ok != test.price || (ok && price != 35000.0) {
+			t.Errorf("%s: expected price %v, got %v", test.orderType, test.price, orderData)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package connectors
+
+import (
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"testing"
+)
+
+func TestBinanceOrderParser(t *testing.T) {
+	body := []byte(`{
+		"symbol": "BTCUSDT", "orderId": 28, "clientOrderId": "tb0011223344556677",
+		"transactTime": 1507725176595, "price": "0.00000000", "origQty": "3.00000000",
+		"executedQty": "3.00000000", "cummulativeQuoteQty": "12003.00000000", "status": "FILLED",
+		"type": "MARKET", "side": "BUY",
+		"fills": [
+			{"price": "4000.00000000", "qty": "1.00000000", "commission": "4.00000000", "commissionAsset": "USDT", "tradeId": 56},
+			{"price": "4001.50000000", "qty": "2.00000000", "commission": "8.00300000", "commissionAsset": "USDT", "tradeId": 57}
+		]
+	}`)
+
+	order, err := binanceOrderParser(body)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if order.ExchangeOrderID != "28" || order.ClientOrderID != "tb0011223344556677" || order.Status != types.OrderStatusFilled {
+		t.Errorf("Unexpected order identity %+v", order)
+	}
+	if order.FilledQuantity != 3 || order.AveragePrice != 4001 {
+		t.Errorf("Expected 3 filled at 4001, got %v at %v", order.FilledQuantity, order.AveragePrice)
+	}
+	if len(order.Fills) != 2 || order.Fills[1].TradeID != "57" || order.FeeAsset != "USDT" {
+		t.Errorf("Unexpected fills %+v", order.Fills)
+	}
+	if order.Fee < 12.003-1e-9 || order.Fee > 12.003+1e-9 {
+		t.Errorf("Expected total fee 12.003, got %v", order.Fee)
+	}
+}
+
+func TestKrakenOrderParser(t *testing.T) {
+	order, err := krakenOrderParser([]byte(`{"error":[],"result":{"descr":{"order":"buy 1.25 XBTUSD @ limit 27500.0"},"txid":["OU22CG-KLAF2-FWUDD7"]}}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if order.ExchangeOrderID != "OU22CG-KLAF2-FWUDD7" || order.Status != types.OrderStatusNew {
+		t.Errorf("Unexpected order %+v", order)
+	}
+
+	order, err = krakenOrderParser([]byte(`{"error":["EOrder:Insufficient funds"],"result":{}}`))
+	if err == nil {
+		t.Fatal("Expected an error for a Kraken error response")
+	}
+	if order == nil || order.Status != types.OrderStatusRejected {
+		t.Errorf("Expected a rejected order, got %+v", order)
+	}
+}
+
+func TestBinanceOpenOrdersParser(t *testing.T) {
+	body := []byte(`[{
+		"symbol": "LTCBTC", "orderId": 1, "clientOrderId": "myOrder1", "price": "0.1", "origQty": "1.0",
+		"executedQty": "0.5", "cummulativeQuoteQty": "0.05", "status": "PARTIALLY_FILLED",
+		"type": "STOP_LOSS_LIMIT", "side": "SELL", "time": 1499827319559, "updateTime": 1499827319560
+	}]`)
+
+	orders, err := binanceOpenOrdersParser(body)
+	if err != nil || len(orders) != 1 {
+		t.Fatalf("Expected 1 order, got %v, err %v", orders, err)
+	}
+	order := orders[0]
+	if order.Type != types.OrderTypeStopLossLimit || order.Side != types.OrderSideSell || order.Status != types.OrderStatusPartiallyFilled {
+		t.Errorf("Unexpected order %+v", order)
+	}
+	if order.CreatedAt != 1499827319559 || order.UpdatedAt != 1499827319560 || order.AveragePrice != 0.1 {
+		t.Errorf("Unexpected order timing or price %+v", order)
+	}
+}
+
+func TestKrakenOpenOrdersParser(t *testing.T) {
+	body := []byte(`{"error":[],"result":{"open":{
+		"OQCLML-BW3P3-BUCMWZ": {"cl_ord_id": "tbabc", "status": "open", "opentm": 1688666559.8974,
+			"descr": {"pair": "XBTUSD", "type": "buy", "ordertype": "limit", "price": "30010.0"},
+			"vol": "1.25000000", "vol_exec": "0.37500000", "fee": "0.00000", "price": "30010.0"},
+		"OB5VMB-B4U2U-DK2WRW": {"status": "open", "opentm": 1688665899.5699,
+			"descr": {"pair": "XBTUSD", "type": "sell", "ordertype": "take-profit", "price": "31000.0"},
+			"vol": "0.5", "vol_exec": "0", "fee": "0", "price": "0"}
+	}}}`)
+
+	orders, err := krakenOpenOrdersParser(body)
+	if err != nil || len(orders) != 2 {
+		t.Fatalf("Expected 2 orders, got %v, err %v", orders, err)
+	}
+	if orders[0].ExchangeOrderID != "OB5VMB-B4U2U-DK2WRW" || orders[0].Type != types.OrderTypeTakeProfit {
+		t.Errorf("Expected the oldest order first, got %+v", orders[0])
+	}
+	if orders[1].Status != types.OrderStatusPartiallyFilled || orders[1].FilledQuantity != 0.375 || orders[1].ClientOrderID != "tbabc" {
+		t.Errorf("Unexpected partially filled order %+v", orders[1])
+	}
+}
+
+func TestKrakenQueryOrderParser(t *testing.T) {
+	body := []byte(`{"error":[],"result":{
+		"OQCLML-BW3P3-BUCMWZ": {"cl_ord_id": "tbabc", "status": "closed", "opentm": 1688666559.8974, "closetm": 1688666600.5,
+			"descr": {"pair": "XBTUSD", "type": "buy", "ordertype": "limit", "price": "30010.0"},
+			"vol": "1.25000000", "vol_exec": "1.25000000", "fee": "0.5", "price": "30005.0"}
+	}}`)
+
+	order, err := krakenQueryOrderParser(body)
+	if err != nil {
+		t.Fatalf("Expected order to parse, got %v", err)
+	}
+	if order.ExchangeOrderID != "OQCLML-BW3P3-BUCMWZ" || order.Status != types.OrderStatusFilled || order.FilledQuantity != 1.25 {
+		t.Errorf("Expected a filled order, got %+v", order)
+	}
+	if order.AveragePrice != 30005 || order.UpdatedAt != 1688666600500 {
+		t.Errorf("Unexpected fill price or close time %+v", order)
+	}
+
+	if _, err := krakenQueryOrderParser([]byte(`{"error":[],"result":{}}`)); err == nil {
+		t.Error("Expected an empty result to fail")
+	}
+}
@@ -0,0 +1,89 @@
+package connectors
+
+import (
+	"fmt"
+	"github.com/bigmeech/tradingbot/internal/paper"
+	"github.com/bigmeech/tradingbot/pkg/types"
+)
+
+// PaperConnector streams market data from a real connector but routes orders to a simulated exchange,
+// so strategies can run against live prices without risking capital.
+type PaperConnector struct {
+	source   types.Connector
+	exchange *paper.Exchange
+}
+
+// NewPaperConnector wraps the streaming side of source with the given simulated exchange.
+// The source connector's own ExecuteOrder is never called.
+func NewPaperConnector(source types.Connector, exchange *paper.Exchange) *PaperConnector {
+	return &PaperConnector{
+		source:   source,
+		exchange: exchange,
+	}
+}
+
+// StreamMarketData streams ticks from the source connector, matching resting paper orders against each price.
+// Ticks without market data carry no price to match against, so they are skipped.
+func (pc *PaperConnector) StreamMarketData(handler func(ctx *types.TickContext)) error {
+	return pc.source.StreamMarketData(func(ctx *types.TickContext) {
+		if ctx.MarketData == nil {
+			return
+		}
+		pc.exchange.UpdatePrice(ctx.TradingPair, ctx.MarketData.Price, ctx.MarketData.Time)
+
+		// Replace the source's order execution with the simulated exchange
+		ctx.ExecuteOrder = func(orderType types.OrderType, side types.OrderSide, amount, price float64) (*types.Order, error) {
+			return pc.ExecuteOrder(orderType, side, ctx.TradingPair, amount, price)
+		}
+		handler(ctx)
+	})
+}
+
+// StopStreaming stops the source connector's data streaming.
+func (pc *PaperConnector) StopStreaming() error {
+	return pc.source.StopStreaming()
+}
+
+// ExecuteOrder places an order on the simulated exchange.
+func (pc *PaperConnector) ExecuteOrder(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64) (*types.Order, error) {
+	return pc.exchange.ExecuteOrder(orderType, side, tradingPair, amount, price)
+}
+
+// CancelOrder cancels a resting order on the simulated exchange.
+func (pc *PaperConnector) CancelOrder(tradingPair, orderID string) (*types.Order, error) {
+	return pc.exchange.CancelOrder(tradingPair, orderID)
+}
+
+// AmendOrder replaces a resting order on the simulated exchange with a new amount and price.
+func (pc *PaperConnector) AmendOrder(tradingPair, orderID string, amount, price float64) (*types.Order, error) {
+	return pc.exchange.AmendOrder(tradingPair, orderID, amount, price)
+}
+
+// GetOpenOrders lists the resting orders on the simulated exchange for a trading pair.
+func (pc *PaperConnector) GetOpenOrders(tradingPair string) ([]*types.Order, error) {
+	return pc.exchange.OpenOrders(tradingPair), nil
+}
+
+// GetOrder returns the current state of an order on the simulated exchange.
+func (pc *PaperConnector) GetOrder(tradingPair, orderID string) (*types.Order, error) {
+	order, ok := pc.exchange.Order(orderID)
+	if !ok || order.TradingPair != tradingPair {
+		return nil, fmt.Errorf("order %s is not known on %s", orderID, tradingPair)
+	}
+	return order, nil
+}
+
+// OnOrderUpdate sets the handler called with resting orders as the simulated exchange fills them.
+func (pc *PaperConnector) OnOrderUpdate(handler func(order *types.Order)) {
+	pc.exchange.OnOrderUpdate(handler)
+}
+
+// GetIdentifier returns the source connector's identifier so ticks resolve to the same market name.
+func (pc *PaperConnector) GetIdentifier() string {
+	return pc.source.GetIdentifier()
+}
+
+// Exchange returns the simulated exchange holding the paper balances and fills.
+func (pc *PaperConnector) Exchange() *paper.Exchange {
+	return pc.exchange
+}
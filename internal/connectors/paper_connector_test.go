@@ -0,0 +1,108 @@
+package connectors
+
+import (
+	"github.com/bigmeech/tradingbot/internal/paper"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"testing"
+)
+
+// stubConnector replays fixed ticks and fails the test if its own ExecuteOrder is used.
+type stubConnector struct {
+	t      *testing.T
+	prices []float64
+}
+
+func (s *stubConnector) StreamMarketData(handler func(ctx *types.TickContext)) error {
+	for i, price := range s.prices {
+		handler(&types.TickContext{
+			MarketUrl:   "stub://feed",
+			TradingPair: "BTC/USDT",
+			MarketData:  &types.MarketData{Price: price, Volume: 1, Time: int64(i)},
+			ExecuteOrder: func(orderType types.OrderType, side types.OrderSide, amount, price float64) (*types.Order, error) {
+				s.t.Error("Expected orders to be routed to the paper exchange")
+				return nil, nil
+			},
+		})
+	}
+	return nil
+}
+
+func (s *stubConnector) StopStreaming() error { return nil }
+
+func (s *stubConnector) ExecuteOrder(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64) (*types.Order, error) {
+	s.t.Error("Expected orders to be routed to the paper exchange")
+	return nil, nil
+}
+
+func (s *stubConnector) CancelOrder(tradingPair, orderID string) (*types.Order, error) {
+	s.t.Error("Expected cancellations to be routed to the paper exchange")
+	return nil, nil
+}
+
+func (s *stubConnector) AmendOrder(tradingPair, orderID string, amount, price float64) (*types.Order, error) {
+	s.t.Error("Expected amendments to be routed to the paper exchange")
+	return nil, nil
+}
+
+func (s *stubConnector) GetOpenOrders(tradingPair string) ([]*types.Order, error) {
+	s.t.Error("Expected open order queries to be routed to the paper exchange")
+	return nil, nil
+}
+
+func (s *stubConnector) GetIdentifier() string { return "stub://feed" }
+
+func TestPaperConnector_RoutesOrdersToExchange(t *testing.T) {
+	exchange := paper.NewExchange(paper.Config{Balances: map[string]float64{"USDT": 1000}})
+	connector := NewPaperConnector(&stubConnector{t: t, prices: []float64{100, 90, 80}}, exchange)
+
+	if connector.GetIdentifier() != "stub://feed" {
+		t.Errorf("Expected source identifier, got %v", connector.GetIdentifier())
+	}
+
+	err := connector.StreamMarketData(func(ctx *types.TickContext) {
+		if ctx.MarketData.Time == 0 {
+			if _, err := ctx.ExecuteOrder(types.OrderTypeLimit, types.OrderSideBuy, 1, 85); err != nil {
+				t.Errorf("Expected limit order to rest, got %v", err)
+			}
+		}
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	fills := connector.Exchange().Fills()
+	if len(fills) != 1 || fills[0].Price != 85 {
+		t.Fatalf("Expected the limit order to fill at 85 from the live stream, got %+v", fills)
+	}
+	if exchange.Balance("BTC") != 1 {
+		t.Errorf("Expected BTC balance 1, got %v", exchange.Balance("BTC"))
+	}
+}
+
+// emptyTickConnector streams a tick without market data before its priced ticks.
+type emptyTickConnector struct {
+	stubConnector
+}
+
+func (e *emptyTickConnector) StreamMarketData(handler func(ctx *types.TickContext)) error {
+	handler(&types.TickContext{MarketUrl: "stub://feed", TradingPair: "BTC/USDT"})
+	return e.stubConnector.StreamMarketData(handler)
+}
+
+func TestPaperConnector_SkipsTicksWithoutMarketData(t *testing.T) {
+	exchange := paper.NewExchange(paper.Config{Balances: map[string]float64{"USDT": 1000}})
+	connector := NewPaperConnector(&emptyTickConnector{stubConnector{t: t, prices: []float64{100}}}, exchange)
+
+	var ticks int
+	if err := connector.StreamMarketData(func(ctx *types.TickContext) {
+		if ctx.MarketData == nil {
+			t.Error("Expected the tick without market data to be skipped")
+		}
+		ticks++
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ticks != 1 {
+		t.Errorf("Expected only the priced tick, got %d ticks", ticks)
+	}
+}
@@ -0,0 +1,41 @@
+package connectors
+
+import (
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"strconv"
+)
+
+// parseFloat parses a decimal string as sent by exchanges that encode numbers as strings,
+// returning zero for empty or malformed values.
+func parseFloat(value string) float64 {
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return parsed
+}
+
+// formatDecimal formats a quantity or price without an exponent, as exchanges require.
+func formatDecimal(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// triggeredOrder reports whether an order type waits for a trigger price: the stop-loss and take-profit
+// orders and their limit variants.
+func triggeredOrder(orderType types.OrderType) bool {
+	switch orderType {
+	case types.OrderTypeStopLoss, types.OrderTypeStopLossLimit, types.OrderTypeTakeProfit, types.OrderTypeTakeProfitLimit:
+		return true
+	}
+	return false
+}
+
+// limitOrder reports whether an order type rests at a limit price: limit orders and the limit variants of
+// the stop-loss and take-profit orders.
+func limitOrder(orderType types.OrderType) bool {
+	switch orderType {
+	case types.OrderTypeLimit, types.OrderTypeStopLossLimit, types.OrderTypeTakeProfitLimit:
+		return true
+	}
+	return false
+}
@@ -1,27 +1,60 @@
 package framework
 
 import (
+	"errors"
+	"fmt"
+	"github.com/bigmeech/tradingbot/internal/candles"
+	"github.com/bigmeech/tradingbot/internal/orderbook"
+	"github.com/bigmeech/tradingbot/internal/portfolio"
+	"github.com/bigmeech/tradingbot/internal/risk"
 	"github.com/bigmeech/tradingbot/pkg/types"
 	"log"
+	"sync"
+	"time"
 )
 
+// barMiddleware is middleware run on the close of candles of a given interval.
+type barMiddleware struct {
+	interval time.Duration
+	mw       types.Middleware
+}
+
 // Framework manages connectors, indicators, and middleware for the bot.
 type Framework struct {
-	storeManager *StoreManager              // Manages both fast and historical data storage
-	connectors   map[string]types.Connector // Registered connectors
-	idToMarket   map[string]string          // Map to track market names by WebSocket URL
-	indicators   map[string]map[string][]types.Indicator
-	middleware   map[string]map[string][]types.Middleware
+	storeManager  *StoreManager              // Manages both fast and historical data storage
+	connectors    map[string]types.Connector // Registered connectors
+	idToMarket    map[string]string          // Map to track market names by WebSocket URL
+	indicators    map[string]map[string][]types.Indicator
+	streams       map[string][]types.StreamingIndicator // Streaming state per market/trading pair, aligned with indicators
+	streamsLock   sync.Mutex
+	middleware    map[string]map[string][]types.Middleware
+	barHandlers   map[string]map[string][]barMiddleware // Middleware run when a candle closes
+	candles       *candles.Builder                      // Aggregates ticks into candles for the registered intervals
+	orderBooks    *orderbook.Books                      // Local order books of connectors that stream depth
+	portfolio     *portfolio.Tracker                    // Balances and positions updated from the fills of orders placed through ticks
+	risk          *risk.Engine                          // Pre-trade checks applied to every order placed through ticks
+	orders        orderTracker                          // Open orders placed through ticks, followed for later fills
+	strategies    []*strategyRunner                     // Strategies started and stopped with the framework
+	strategyState types.StrategyStateStore              // Persists the state of stateful strategies, if set
+	lifecycle     lifecycle                             // Running state between Start and Stop
 }
 
 // NewFramework initializes a new Framework with StoreManager and configuration.
 func NewFramework(storeManager *StoreManager) *Framework {
+	tracker := portfolio.NewTracker(nil)
 	return &Framework{
 		storeManager: storeManager,
 		connectors:   make(map[string]types.Connector),
 		idToMarket:   make(map[string]string),
 		indicators:   make(map[string]map[string][]types.Indicator),
+		streams:      make(map[string][]types.StreamingIndicator),
 		middleware:   make(map[string]map[string][]types.Middleware),
+		barHandlers:  make(map[string]map[string][]barMiddleware),
+		candles:      candles.NewBuilder(),
+		orderBooks:   orderbook.NewBooks(0),
+		portfolio:    tracker,
+		risk:         risk.NewEngine(risk.Limits{}, tracker),
+		orders:       orderTracker{pollInterval: DefaultOrderPollInterval},
 	}
 }
 
@@ -46,11 +79,91 @@ func (f *Framework) RegisterMiddleware(marketName, tradingPair string, mw types.
 	f.middleware[marketName][tradingPair] = append(f.middleware[marketName][tradingPair], mw)
 }
 
+// RegisterCandleInterval starts aggregating ticks for a market and trading pair into candles of the given interval.
+// It returns an error if the interval is shorter than a millisecond.
+func (f *Framework) RegisterCandleInterval(marketName, tradingPair string, interval time.Duration) error {
+	return f.candles.AddInterval(marketName, tradingPair, interval)
+}
+
+// RegisterBarCloseMiddleware adds middleware that runs each time a candle of the given interval closes
+// for a market and trading pair, instead of on every tick. The closed candle is set in TickContext.Candle.
+// It returns an error, registering nothing, if the interval is shorter than a millisecond.
+func (f *Framework) RegisterBarCloseMiddleware(marketName, tradingPair string, interval time.Duration, mw types.Middleware) error {
+	if err := f.RegisterCandleInterval(marketName, tradingPair, interval); err != nil {
+		return err
+	}
+	if f.barHandlers[marketName] == nil {
+		f.barHandlers[marketName] = make(map[string][]barMiddleware)
+	}
+	f.barHandlers[marketName][tradingPair] = append(f.barHandlers[marketName][tradingPair], barMiddleware{interval: interval, mw: mw})
+	return nil
+}
+
+// StoreManager returns the manager of the fast and large stores ticks are recorded in.
+func (f *Framework) StoreManager() *StoreManager {
+	return f.storeManager
+}
+
+// QueryCandles retrieves up to count of the most recent closed candles for a market, trading pair and interval.
+func (f *Framework) QueryCandles(market, tradingPair string, interval time.Duration, count int) []types.Candle {
+	return f.storeManager.QueryCandles(market, tradingPair, interval, count)
+}
+
 // QueryPriceHistory retrieves price history for a specific market and trading pair.
 func (f *Framework) QueryPriceHistory(market, tradingPair string, period int) []float64 {
 	return f.storeManager.QueryPriceHistory(market, tradingPair, period)
 }
 
+// registeredPairs returns the trading pairs with indicators, middleware or bar close middleware registered, per market.
+func (f *Framework) registeredPairs() map[string][]string {
+	seen := make(map[string]map[string]bool)
+	add := func(marketName, tradingPair string) {
+		if seen[marketName] == nil {
+			seen[marketName] = make(map[string]bool)
+		}
+		seen[marketName][tradingPair] = true
+	}
+	for marketName, pairs := range f.indicators {
+		for tradingPair := range pairs {
+			add(marketName, tradingPair)
+		}
+	}
+	for marketName, pairs := range f.middleware {
+		for tradingPair := range pairs {
+			add(marketName, tradingPair)
+		}
+	}
+	for marketName, pairs := range f.barHandlers {
+		for tradingPair := range pairs {
+			add(marketName, tradingPair)
+		}
+	}
+
+	registered := make(map[string][]string, len(seen))
+	for marketName, pairs := range seen {
+		for tradingPair := range pairs {
+			registered[marketName] = append(registered[marketName], tradingPair)
+		}
+	}
+	return registered
+}
+
+// warmStartStores fills the empty fast store of every registered trading pair with recent ticks from the large store.
+func (f *Framework) warmStartStores() {
+	for marketName, tradingPairs := range f.registeredPairs() {
+		for _, tradingPair := range tradingPairs {
+			loaded, err := f.storeManager.WarmStart(marketName, tradingPair)
+			if err != nil {
+				log.Printf("Failed to warm start %s %s from the large store: %v\n", marketName, tradingPair, err)
+				continue
+			}
+			if loaded > 0 {
+				log.Printf("Loaded %d ticks of %s %s from the large store.", loaded, marketName, tradingPair)
+			}
+		}
+	}
+}
+
 // GetMiddleware retrieves middleware for a given market and trading pair.
 func (f *Framework) GetMiddleware(marketName, tradingPair string) []types.Middleware {
 	return f.middleware[marketName][tradingPair]
@@ -71,14 +184,74 @@ func (f *Framework) GetIndicators(marketName, tradingPair string) []types.Indica
 
 // executeMiddleware calculates indicators and then runs all middleware for a specific market and trading pair.
 func (f *Framework) executeMiddleware(ctx *types.TickContext) error {
-	// Calculate indicators for the trading pair and store in context
-	for _, indicator := range f.GetIndicators(ctx.MarketName, ctx.TradingPair) {
+	f.calculateIndicators(ctx)
+	return f.runMiddleware(ctx)
+}
+
+// calculateIndicators calculates the indicators for the tick's trading pair and stores them in the context.
+// Streamable indicators are updated with the tick's price; others are recalculated from the price history.
+func (f *Framework) calculateIndicators(ctx *types.TickContext) {
+	indicators := f.GetIndicators(ctx.MarketName, ctx.TradingPair)
+	streams, seeded := f.indicatorStreams(ctx.MarketName, ctx.TradingPair, indicators)
+
+	for i, indicator := range indicators {
+		name := indicator.Name()
+		if stream := streams[i]; stream != nil {
+			// Newly created streams were seeded from history that already includes this tick
+			if !seeded[i] && ctx.MarketData != nil {
+				stream.Update(ctx.MarketData.Price)
+			}
+			ctx.Indicators[name] = stream.Value()
+			if multi, ok := stream.(types.MultiValueStreamingIndicator); ok {
+				for output, value := range multi.OutputValues() {
+					ctx.Indicators[types.IndicatorKey(name, output)] = value
+				}
+			}
+			continue
+		}
+
 		period := indicator.Period() // Use the indicator's period to get historical data
 		priceHistory := f.QueryPriceHistory(ctx.MarketName, ctx.TradingPair, period)
-		ctx.Indicators[indicator.Name()] = indicator.Calculate(priceHistory)
+		ctx.Indicators[name] = indicator.Calculate(priceHistory)
+
+		// Multi-value indicators also store each named output
+		if multi, ok := indicator.(types.MultiValueIndicator); ok {
+			for output, value := range multi.CalculateOutputs(priceHistory) {
+				ctx.Indicators[types.IndicatorKey(name, output)] = value
+			}
+		}
+	}
+}
+
+// indicatorStreams returns the streams for a market and trading pair, with nil for indicators that cannot
+// stream. Streams are created on first use and seeded from the recorded price history, which is reported
+// in seeded.
+func (f *Framework) indicatorStreams(marketName, tradingPair string, indicators []types.Indicator) ([]types.StreamingIndicator, []bool) {
+	f.streamsLock.Lock()
+	defer f.streamsLock.Unlock()
+
+	key := marketName + ":" + tradingPair
+	streams := f.streams[key]
+	seeded := make([]bool, len(indicators))
+	for i := len(streams); i < len(indicators); i++ {
+		streamable, ok := indicators[i].(types.StreamableIndicator)
+		if !ok {
+			streams = append(streams, nil)
+			continue
+		}
+		stream := streamable.NewStream()
+		for _, price := range f.QueryPriceHistory(marketName, tradingPair, indicators[i].Period()) {
+			stream.Update(price)
+		}
+		streams = append(streams, stream)
+		seeded[i] = true
 	}
+	f.streams[key] = streams
+	return streams, seeded
+}
 
-	// Run middleware
+// runMiddleware runs the tick middleware for a specific market and trading pair.
+func (f *Framework) runMiddleware(ctx *types.TickContext) error {
 	mws := f.GetMiddleware(ctx.MarketName, ctx.TradingPair)
 	for _, mw := range mws {
 		if err := mw(ctx); err != nil {
@@ -88,26 +261,240 @@ func (f *Framework) executeMiddleware(ctx *types.TickContext) error {
 	return nil
 }
 
+// bindOrderManagement exposes the connector's order management on the tick's trading pair,
+// unless the connector has already provided its own functions.
+func bindOrderManagement(ctx *types.TickContext, connector types.Connector) {
+	tradingPair := ctx.TradingPair
+	if ctx.CancelOrder == nil {
+		ctx.CancelOrder = func(orderID string) (*types.Order, error) {
+			return connector.CancelOrder(tradingPair, orderID)
+		}
+	}
+	if ctx.AmendOrder == nil {
+		ctx.AmendOrder = func(orderID string, amount, price float64) (*types.Order, error) {
+			return connector.AmendOrder(tradingPair, orderID, amount, price)
+		}
+	}
+	if ctx.GetOpenOrders == nil {
+		ctx.GetOpenOrders = func() ([]*types.Order, error) {
+			return connector.GetOpenOrders(tradingPair)
+		}
+	}
+}
+
+// Portfolio returns the tracker holding the balances and positions built up from order fills.
+func (f *Framework) Portfolio() *portfolio.Tracker {
+	return f.portfolio
+}
+
+// bindPortfolio exposes the portfolio on the tick, marks the tick's position to the latest price and
+// wraps the order functions so the fills of every order they return are applied to the portfolio. Orders
+// left open are followed, so their later fills are applied too.
+func (f *Framework) bindPortfolio(ctx *types.TickContext) {
+	marketName := ctx.MarketName
+	if ctx.MarketData != nil {
+		f.portfolio.MarkPrice(marketName, ctx.TradingPair, ctx.MarketData.Price)
+	}
+	ctx.Portfolio = f.portfolio
+
+	if executeOrder := ctx.ExecuteOrder; executeOrder != nil {
+		ctx.ExecuteOrder = func(orderType types.OrderType, side types.OrderSide, amount, price float64) (*types.Order, error) {
+			order, err := executeOrder(orderType, side, amount, price)
+			f.portfolio.ApplyOrder(marketName, order)
+			f.trackOrder(marketName, nil, order)
+			return order, err
+		}
+	}
+	// Cancelled orders may have partially filled since they were placed, and amendments may fill immediately
+	cancelOrder := ctx.CancelOrder
+	ctx.CancelOrder = func(orderID string) (*types.Order, error) {
+		order, err := cancelOrder(orderID)
+		f.portfolio.ApplyOrder(marketName, order)
+		f.trackOrder(marketName, nil, order)
+		return order, err
+	}
+	amendOrder := ctx.AmendOrder
+	ctx.AmendOrder = func(orderID string, amount, price float64) (*types.Order, error) {
+		order, err := amendOrder(orderID, amount, price)
+		f.portfolio.ApplyOrder(marketName, order)
+		if err == nil && order != nil {
+			f.untrackOrder(marketName, orderID)
+		}
+		f.trackOrder(marketName, nil, order)
+		return order, err
+	}
+}
+
+// Risk returns the engine running pre-trade checks on orders placed through ticks.
+func (f *Framework) Risk() *risk.Engine {
+	return f.risk
+}
+
+// bindRisk wraps the tick's ExecuteOrder and AmendOrder so every order and amendment passes the risk checks
+// before it reaches the exchange. Orders are checked at the tick's time, or the current time if it has none,
+// and market orders at the tick's price. Amendments are checked as orders on the side of the order they replace.
+func (f *Framework) bindRisk(ctx *types.TickContext) {
+	marketName, tradingPair := ctx.MarketName, ctx.TradingPair
+	marketPrice := 0.0
+	var tickTime time.Time
+	if ctx.MarketData != nil {
+		marketPrice = ctx.MarketData.Price
+		if ctx.MarketData.Time != 0 {
+			tickTime = time.UnixMilli(ctx.MarketData.Time)
+		}
+	}
+
+	if executeOrder := ctx.ExecuteOrder; executeOrder != nil {
+		ctx.ExecuteOrder = func(orderType types.OrderType, side types.OrderSide, amount, price float64) (*types.Order, error) {
+			checkPrice := price
+			if orderType == types.OrderTypeMarket || checkPrice <= 0 {
+				checkPrice = marketPrice
+			}
+			if err := f.risk.CheckOrderAt(tickTime, marketName, tradingPair, side, amount, checkPrice); err != nil {
+				log.Printf("Rejected %s %s order for %v %s on %s: %v\n", orderType, side, amount, tradingPair, marketName, err)
+				return nil, err
+			}
+			return executeOrder(orderType, side, amount, price)
+		}
+	}
+
+	amendOrder, getOpenOrders := ctx.AmendOrder, ctx.GetOpenOrders
+	if amendOrder == nil || getOpenOrders == nil {
+		return
+	}
+	ctx.AmendOrder = func(orderID string, amount, price float64) (*types.Order, error) {
+		orders, err := getOpenOrders()
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up order %s for the risk checks: %w", orderID, err)
+		}
+		var original *types.Order
+		for _, order := range orders {
+			if order.ExchangeOrderID == orderID || order.ClientOrderID == orderID {
+				original = order
+				break
+			}
+		}
+		if original == nil {
+			return nil, fmt.Errorf("cannot amend order %s: it is not open on %s %s", orderID, marketName, tradingPair)
+		}
+		checkPrice := price
+		if original.Type == types.OrderTypeMarket || checkPrice <= 0 {
+			checkPrice = marketPrice
+		}
+		if err := f.risk.CheckOrderAt(tickTime, marketName, tradingPair, original.Side, amount, checkPrice); err != nil {
+			log.Printf("Rejected amendment of order %s to %v %s on %s: %v\n", orderID, amount, tradingPair, marketName, err)
+			return nil, err
+		}
+		return amendOrder(orderID, amount, price)
+	}
+}
+
 // Connectors returns all registered connectors.
 func (f *Framework) Connectors() map[string]types.Connector {
 	return f.connectors
 }
 
-// Start initiates the connectors, applying middleware to each tick through processTickFunc.
-func (f *Framework) Start(processTickFunc func(ctx *types.TickContext)) {
-	for wsURL, connector := range f.connectors {
-		go func(connector types.Connector, wsURL string) {
-			connector.StreamMarketData(func(ctx *types.TickContext) {
-				// Set MarketName in TickContext based on WebSocket URL
-				ctx.MarketName = f.idToMarket[ctx.MarketUrl]
+// handleTick records an incoming tick, then calculates indicators, runs middleware and processes the tick.
+func (f *Framework) handleTick(name string, connector types.Connector, ctx *types.TickContext, processTickFunc func(ctx *types.TickContext)) {
+	// Set MarketName in TickContext based on the connector identifier, falling back to the registered name
+	if market, ok := f.idToMarket[ctx.MarketUrl]; ok {
+		ctx.MarketName = market
+	} else {
+		ctx.MarketName = name
+	}
+	if ctx.Indicators == nil {
+		ctx.Indicators = make(map[string]float64)
+	}
+	f.lifecycle.trackPair(ctx.MarketName, ctx.TradingPair)
+	bindOrderManagement(ctx, connector)
+	f.bindPortfolio(ctx)
+	f.bindRisk(ctx)
+	if ctx.Candles == nil {
+		marketName, tradingPair := ctx.MarketName, ctx.TradingPair
+		ctx.Candles = func(interval time.Duration, count int) []types.Candle {
+			return f.QueryCandles(marketName, tradingPair, interval, count)
+		}
+	}
+
+	if ctx.OrderBook == nil {
+		marketName, tradingPair := ctx.MarketName, ctx.TradingPair
+		ctx.OrderBook = func(levels int) *types.OrderBook {
+			return f.OrderBook(marketName, tradingPair, levels)
+		}
+	}
+
+	// Record the tick so indicators see it as part of the price history
+	if err := f.storeManager.RecordTick(ctx.MarketName, ctx.TradingPair, ctx.MarketData); err != nil {
+		log.Printf("Failed to record tick for %s: %v\n", ctx.TradingPair, err)
+	}
+
+	// Close any candles this tick completes, then calculate indicators
+	closed := f.recordCandles(ctx)
+	f.calculateIndicators(ctx)
+
+	// Bars close before the tick that completed them, so bar close middleware runs first
+	for _, candle := range closed {
+		f.runBarCloseMiddleware(ctx, candle)
+	}
+
+	// Run tick middleware, then process the tick
+	if err := f.runMiddleware(ctx); err != nil {
+		log.Printf("Middleware error for %s: %v\n", ctx.TradingPair, err)
+		return
+	}
+	processTickFunc(ctx)
+}
+
+// OrderBook returns a copy of the best levels of a market and trading pair's local order book, or nil if it has no synced book.
+// The books of connectors implementing types.OrderBookProvider are read from the connector.
+func (f *Framework) OrderBook(marketName, tradingPair string, levels int) *types.OrderBook {
+	if provider, ok := f.connectors[marketName].(types.OrderBookProvider); ok {
+		return provider.OrderBook(tradingPair, levels)
+	}
+	return f.orderBooks.Snapshot(marketName, tradingPair, levels)
+}
+
+// handleDepth applies a depth update to the local order book, requesting a new snapshot when the book is out of sync.
+func (f *Framework) handleDepth(name string, streamer types.DepthStreamer, update *types.OrderBookUpdate) {
+	err := f.orderBooks.Apply(name, update)
+	if errors.Is(err, orderbook.ErrOutOfSync) {
+		log.Printf("Resyncing order book for %s on %s: %v\n", update.TradingPair, name, err)
+		// The snapshot may be delivered through the handler that called us, so it is requested asynchronously
+		go f.resyncDepth(name, streamer, update.TradingPair)
+	}
+}
 
-				// Calculate indicators and run middleware, then process the tick
-				if err := f.executeMiddleware(ctx); err != nil {
-					log.Printf("Middleware error for %s: %v\n", ctx.TradingPair, err)
-					return
-				}
-				processTickFunc(ctx)
-			})
-		}(connector, wsURL)
+// resyncDepth requests a new snapshot of a book. If the request fails the book is reset, so the next diff retries.
+func (f *Framework) resyncDepth(name string, streamer types.DepthStreamer, tradingPair string) {
+	if err := streamer.ResyncDepth(tradingPair); err != nil {
+		log.Printf("Failed to resync order book for %s on %s: %v\n", tradingPair, name, err)
+		f.orderBooks.Reset(name, tradingPair)
+	}
+}
+
+// recordCandles aggregates the tick into candles and stores the ones it closes.
+func (f *Framework) recordCandles(ctx *types.TickContext) []types.Candle {
+	if ctx.MarketData == nil {
+		return nil
+	}
+	closed := f.candles.Add(ctx.MarketName, ctx.TradingPair, ctx.MarketData)
+	for _, candle := range closed {
+		f.storeManager.RecordCandle(ctx.MarketName, candle)
+	}
+	return closed
+}
+
+// runBarCloseMiddleware runs the middleware registered for the candle's interval with the candle set in a copy of the context.
+func (f *Framework) runBarCloseMiddleware(ctx *types.TickContext, candle types.Candle) {
+	barCtx := *ctx
+	barCtx.Candle = &candle
+	for _, handler := range f.barHandlers[ctx.MarketName][ctx.TradingPair] {
+		if handler.interval != candle.Interval {
+			continue
+		}
+		if err := handler.mw(&barCtx); err != nil {
+			log.Printf("Bar close middleware error for %s %s: %v\n", ctx.TradingPair, candle.Interval, err)
+			return
+		}
 	}
 }
@@ -1,8 +1,14 @@
 package framework
 
 import (
+	"context"
+	"errors"
+	"github.com/bigmeech/tradingbot/internal/indicators"
+	"github.com/bigmeech/tradingbot/internal/risk"
 	"github.com/bigmeech/tradingbot/pkg/types"
+	"math"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -20,20 +26,21 @@ func NewMockStore() *MockStore {
 	}
 }
 
-// RecordTick simulates recording a tick for a trading pair.
-func (m *MockStore) RecordTick(tradingPair string, marketData *types.MarketData) error {
+// RecordTick simulates recording a tick for a market and trading pair.
+func (m *MockStore) RecordTick(market, tradingPair string, marketData *types.MarketData) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.recordedData[tradingPair] = append(m.recordedData[tradingPair], *marketData)
+	key := market + ":" + tradingPair
+	m.recordedData[key] = append(m.recordedData[key], *marketData)
 	return nil
 }
 
-// QueryPriceHistory simulates querying the price history for a trading pair.
-func (m *MockStore) QueryPriceHistory(tradingPair string, period int) []float64 {
+// QueryPriceHistory simulates querying the price history for a market and trading pair.
+func (m *MockStore) QueryPriceHistory(market, tradingPair string, period int) []float64 {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	history := make([]float64, 0, period)
-	data := m.recordedData[tradingPair]
+	data := m.recordedData[market+":"+tradingPair]
 	count := len(data)
 	start := count - period
 	if start < 0 {
@@ -64,27 +71,52 @@ func (m *MockConnector) StreamMarketData(handler func(ctx *types.TickContext)) e
 			MarketName:  "MockConnector",
 			TradingPair: "BTC/USDT",
 			MarketData:  &types.MarketData{Price: 50000.0, Volume: 1.5},
-			ExecuteOrder: func(orderType types.OrderType, side types.OrderSide, amount, price float64) error {
-				return nil
+			ExecuteOrder: func(orderType types.OrderType, side types.OrderSide, amount, price float64) (*types.Order, error) {
+				return nil, nil
 			},
 		})
 	}
 	return nil
 }
 
-// ExecuteOrder simulates executing an order for the mock connector.
-func (m *MockConnector) ExecuteOrder(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64) error {
+// StopStreaming simulates stopping the data stream for the mock connector.
+func (m *MockConnector) StopStreaming() error {
 	return nil
 }
 
+// GetIdentifier returns an empty identifier so the registration name is used as the market name.
+func (m *MockConnector) GetIdentifier() string {
+	return ""
+}
+
+// ExecuteOrder simulates executing an order for the mock connector.
+func (m *MockConnector) ExecuteOrder(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64) (*types.Order, error) {
+	return nil, nil
+}
+
+// CancelOrder simulates cancelling an order for the mock connector.
+func (m *MockConnector) CancelOrder(tradingPair, orderID string) (*types.Order, error) {
+	return nil, nil
+}
+
+// AmendOrder simulates amending an order for the mock connector.
+func (m *MockConnector) AmendOrder(tradingPair, orderID string, amount, price float64) (*types.Order, error) {
+	return nil, nil
+}
+
+// GetOpenOrders simulates listing open orders for the mock connector.
+func (m *MockConnector) GetOpenOrders(tradingPair string) ([]*types.Order, error) {
+	return nil, nil
+}
+
 func TestFramework_RegisterConnectorAndStreamTicks(t *testing.T) {
 	// Initialize mock stores
 	largeStore := NewMockStore()
 	bufferSize := 10
 	threshold := 5
 
-	// Initialize StoreManager and Framework with mock stores and buffer size
-	storeManager := NewStoreManager(largeStore, bufferSize, threshold)
+	// Initialize StoreManager and Framework with an in-memory fast store and the mock large store
+	storeManager := NewStoreManager(NewInMemoryFastStore(bufferSize), largeStore, threshold)
 	framework := NewFramework(storeManager)
 
 	// Set up a channel to capture processed ticks
@@ -104,20 +136,23 @@ func TestFramework_RegisterConnectorAndStreamTicks(t *testing.T) {
 				MarketName:  "MockConnector",
 				TradingPair: "BTC/USDT",
 				MarketData:  &types.MarketData{Price: 50000.0, Volume: 1.5},
-				ExecuteOrder: func(orderType types.OrderType, side types.OrderSide, amount, price float64) error {
-					return nil
+				ExecuteOrder: func(orderType types.OrderType, side types.OrderSide, amount, price float64) (*types.Order, error) {
+					return nil, nil
 				},
 			})
 		},
 	}
 
+	mockConnector.Connect()
 	framework.RegisterConnector("MockConnector", mockConnector)
 	if len(framework.Connectors()) != 1 {
 		t.Fatalf("Expected 1 connector, got %v", len(framework.Connectors()))
 	}
 
 	// Start the framework with the mock tick processing function
-	go framework.Start(processTickFunc)
+	if err := framework.Start(context.Background(), processTickFunc); err != nil {
+		t.Fatalf("Expected framework to start, got %v", err)
+	}
 
 	// Wait for the tick to be processed and received in the channel with a timeout
 	select {
@@ -135,7 +170,362 @@ func TestFramework_RegisterConnectorAndStreamTicks(t *testing.T) {
 		if tick.MarketData.Volume != 1.5 {
 			t.Errorf("Expected volume 1.5, got %v", tick.MarketData.Volume)
 		}
+		if tick.CancelOrder == nil || tick.AmendOrder == nil || tick.GetOpenOrders == nil {
+			t.Error("Expected order management functions to be bound to the connector")
+		}
 	case <-time.After(1 * time.Second):
 		t.Fatal("Expected tick to be processed but received none within the timeout period")
 	}
 }
+
+func TestFramework_PortfolioTracksFills(t *testing.T) {
+	framework := NewFramework(NewStoreManager(NewInMemoryFastStore(10), NewMockStore(), 5))
+	framework.RegisterMiddleware("MockConnector", "BTC/USDT", func(ctx *types.TickContext) error {
+		if ctx.Portfolio.Position(ctx.MarketName, ctx.TradingPair).IsLong() {
+			return nil
+		}
+		_, err := ctx.ExecuteOrder(types.OrderTypeMarket, types.OrderSideBuy, 1, 0)
+		return err
+	})
+
+	newTick := func(price float64) *types.TickContext {
+		return &types.TickContext{
+			TradingPair: "BTC/USDT",
+			MarketData:  &types.MarketData{Price: price},
+			ExecuteOrder: func(orderType types.OrderType, side types.OrderSide, amount, _ float64) (*types.Order, error) {
+				order := &types.Order{ClientOrderID: types.NewClientOrderID(), TradingPair: "BTC/USDT", Type: orderType, Side: side, Quantity: amount}
+				order.AddFill(types.Fill{Price: price, Quantity: amount})
+				return order, nil
+			},
+		}
+	}
+
+	connector := &MockConnector{}
+	framework.handleTick("MockConnector", connector, newTick(100), func(ctx *types.TickContext) {})
+	framework.handleTick("MockConnector", connector, newTick(110), func(ctx *types.TickContext) {})
+
+	position := framework.Portfolio().Position("MockConnector", "BTC/USDT")
+	if position.Quantity != 1 || position.AverageEntryPrice != 100 {
+		t.Fatalf("Expected a single buy at 100, got %+v", position)
+	}
+	if position.UnrealizedPnL != 10 {
+		t.Errorf("Expected the position to be marked at 110, got %+v", position)
+	}
+}
+
+func TestFramework_RiskRejectsOrders(t *testing.T) {
+	framework := NewFramework(NewStoreManager(NewInMemoryFastStore(10), NewMockStore(), 5))
+	framework.Risk().SetLimits(risk.Limits{MaxOrderNotional: 1000})
+
+	var results []error
+	framework.RegisterMiddleware("MockConnector", "BTC/USDT", func(ctx *types.TickContext) error {
+		_, err := ctx.ExecuteOrder(types.OrderTypeMarket, types.OrderSideBuy, 1, 0)
+		results = append(results, err)
+		return nil
+	})
+
+	executed := 0
+	newTick := func(price float64) *types.TickContext {
+		return &types.TickContext{
+			TradingPair: "BTC/USDT",
+			MarketData:  &types.MarketData{Price: price},
+			ExecuteOrder: func(orderType types.OrderType, side types.OrderSide, amount, price float64) (*types.Order, error) {
+				executed++
+				return nil, nil
+			},
+		}
+	}
+
+	connector := &MockConnector{}
+	framework.handleTick("MockConnector", connector, newTick(500), func(ctx *types.TickContext) {})
+	framework.handleTick("MockConnector", connector, newTick(5000), func(ctx *types.TickContext) {})
+
+	var rejection *types.RiskRejectionError
+	if len(results) != 2 || results[0] != nil || !errors.As(results[1], &rejection) {
+		t.Fatalf("Expected the second market order to be rejected at the tick price, got %v", results)
+	}
+	if executed != 1 {
+		t.Errorf("Expected only the first order to reach the connector, got %d", executed)
+	}
+}
+
+func TestFramework_RiskChecksAmendmentsAtTickTime(t *testing.T) {
+	framework := NewFramework(NewStoreManager(NewInMemoryFastStore(10), NewMockStore(), 5))
+	framework.Risk().SetLimits(risk.Limits{MaxOrderNotional: 1000, MaxOrdersPerMinute: 1})
+
+	var action func(ctx *types.TickContext) error
+	var results []error
+	framework.RegisterMiddleware("MockConnector", "BTC/USDT", func(ctx *types.TickContext) error {
+		results = append(results, action(ctx))
+		return nil
+	})
+
+	amended, executed := 0, 0
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC).UnixMilli()
+	tick := func(offset time.Duration, do func(ctx *types.TickContext) error) {
+		action = do
+		framework.handleTick("MockConnector", &MockConnector{}, &types.TickContext{
+			TradingPair: "BTC/USDT",
+			MarketData:  &types.MarketData{Price: 500, Time: start + offset.Milliseconds()},
+			ExecuteOrder: func(orderType types.OrderType, side types.OrderSide, amount, price float64) (*types.Order, error) {
+				executed++
+				return nil, nil
+			},
+			AmendOrder: func(orderID string, amount, price float64) (*types.Order, error) {
+				amended++
+				return nil, nil
+			},
+			GetOpenOrders: func() ([]*types.Order, error) {
+				return []*types.Order{{ExchangeOrderID: "1", TradingPair: "BTC/USDT", Type: types.OrderTypeLimit, Side: types.OrderSideBuy, Status: types.OrderStatusNew, Price: 400, Quantity: 1}}, nil
+			},
+		}, func(ctx *types.TickContext) {})
+	}
+	amend := func(orderID string, price float64) func(ctx *types.TickContext) error {
+		return func(ctx *types.TickContext) error {
+			_, err := ctx.AmendOrder(orderID, 1, price)
+			return err
+		}
+	}
+	execute := func(ctx *types.TickContext) error {
+		_, err := ctx.ExecuteOrder(types.OrderTypeMarket, types.OrderSideBuy, 1, 0)
+		return err
+	}
+
+	tick(0, amend("1", 5000))            // Above the notional limit
+	tick(0, amend("2", 450))             // Not an open order
+	tick(0, amend("1", 450))             // Accepted
+	tick(30*time.Second, execute)        // Within a minute of the amendment by the ticks' clock
+	tick(2*time.Minute, execute)         // A minute later by the ticks' clock, however little time has passed
+	tick(2*time.Minute, amend("1", 450)) // Within a minute of the order
+
+	var rejection *types.RiskRejectionError
+	if len(results) != 6 || !errors.As(results[0], &rejection) || rejection.Rule != types.RiskRuleMaxOrderNotional {
+		t.Fatalf("Expected the amendment above the notional limit to be rejected, got %v", results)
+	}
+	if results[1] == nil || errors.As(results[1], &rejection) || results[2] != nil {
+		t.Errorf("Expected only the amendment of the open order to pass, got %v", results)
+	}
+	if !errors.As(results[3], &rejection) || rejection.Rule != types.RiskRuleMaxOrdersPerMinute || results[4] != nil {
+		t.Errorf("Expected the orders per minute to be counted at the ticks' time, got %v", results)
+	}
+	if !errors.As(results[5], &rejection) || rejection.Rule != types.RiskRuleMaxOrdersPerMinute {
+		t.Errorf("Expected amendments to count towards the orders per minute, got %v", results[5])
+	}
+	if amended != 1 || executed != 1 {
+		t.Errorf("Expected one amendment and one order to reach the connector, got %d and %d", amended, executed)
+	}
+}
+
+func TestFramework_BarCloseMiddleware(t *testing.T) {
+	framework := NewFramework(NewStoreManager(NewInMemoryFastStore(10), NewMockStore(), 5))
+
+	var bars []types.Candle
+	var history []types.Candle
+	err := framework.RegisterBarCloseMiddleware("MockConnector", "BTC/USDT", time.Minute, func(ctx *types.TickContext) error {
+		bars = append(bars, *ctx.Candle)
+		history = ctx.Candles(time.Minute, 10)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected bar close middleware to register, got %v", err)
+	}
+	if err := framework.RegisterBarCloseMiddleware("MockConnector", "BTC/USDT", 0, func(ctx *types.TickContext) error { return nil }); err == nil {
+		t.Error("Expected a zero interval to be rejected")
+	}
+	ticksSeen := 0
+	framework.RegisterMiddleware("MockConnector", "BTC/USDT", func(ctx *types.TickContext) error {
+		if ctx.Candle != nil {
+			t.Error("Expected tick middleware to run without a candle")
+		}
+		ticksSeen++
+		return nil
+	})
+
+	connector := &MockConnector{}
+	for i, price := range []float64{100, 110, 90, 105, 120} {
+		framework.handleTick("MockConnector", connector, &types.TickContext{
+			TradingPair: "BTC/USDT",
+			MarketData:  &types.MarketData{Price: price, Volume: 1, Time: 1699999980000 + int64(i)*30000},
+		}, func(ctx *types.TickContext) {})
+	}
+
+	if ticksSeen != 5 {
+		t.Errorf("Expected tick middleware to run on every tick, ran %d times", ticksSeen)
+	}
+	if len(bars) != 2 {
+		t.Fatalf("Expected 2 bar closes, got %+v", bars)
+	}
+	if bars[0].Open != 100 || bars[0].Close != 110 || bars[1].High != 105 || bars[1].Low != 90 {
+		t.Errorf("Unexpected bars %+v", bars)
+	}
+	if len(history) != 2 || history[1] != bars[1] {
+		t.Errorf("Expected closed candles to be stored, got %+v", history)
+	}
+}
+
+func TestFramework_MultiValueIndicators(t *testing.T) {
+	framework := NewFramework(NewStoreManager(NewInMemoryFastStore(10), NewMockStore(), 5))
+	bands := indicators.NewBollingerBands(3, 2)
+	framework.RegisterIndicator("MockConnector", "BTC/USDT", bands)
+
+	var values map[string]float64
+	framework.RegisterMiddleware("MockConnector", "BTC/USDT", func(ctx *types.TickContext) error {
+		values = ctx.Indicators
+		return nil
+	})
+
+	connector := &MockConnector{}
+	for _, price := range []float64{100, 105, 110} {
+		framework.handleTick("MockConnector", connector, &types.TickContext{
+			TradingPair: "BTC/USDT",
+			MarketData:  &types.MarketData{Price: price},
+		}, func(ctx *types.TickContext) {})
+	}
+
+	upper := values[types.IndicatorKey(bands.Name(), indicators.BollingerUpper)]
+	middle := values[types.IndicatorKey(bands.Name(), indicators.BollingerMiddle)]
+	lower := values[types.IndicatorKey(bands.Name(), indicators.BollingerLower)]
+	if middle != 105 || values[bands.Name()] != middle {
+		t.Errorf("Expected middle band 105 under both keys, got %v", values)
+	}
+	if upper <= middle || lower >= middle {
+		t.Errorf("Expected upper above and lower below the middle band, got %v", values)
+	}
+}
+
+func TestFramework_StreamingIndicatorsPerPair(t *testing.T) {
+	framework := NewFramework(NewStoreManager(NewInMemoryFastStore(10), NewMockStore(), 5))
+	ema := indicators.NewEMA(3)
+	framework.RegisterIndicator("MockConnector", "BTC/USDT", ema)
+	framework.RegisterIndicator("MockConnector", "ETH/USDT", ema)
+
+	latest := make(map[string]float64)
+	record := func(ctx *types.TickContext) error {
+		latest[ctx.TradingPair] = ctx.Indicators[ema.Name()]
+		return nil
+	}
+	framework.RegisterMiddleware("MockConnector", "BTC/USDT", record)
+	framework.RegisterMiddleware("MockConnector", "ETH/USDT", record)
+
+	btc := []float64{100, 102, 104, 103, 107, 110}
+	eth := []float64{10, 11, 9, 12, 13, 12}
+	connector := &MockConnector{}
+	for i := range btc {
+		for pair, price := range map[string]float64{"BTC/USDT": btc[i], "ETH/USDT": eth[i]} {
+			framework.handleTick("MockConnector", connector, &types.TickContext{
+				TradingPair: pair,
+				MarketData:  &types.MarketData{Price: price},
+			}, func(ctx *types.TickContext) {})
+		}
+	}
+
+	// The streams keep the EMA over every tick rather than just the last period prices
+	if expected := ema.Calculate(btc); math.Abs(latest["BTC/USDT"]-expected) > 1e-9 {
+		t.Errorf("Expected BTC/USDT EMA %v, got %v", expected, latest["BTC/USDT"])
+	}
+	if expected := ema.Calculate(eth); math.Abs(latest["ETH/USDT"]-expected) > 1e-9 {
+		t.Errorf("Expected ETH/USDT EMA %v, got %v", expected, latest["ETH/USDT"])
+	}
+}
+
+// depthConnector streams ticks and an order book whose first diff arrives before any snapshot.
+type depthConnector struct {
+	*streamingConnector
+	handler func(update *types.OrderBookUpdate)
+	resyncs atomic.Int32
+}
+
+func (d *depthConnector) StreamDepth(handler func(update *types.OrderBookUpdate)) error {
+	d.handler = handler
+	handler(&types.OrderBookUpdate{TradingPair: "BTC/USDT", Bids: []types.PriceLevel{{Price: 99, Quantity: 5}}, FirstSequence: 11, LastSequence: 12})
+	return nil
+}
+
+func (d *depthConnector) ResyncDepth(tradingPair string) error {
+	d.resyncs.Add(1)
+	d.handler(&types.OrderBookUpdate{
+		TradingPair:  tradingPair,
+		Snapshot:     true,
+		Bids:         []types.PriceLevel{{Price: 99, Quantity: 1}},
+		Asks:         []types.PriceLevel{{Price: 101, Quantity: 2}},
+		LastSequence: 10,
+	})
+	return nil
+}
+
+func TestFramework_OrderBookResync(t *testing.T) {
+	framework := NewFramework(NewStoreManager(NewInMemoryFastStore(10), NewMockStore(), 5))
+	connector := &depthConnector{streamingConnector: newStreamingConnector()}
+	framework.RegisterConnector("Depth", connector)
+
+	books := make(chan *types.OrderBook, 1)
+	framework.RegisterMiddleware("Depth", "BTC/USDT", func(ctx *types.TickContext) error {
+		if book := ctx.OrderBook(5); book != nil {
+			select {
+			case books <- book:
+			default:
+			}
+		}
+		return nil
+	})
+	if err := framework.Start(context.Background(), func(ctx *types.TickContext) {}); err != nil {
+		t.Fatalf("Expected framework to start, got %v", err)
+	}
+	defer framework.Stop(context.Background())
+
+	select {
+	case book := <-books:
+		// The diff received before the snapshot is replayed on top of it
+		if bid, _ := book.BestBid(); bid.Quantity != 5 || book.Spread() != 2 || book.Sequence != 12 {
+			t.Errorf("Unexpected order book %+v", book)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected ticks to see the synced order book")
+	}
+	if connector.resyncs.Load() != 1 {
+		t.Errorf("Expected one resync, got %d", connector.resyncs.Load())
+	}
+	if framework.OrderBook("Depth", "ETH/USDT", 5) != nil {
+		t.Error("Expected no book for a pair without depth")
+	}
+}
+
+// bookProvider is a depth connector that keeps its own order books.
+type bookProvider struct {
+	*depthConnector
+}
+
+func (b *bookProvider) OrderBook(tradingPair string, levels int) *types.OrderBook {
+	return &types.OrderBook{TradingPair: tradingPair, Bids: []types.PriceLevel{{Price: 50, Quantity: 1}}}
+}
+
+func TestFramework_OrderBookFromProvider(t *testing.T) {
+	framework := NewFramework(NewStoreManager(NewInMemoryFastStore(10), NewMockStore(), 5))
+	connector := &bookProvider{&depthConnector{streamingConnector: newStreamingConnector()}}
+	framework.RegisterConnector("Kraken", connector)
+
+	books := make(chan *types.OrderBook, 1)
+	framework.RegisterMiddleware("Kraken", "BTC/USDT", func(ctx *types.TickContext) error {
+		select {
+		case books <- ctx.OrderBook(5):
+		default:
+		}
+		return nil
+	})
+	if err := framework.Start(context.Background(), func(ctx *types.TickContext) {}); err != nil {
+		t.Fatalf("Expected framework to start, got %v", err)
+	}
+	defer framework.Stop(context.Background())
+
+	select {
+	case book := <-books:
+		if bid, _ := book.BestBid(); bid.Price != 50 {
+			t.Errorf("Expected the connector's book, got %+v", book)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected ticks to see the connector's order book")
+	}
+	if connector.handler != nil || connector.resyncs.Load() != 0 {
+		t.Error("Expected the framework not to stream depth into books of its own")
+	}
+}
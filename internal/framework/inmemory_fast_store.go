@@ -1,49 +1,79 @@
 package framework
 
 import (
+	"github.com/bigmeech/tradingbot/internal/store"
 	"github.com/bigmeech/tradingbot/pkg/types"
+	"sync"
 )
 
-// InMemoryFastStore is an in-memory circular buffer optimized for fast data access.
+// InMemoryFastStore keeps the most recent ticks of each market and trading pair in a circular buffer.
 type InMemoryFastStore struct {
-	data  []types.MarketData
-	limit int
-	index int
-	full  bool
+	buffers map[string]*store.CircularBuffer // Buffers per market/trading pair
+	limit   int                              // Ticks kept per market/trading pair
+	mu      sync.Mutex
 }
 
-// NewInMemoryFastStore initializes an InMemoryFastStore with a fixed size.
+// NewInMemoryFastStore initializes an InMemoryFastStore keeping limit ticks per market and trading pair.
 func NewInMemoryFastStore(limit int) *InMemoryFastStore {
 	return &InMemoryFastStore{
-		data:  make([]types.MarketData, limit),
-		limit: limit,
+		buffers: make(map[string]*store.CircularBuffer),
+		limit:   limit,
 	}
 }
 
-// RecordTick records new market data in constant time.
-func (f *InMemoryFastStore) RecordTick(tradingPair string, marketData *types.MarketData) error {
-	f.data[f.index] = *marketData
-	f.index = (f.index + 1) % f.limit
-	if f.index == 0 {
-		f.full = true
+// RecordTick records new market data in constant time, overwriting the oldest tick once the pair's buffer is full.
+func (f *InMemoryFastStore) RecordTick(market, tradingPair string, marketData *types.MarketData) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := market + ":" + tradingPair
+	buffer, exists := f.buffers[key]
+	if !exists {
+		buffer = store.NewCircularBuffer(f.limit)
+		f.buffers[key] = buffer
 	}
+	buffer.Add(*marketData)
 	return nil
 }
 
-// QueryPriceHistory retrieves recent price history.
-func (f *InMemoryFastStore) QueryPriceHistory(tradingPair string, period int) []float64 {
-	if !f.full && period > f.index {
-		period = f.index
+// QueryPriceHistory retrieves up to period of the most recent prices of a market and trading pair, oldest first.
+func (f *InMemoryFastStore) QueryPriceHistory(market, tradingPair string, period int) []float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	buffer, exists := f.buffers[market+":"+tradingPair]
+	if !exists || period <= 0 {
+		return []float64{}
 	}
-	if period > f.limit {
-		period = f.limit
+	recentData := buffer.GetData(period)
+	prices := make([]float64, len(recentData))
+	for i, entry := range recentData {
+		prices[i] = entry.Price
 	}
+	return prices
+}
 
-	prices := make([]float64, period)
-	start := (f.index - period + f.limit) % f.limit
+// QueryTicks retrieves up to count of the most recent ticks of a market and trading pair with a time before
+// the given Unix millisecond time, or the most recent ticks if before is zero, oldest first. Ticks may be
+// recorded out of time order, e.g. from several connections, so they are sorted by time.
+func (f *InMemoryFastStore) QueryTicks(market, tradingPair string, before int64, count int) []types.MarketData {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 
-	for i := 0; i < period; i++ {
-		prices[i] = f.data[(start+i)%f.limit].Price
+	buffer, exists := f.buffers[market+":"+tradingPair]
+	if !exists || count <= 0 {
+		return []types.MarketData{}
 	}
-	return prices
+	// The buffer's data is copied, since it is shared with the buffer
+	ticks := make([]types.MarketData, 0, f.limit)
+	for _, data := range buffer.GetData(f.limit) {
+		if before == 0 || data.Time < before {
+			ticks = append(ticks, data)
+		}
+	}
+	sortTicks(ticks)
+	if len(ticks) > count {
+		ticks = ticks[len(ticks)-count:]
+	}
+	return ticks
 }
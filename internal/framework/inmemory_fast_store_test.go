@@ -9,12 +9,12 @@ func TestInMemoryFastStore_RecordTickAndQuery(t *testing.T) {
 	store := NewInMemoryFastStore(3) // Limit of 3 for testing circular buffer
 
 	// Add three ticks
-	store.RecordTick("BTC/USDT", &types.MarketData{Price: 100.0})
-	store.RecordTick("BTC/USDT", &types.MarketData{Price: 200.0})
-	store.RecordTick("BTC/USDT", &types.MarketData{Price: 300.0})
+	store.RecordTick("Mock", "BTC/USDT", &types.MarketData{Price: 100.0})
+	store.RecordTick("Mock", "BTC/USDT", &types.MarketData{Price: 200.0})
+	store.RecordTick("Mock", "BTC/USDT", &types.MarketData{Price: 300.0})
 
 	// Retrieve all three prices
-	prices := store.QueryPriceHistory("BTC/USDT", 3)
+	prices := store.QueryPriceHistory("Mock", "BTC/USDT", 3)
 	expected := []float64{100.0, 200.0, 300.0}
 
 	if len(prices) != len(expected) {
@@ -28,8 +28,8 @@ func TestInMemoryFastStore_RecordTickAndQuery(t *testing.T) {
 	}
 
 	// Add another tick, which should overwrite the oldest entry
-	store.RecordTick("BTC/USDT", &types.MarketData{Price: 400.0})
-	prices = store.QueryPriceHistory("BTC/USDT", 3)
+	store.RecordTick("Mock", "BTC/USDT", &types.MarketData{Price: 400.0})
+	prices = store.QueryPriceHistory("Mock", "BTC/USDT", 3)
 	expected = []float64{200.0, 300.0, 400.0} // Circular buffer should now contain the last three entries
 
 	for i, price := range prices {
@@ -38,3 +38,34 @@ func TestInMemoryFastStore_RecordTickAndQuery(t *testing.T) {
 		}
 	}
 }
+
+func TestInMemoryFastStore_SeparatesPairs(t *testing.T) {
+	store := NewInMemoryFastStore(3)
+	store.RecordTick("Mock", "BTC/USDT", &types.MarketData{Price: 100.0})
+	store.RecordTick("Mock", "ETH/USDT", &types.MarketData{Price: 10.0})
+	store.RecordTick("Other", "BTC/USDT", &types.MarketData{Price: 101.0})
+
+	if prices := store.QueryPriceHistory("Mock", "BTC/USDT", 3); len(prices) != 1 || prices[0] != 100.0 {
+		t.Errorf("Expected only the Mock BTC/USDT price, got %v", prices)
+	}
+	if prices := store.QueryPriceHistory("Mock", "SOL/USDT", 3); len(prices) != 0 {
+		t.Errorf("Expected no prices for an unknown pair, got %v", prices)
+	}
+}
+
+func TestInMemoryFastStore_QueryTicksOutOfOrder(t *testing.T) {
+	store := NewInMemoryFastStore(5)
+	// Ticks arriving late, e.g. over another connection, are recorded after newer ones
+	for _, tick := range []int64{1, 3, 2, 5, 4} {
+		store.RecordTick("Mock", "BTC/USDT", &types.MarketData{Price: float64(tick * 100), Time: tick})
+	}
+
+	ticks := store.QueryTicks("Mock", "BTC/USDT", 0, 3)
+	if len(ticks) != 3 || ticks[0].Time != 3 || ticks[1].Time != 4 || ticks[2].Time != 5 {
+		t.Errorf("Expected the three most recent ticks by time, got %+v", ticks)
+	}
+	ticks = store.QueryTicks("Mock", "BTC/USDT", 4, 5)
+	if len(ticks) != 3 || ticks[0].Time != 1 || ticks[1].Time != 2 || ticks[2].Time != 3 {
+		t.Errorf("Expected every tick before time 4, got %+v", ticks)
+	}
+}
@@ -0,0 +1,288 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+// lifecycle tracks the running state of a Framework between Start and Stop.
+type lifecycle struct {
+	mu                 sync.Mutex
+	running            bool
+	stopped            bool               // Set once shut down, after which the framework cannot start again
+	closed             bool               // Set by Close, after which the framework cannot start again
+	cancel             context.CancelFunc // Cancels the context the framework was started with
+	done               chan struct{}      // Closed once shutdown has finished
+	stopErr            error              // Errors from the last shutdown
+	cancelOrdersOnStop bool
+
+	stopping atomic.Bool    // Set once shutdown begins, after which new ticks are dropped
+	ticks    sync.RWMutex   // Read-locked while a tick is handled and write-locked to drain in-flight ticks
+	streams  sync.WaitGroup // StreamMarketData calls that have not returned yet
+
+	pairsLock sync.Mutex
+	pairs     map[string]map[string]bool // Trading pairs that received ticks, per market
+}
+
+// trackPair records that a market and trading pair received a tick.
+func (l *lifecycle) trackPair(marketName, tradingPair string) {
+	l.pairsLock.Lock()
+	defer l.pairsLock.Unlock()
+
+	if l.pairs == nil {
+		l.pairs = make(map[string]map[string]bool)
+	}
+	if l.pairs[marketName] == nil {
+		l.pairs[marketName] = make(map[string]bool)
+	}
+	l.pairs[marketName][tradingPair] = true
+}
+
+// tradedPairs returns the trading pairs that received ticks, per market.
+func (l *lifecycle) tradedPairs() map[string][]string {
+	l.pairsLock.Lock()
+	defer l.pairsLock.Unlock()
+
+	pairs := make(map[string][]string, len(l.pairs))
+	for marketName, tradingPairs := range l.pairs {
+		for tradingPair := range tradingPairs {
+			pairs[marketName] = append(pairs[marketName], tradingPair)
+		}
+	}
+	return pairs
+}
+
+// SetCancelOrdersOnStop sets whether shutting down cancels the open orders on every trading pair that received ticks.
+func (f *Framework) SetCancelOrdersOnStop(cancel bool) {
+	f.lifecycle.mu.Lock()
+	defer f.lifecycle.mu.Unlock()
+	f.lifecycle.cancelOrdersOnStop = cancel
+}
+
+// Start loads recent ticks of the registered trading pairs from the large store into an empty fast store,
+// starts the registered strategies, then streams market data from every connector, passing each tick
+// through handleTick to processTickFunc, until ctx is cancelled or Stop is called. If a strategy fails to start,
+// Start returns its error without streaming. Connectors that implement types.DepthStreamer also stream order
+// book depth into the local books exposed by TickContext.OrderBook, unless they implement
+// types.OrderBookProvider and keep those books themselves. Later fills of the orders placed through ticks are
+// taken from connectors that implement types.OrderUpdateNotifier, or polled for on connectors that implement
+// types.OrderQuerier. Cancelling ctx shuts the framework down as Stop does, without waiting for the shutdown to finish.
+// A framework that has stopped cannot be started again, since its connectors cannot stream again.
+func (f *Framework) Start(ctx context.Context, processTickFunc func(ctx *types.TickContext)) error {
+	l := &f.lifecycle
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.running {
+		return errors.New("framework is already running")
+	}
+	if l.closed {
+		return errors.New("framework is closed")
+	}
+	// Connectors cannot stream again once stopped, e.g. their stop channels are already closed
+	if l.stopped {
+		return errors.New("framework has stopped and cannot be started again")
+	}
+	f.warmStartStores()
+	if err := f.startStrategies(); err != nil {
+		return err
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	l.running = true
+	l.cancel = cancel
+	l.done = make(chan struct{})
+	l.stopErr = nil
+	l.stopping.Store(false)
+
+	f.orders.mu.Lock()
+	pollInterval := f.orders.pollInterval
+	f.orders.mu.Unlock()
+	for name, connector := range f.connectors {
+		l.streams.Add(1)
+		go func(connector types.Connector, name string) {
+			defer l.streams.Done()
+			err := connector.StreamMarketData(func(ctx *types.TickContext) {
+				f.handleTickWhileRunning(name, connector, ctx, processTickFunc)
+			})
+			if err != nil {
+				log.Printf("Connector %s failed to stream market data: %v\n", name, err)
+			}
+		}(connector, name)
+
+		// Fills of resting orders are reported by the connector, or polled for if it can look orders up
+		if notifier, ok := connector.(types.OrderUpdateNotifier); ok {
+			marketName := name
+			notifier.OnOrderUpdate(func(order *types.Order) {
+				f.handleOrderUpdate(marketName, order)
+			})
+		} else if querier, ok := connector.(types.OrderQuerier); ok && pollInterval > 0 {
+			l.streams.Add(1)
+			go func(querier types.OrderQuerier, name string) {
+				defer l.streams.Done()
+				f.pollOrders(runCtx, name, querier, pollInterval)
+			}(querier, name)
+		}
+
+		// Connectors that keep their own books update them from the market data stream
+		if _, ok := connector.(types.OrderBookProvider); ok {
+			continue
+		}
+		if streamer, ok := connector.(types.DepthStreamer); ok {
+			l.streams.Add(1)
+			go func(streamer types.DepthStreamer, name string) {
+				defer l.streams.Done()
+				err := streamer.StreamDepth(func(update *types.OrderBookUpdate) {
+					f.handleDepth(name, streamer, update)
+				})
+				if err != nil {
+					log.Printf("Connector %s failed to stream order book depth: %v\n", name, err)
+				}
+			}(streamer, name)
+		}
+	}
+
+	go func(done chan struct{}) {
+		<-runCtx.Done()
+		err := f.shutdown()
+
+		l.mu.Lock()
+		l.running = false
+		l.stopped = true
+		l.stopErr = err
+		l.mu.Unlock()
+		close(done)
+	}(l.done)
+	return nil
+}
+
+// Stop shuts the framework down and waits until it has finished or ctx is done. Shutting down stops every
+// connector, waits for ticks already being handled and for the connectors' streams to return, cancels open
+// orders if SetCancelOrdersOnStop is set, stops the strategies and flushes the stores. Stop returns the errors
+// of the shutdown, and returns them again if called after the framework stopped because its context was cancelled.
+// Stop must not be called from middleware, since shutdown waits for the tick running the middleware.
+func (f *Framework) Stop(ctx context.Context) error {
+	l := &f.lifecycle
+	l.mu.Lock()
+	if !l.running {
+		defer l.mu.Unlock()
+		return l.stopErr
+	}
+	cancel, done := l.cancel, l.done
+	l.mu.Unlock()
+
+	cancel()
+	select {
+	case <-done:
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.stopErr
+	case <-ctx.Done():
+		return fmt.Errorf("framework did not stop in time: %w", ctx.Err())
+	}
+}
+
+// Close stops the framework like Stop, then stops the write-behind pipeline and closes the stores, releasing
+// the large store's writer goroutine and the stores' connections. If the framework does not stop before ctx
+// is done, Close returns without closing the stores. The framework cannot be started again once closed.
+func (f *Framework) Close(ctx context.Context) error {
+	stopErr := f.Stop(ctx)
+
+	l := &f.lifecycle
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.running {
+		return stopErr
+	}
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	if err := f.storeManager.Close(); err != nil {
+		return errors.Join(stopErr, fmt.Errorf("failed to close stores: %w", err))
+	}
+	return stopErr
+}
+
+// handleTickWhileRunning handles a tick unless shutdown has begun, so no tick starts after shutdown drains them.
+func (f *Framework) handleTickWhileRunning(name string, connector types.Connector, ctx *types.TickContext, processTickFunc func(ctx *types.TickContext)) {
+	f.lifecycle.ticks.RLock()
+	defer f.lifecycle.ticks.RUnlock()
+
+	if f.lifecycle.stopping.Load() {
+		return
+	}
+	f.handleTick(name, connector, ctx, processTickFunc)
+}
+
+// shutdown stops the connectors, drains in-flight ticks, optionally cancels open orders, stops the strategies
+// and flushes the stores, returning every error encountered along the way.
+func (f *Framework) shutdown() error {
+	l := &f.lifecycle
+	l.stopping.Store(true)
+
+	var errs []error
+	for name, connector := range f.connectors {
+		if err := connector.StopStreaming(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop connector %s: %w", name, err))
+		}
+	}
+
+	// Wait for ticks already being handled, then for every stream to return
+	l.ticks.Lock()
+	l.ticks.Unlock()
+	l.streams.Wait()
+
+	l.mu.Lock()
+	cancelOrders := l.cancelOrdersOnStop
+	l.mu.Unlock()
+	if cancelOrders {
+		errs = append(errs, f.cancelOpenOrders()...)
+	}
+	errs = append(errs, f.stopStrategies()...)
+
+	if err := f.storeManager.Flush(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to flush stores: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// cancelOpenOrders cancels the open orders on every market and trading pair that received ticks,
+// applying any fills reported by the cancellations to the portfolio.
+func (f *Framework) cancelOpenOrders() []error {
+	var errs []error
+	for marketName, tradingPairs := range f.lifecycle.tradedPairs() {
+		connector, ok := f.connectors[marketName]
+		if !ok {
+			continue
+		}
+		for _, tradingPair := range tradingPairs {
+			orders, err := connector.GetOpenOrders(tradingPair)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to list open orders for %s on %s: %w", tradingPair, marketName, err))
+				continue
+			}
+			for _, order := range orders {
+				if order == nil || !order.IsOpen() {
+					continue
+				}
+				orderID := order.ExchangeOrderID
+				if orderID == "" {
+					orderID = order.ClientOrderID
+				}
+				cancelled, err := connector.CancelOrder(tradingPair, orderID)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("failed to cancel order %s for %s on %s: %w", orderID, tradingPair, marketName, err))
+					continue
+				}
+				f.portfolio.ApplyOrder(marketName, cancelled)
+				log.Printf("Cancelled order %s for %s on %s during shutdown.", orderID, tradingPair, marketName)
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,352 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/bigmeech/tradingbot/internal/connectors"
+	"github.com/bigmeech/tradingbot/internal/indicators"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// streamingConnector streams ticks until StopStreaming is called and reports a fixed set of open orders.
+type streamingConnector struct {
+	MockConnector
+	stopCh     chan struct{}
+	stopOnce   sync.Once
+	returned   atomic.Bool // Set once StreamMarketData has returned
+	openOrders []*types.Order
+
+	mu        sync.Mutex
+	cancelled []string
+}
+
+func newStreamingConnector(openOrders ...*types.Order) *streamingConnector {
+	return &streamingConnector{stopCh: make(chan struct{}), openOrders: openOrders}
+}
+
+// StreamMarketData blocks, sending a tick every millisecond until the stream is stopped.
+func (s *streamingConnector) StreamMarketData(handler func(ctx *types.TickContext)) error {
+	defer s.returned.Store(true)
+	for price := 100.0; ; price++ {
+		select {
+		case <-s.stopCh:
+			return nil
+		case <-time.After(time.Millisecond):
+		}
+		handler(&types.TickContext{
+			TradingPair: "BTC/USDT",
+			MarketData:  &types.MarketData{Price: price, Volume: 1, Time: 1700000000000 + int64(price)},
+		})
+	}
+}
+
+func (s *streamingConnector) StopStreaming() error {
+	s.stopOnce.Do(func() { close(s.stopCh) })
+	return nil
+}
+
+func (s *streamingConnector) GetOpenOrders(tradingPair string) ([]*types.Order, error) {
+	return s.openOrders, nil
+}
+
+func (s *streamingConnector) CancelOrder(tradingPair, orderID string) (*types.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cancelled = append(s.cancelled, orderID)
+	return &types.Order{ExchangeOrderID: orderID, TradingPair: tradingPair, Status: types.OrderStatusCancelled}, nil
+}
+
+// flushingStore counts flushes of a MockStore.
+type flushingStore struct {
+	*MockStore
+	flushes atomic.Int32
+	err     error
+}
+
+func (s *flushingStore) Flush() error {
+	s.flushes.Add(1)
+	return s.err
+}
+
+// closingStore counts closes of a MockStore.
+type closingStore struct {
+	*MockStore
+	closes atomic.Int32
+}
+
+func (s *closingStore) Close() error {
+	s.closes.Add(1)
+	return nil
+}
+
+// waitForTicks blocks until count ticks were processed.
+func waitForTicks(t *testing.T, processed *atomic.Int32, count int32) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for processed.Load() < count {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d ticks to be processed, got %d", count, processed.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFramework_StopDrainsTicksAndFlushes(t *testing.T) {
+	largeStore := &flushingStore{MockStore: NewMockStore()}
+	framework := NewFramework(NewStoreManager(NewInMemoryFastStore(10), largeStore, 5))
+	connector := newStreamingConnector()
+	framework.RegisterConnector("Stream", connector)
+
+	var processed, inFlight atomic.Int32
+	processTick := func(ctx *types.TickContext) {
+		inFlight.Add(1)
+		time.Sleep(2 * time.Millisecond) // Give Stop a chance to begin while the tick is running
+		processed.Add(1)
+		inFlight.Add(-1)
+	}
+	if err := framework.Start(context.Background(), processTick); err != nil {
+		t.Fatalf("Expected framework to start, got %v", err)
+	}
+	if err := framework.Start(context.Background(), processTick); err == nil {
+		t.Error("Expected starting a running framework to fail")
+	}
+	waitForTicks(t, &processed, 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := framework.Stop(ctx); err != nil {
+		t.Fatalf("Expected framework to stop cleanly, got %v", err)
+	}
+	if inFlight.Load() != 0 {
+		t.Error("Expected Stop to wait for in-flight ticks")
+	}
+	if !connector.returned.Load() {
+		t.Error("Expected Stop to wait for the connector's stream to return")
+	}
+	if largeStore.flushes.Load() != 1 {
+		t.Errorf("Expected the large store to be flushed once, got %d", largeStore.flushes.Load())
+	}
+
+	count := processed.Load()
+	time.Sleep(10 * time.Millisecond)
+	if processed.Load() != count {
+		t.Errorf("Expected no ticks after Stop, got %d more", processed.Load()-count)
+	}
+	if err := framework.Stop(ctx); err != nil {
+		t.Errorf("Expected stopping a stopped framework to succeed, got %v", err)
+	}
+}
+
+func TestFramework_ContextCancellationStops(t *testing.T) {
+	largeStore := &flushingStore{MockStore: NewMockStore(), err: errors.New("disk full")}
+	framework := NewFramework(NewStoreManager(NewInMemoryFastStore(10), largeStore, 5))
+	connector := newStreamingConnector()
+	framework.RegisterConnector("Stream", connector)
+
+	var processed atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := framework.Start(ctx, func(*types.TickContext) { processed.Add(1) }); err != nil {
+		t.Fatalf("Expected framework to start, got %v", err)
+	}
+	waitForTicks(t, &processed, 1)
+	cancel()
+
+	select {
+	case <-connector.stopCh:
+	case <-time.After(time.Second):
+		t.Fatal("Expected cancelling the context to stop the connector")
+	}
+
+	// Stop waits for the shutdown started by the cancellation and reports its errors
+	stopCtx, stopCancel := context.WithTimeout(context.Background(), time.Second)
+	defer stopCancel()
+	if err := framework.Stop(stopCtx); err == nil || !errors.Is(err, largeStore.err) {
+		t.Errorf("Expected the flush error from shutdown, got %v", err)
+	}
+
+	// The connector's stream cannot be restarted, so neither can the framework
+	if err := framework.Start(context.Background(), func(*types.TickContext) {}); err == nil {
+		t.Error("Expected starting a stopped framework to fail")
+	}
+}
+
+func TestFramework_StopCancelsOpenOrders(t *testing.T) {
+	framework := NewFramework(NewStoreManager(NewInMemoryFastStore(10), NewMockStore(), 5))
+	connector := newStreamingConnector(
+		&types.Order{ExchangeOrderID: "1", TradingPair: "BTC/USDT", Status: types.OrderStatusNew},
+		&types.Order{ClientOrderID: "client-2", TradingPair: "BTC/USDT", Status: types.OrderStatusPartiallyFilled},
+		&types.Order{ExchangeOrderID: "3", TradingPair: "BTC/USDT", Status: types.OrderStatusFilled},
+	)
+	framework.RegisterConnector("Stream", connector)
+	framework.SetCancelOrdersOnStop(true)
+
+	var processed atomic.Int32
+	if err := framework.Start(context.Background(), func(*types.TickContext) { processed.Add(1) }); err != nil {
+		t.Fatalf("Expected framework to start, got %v", err)
+	}
+	waitForTicks(t, &processed, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := framework.Stop(ctx); err != nil {
+		t.Fatalf("Expected framework to stop cleanly, got %v", err)
+	}
+	if len(connector.cancelled) != 2 || connector.cancelled[0] != "1" || connector.cancelled[1] != "client-2" {
+		t.Errorf("Expected open orders 1 and client-2 to be cancelled, got %v", connector.cancelled)
+	}
+}
+
+func TestFramework_CloseStopsWriteBehindAndStores(t *testing.T) {
+	largeStore := &closingStore{MockStore: NewMockStore()}
+	storeManager := NewStoreManager(NewInMemoryFastStore(10), largeStore, 5)
+	if err := storeManager.EnableWriteBehind(WriteBehindConfig{FlushInterval: time.Hour}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	framework := NewFramework(storeManager)
+	framework.RegisterConnector("Stream", newStreamingConnector())
+
+	var processed atomic.Int32
+	if err := framework.Start(context.Background(), func(*types.TickContext) { processed.Add(1) }); err != nil {
+		t.Fatalf("Expected framework to start, got %v", err)
+	}
+	waitForTicks(t, &processed, 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := framework.Close(ctx); err != nil {
+		t.Fatalf("Expected framework to close cleanly, got %v", err)
+	}
+	select {
+	case <-storeManager.writeBehind.done:
+	default:
+		t.Error("Expected Close to stop the write-behind goroutine")
+	}
+	if metrics := storeManager.WriteMetrics(); metrics.Written != metrics.Queued || metrics.Written < 3 {
+		t.Errorf("Expected every queued tick to be written before closing, got %+v", metrics)
+	}
+	if largeStore.closes.Load() != 1 {
+		t.Errorf("Expected the large store to be closed once, got %d", largeStore.closes.Load())
+	}
+
+	if err := framework.Close(ctx); err != nil || largeStore.closes.Load() != 1 {
+		t.Errorf("Expected closing again to do nothing, got %v and %d closes", err, largeStore.closes.Load())
+	}
+	if err := framework.Start(context.Background(), func(*types.TickContext) {}); err == nil {
+		t.Error("Expected a closed framework not to start")
+	}
+}
+
+func TestFramework_StopTimesOut(t *testing.T) {
+	framework := NewFramework(NewStoreManager(NewInMemoryFastStore(10), NewMockStore(), 5))
+	connector := newStreamingConnector()
+	framework.RegisterConnector("Stream", connector)
+
+	release := make(chan struct{})
+	var processed atomic.Int32
+	if err := framework.Start(context.Background(), func(*types.TickContext) {
+		processed.Add(1)
+		<-release // Hold the tick until the test releases it
+	}); err != nil {
+		t.Fatalf("Expected framework to start, got %v", err)
+	}
+	waitForTicks(t, &processed, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := framework.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Stop to give up at the deadline, got %v", err)
+	}
+
+	close(release)
+	if err := framework.Stop(context.Background()); err != nil {
+		t.Errorf("Expected Stop to finish once the tick completes, got %v", err)
+	}
+}
+
+func TestFramework_StartWarmStartsStores(t *testing.T) {
+	largeStore := NewInMemoryFastStore(100)
+	for i := 1; i <= 5; i++ {
+		largeStore.RecordTick("Stream", "BTC/USDT", &types.MarketData{Price: float64(i * 100), Time: int64(i)})
+	}
+	framework := NewFramework(NewStoreManager(NewInMemoryFastStore(10), largeStore, 5))
+	framework.RegisterIndicator("Stream", "BTC/USDT", indicators.NewSMA(5))
+
+	if err := framework.Start(context.Background(), func(*types.TickContext) {}); err != nil {
+		t.Fatalf("Expected framework to start, got %v", err)
+	}
+	defer framework.Stop(context.Background())
+
+	if prices := framework.QueryPriceHistory("Stream", "BTC/USDT", 5); len(prices) != 5 || prices[4] != 500 {
+		t.Errorf("Expected the history to be loaded from the large store, got %v", prices)
+	}
+}
+
+func TestFramework_StopClosesDepthStreamingConnection(t *testing.T) {
+	closed := make(chan struct{})
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v3/depth" {
+			w.Write([]byte(`{"lastUpdateId":10,"bids":[["100.0","1.0"]],"asks":[["101.0","1.0"]]}`))
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Failed to upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		// Stream depth diffs until the client closes the connection
+		go func() {
+			for sequence := 1; ; sequence++ {
+				diff := fmt.Sprintf(`{"e":"depthUpdate","E":1700000000000,"s":"BTCUSDT","U":%d,"u":%d,"b":[["100.0","2.0"]],"a":[]}`, sequence, sequence)
+				if err := conn.WriteMessage(websocket.TextMessage, []byte(diff)); err != nil {
+					return
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+		}()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				close(closed)
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	framework := NewFramework(NewStoreManager(NewInMemoryFastStore(10), NewMockStore(), 5))
+	framework.RegisterConnector("Binance", connectors.NewBinanceConnector("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", server.URL, "key", "secret"))
+	if err := framework.Start(context.Background(), func(*types.TickContext) {}); err != nil {
+		t.Fatalf("Expected framework to start, got %v", err)
+	}
+
+	// A synced book shows the depth handler is registered alongside the market data stream
+	deadline := time.Now().Add(2 * time.Second)
+	for framework.OrderBook("Binance", "BTCUSDT", 1) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the depth stream to sync the order book")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := framework.Stop(ctx); err != nil {
+		t.Fatalf("Expected framework to stop cleanly, got %v", err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected Stop to close the connection shared by the market data and depth streams")
+	}
+}
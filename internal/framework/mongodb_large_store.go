@@ -2,53 +2,323 @@ package framework
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/bigmeech/tradingbot/pkg/models"
 	"github.com/bigmeech/tradingbot/pkg/types"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
 	"time"
 )
 
+// MongoDB error codes handled when preparing the collection.
+const (
+	indexNotFoundCode        = 27 // Dropping an index that does not exist
+	namespaceExistsCode      = 48 // Creating a collection that already exists
+	indexOptionsConflictCode = 85 // Creating an index that exists with other options, e.g. another TTL
+)
+
+// retentionIndexName is the name of the TTL index enforcing the retention period of regular collections.
+const retentionIndexName = "retention"
+
+// MongoDBLargeStoreOptions configures how a MongoDBLargeStore lays out and keeps ticks.
+type MongoDBLargeStoreOptions struct {
+	TimeSeries bool          // Create the collection as a time-series collection, which requires MongoDB 5.0 or later
+	Retention  time.Duration // Delete ticks older than this, zero to keep them forever
+	Timeout    time.Duration // Limit on connecting and on each read and write, 10 seconds if zero
+}
+
+// MongoDBLargeStore keeps the tick history of every market and trading pair in one MongoDB collection.
+// Ticks are keyed by market, trading pair and time, with a compound index serving the per-pair queries.
 type MongoDBLargeStore struct {
 	client     *mongo.Client
 	collection *mongo.Collection
-	ctx        context.Context
+	timeout    time.Duration
 }
 
-// NewMongoDBLargeStore initializes a connection to MongoDB.
-func NewMongoDBLargeStore(uri, dbName, collectionName string) (*MongoDBLargeStore, error) {
-	ctx, _ := context.WithTimeout(context.Background(), 10*time.Second)
+// mongoTick is the document a tick is stored as. The market and trading pair are kept in meta, which is the
+// metaField of time-series collections.
+type mongoTick struct {
+	Time        time.Time     `bson:"time"`
+	Meta        mongoTickMeta `bson:"meta"`
+	Price       float64       `bson:"price"`
+	Volume      float64       `bson:"volume"`
+	Bid         float64       `bson:"bid,omitempty"`
+	Ask         float64       `bson:"ask,omitempty"`
+	Side        string        `bson:"side,omitempty"`
+	TradeID     string        `bson:"tradeId,omitempty"`
+	ReceiveTime int64         `bson:"receiveTime,omitempty"`
+}
+
+type mongoTickMeta struct {
+	Market      string `bson:"market"`
+	TradingPair string `bson:"pair"`
+}
+
+// NewMongoDBLargeStore connects to MongoDB and prepares the collection, creating it as a time-series
+// collection if asked to, and its indexes and retention policy.
+func NewMongoDBLargeStore(uri, dbName, collectionName string, opts MongoDBLargeStoreOptions) (*MongoDBLargeStore, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
 	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
 	if err != nil {
 		return nil, err
 	}
-	collection := client.Database(dbName).Collection(collectionName)
+	db := client.Database(dbName)
+	if err := createTickCollection(ctx, db, collectionName, opts); err != nil {
+		client.Disconnect(context.Background())
+		return nil, err
+	}
 
-	return &MongoDBLargeStore{
+	store := &MongoDBLargeStore{
 		client:     client,
-		collection: collection,
-		ctx:        ctx,
-	}, nil
+		collection: db.Collection(collectionName),
+		timeout:    opts.Timeout,
+	}
+	if err := store.createIndexes(ctx, opts); err != nil {
+		client.Disconnect(context.Background())
+		return nil, err
+	}
+	return store, nil
 }
 
-// RecordTick stores new market data in MongoDB.
-func (m *MongoDBLargeStore) RecordTick(tradingPair string, marketData *types.MarketData) error {
-	_, err := m.collection.InsertOne(m.ctx, marketData)
-	return err
+// createTickCollection creates the collection as a time-series collection, expiring ticks after the retention
+// period, if asked to. If the time-series collection exists its retention period is updated. Regular
+// collections are created on the first insert.
+func createTickCollection(ctx context.Context, db *mongo.Database, name string, opts MongoDBLargeStoreOptions) error {
+	if !opts.TimeSeries {
+		return nil
+	}
+	createOpts := options.CreateCollection().SetTimeSeriesOptions(
+		options.TimeSeries().SetTimeField("time").SetMetaField("meta").SetGranularity("seconds"))
+	if opts.Retention > 0 {
+		createOpts.SetExpireAfterSeconds(int64(opts.Retention.Seconds()))
+	}
+	err := db.CreateCollection(ctx, name, createOpts)
+	if isCommandError(err, namespaceExistsCode) {
+		if err := db.RunCommand(ctx, collectionExpiryCommand(name, opts.Retention)).Err(); err != nil {
+			return fmt.Errorf("failed to update the retention period of %s: %w", name, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create time-series collection %s: %w", name, err)
+	}
+	return nil
 }
 
-// QueryPriceHistory retrieves price history from MongoDB.
-func (m *MongoDBLargeStore) QueryPriceHistory(tradingPair string, period int) []float64 {
-	cursor, err := m.collection.Find(m.ctx, nil, options.Find().SetSort(map[string]int{"_id": -1}).SetLimit(int64(period)))
+// createIndexes creates the compound index on market, trading pair and time, and for regular collections
+// the TTL index enforcing the retention period.
+func (m *MongoDBLargeStore) createIndexes(ctx context.Context, opts MongoDBLargeStoreOptions) error {
+	_, err := m.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "meta.market", Value: 1}, {Key: "meta.pair", Value: 1}, {Key: "time", Value: 1}},
+		Options: options.Index().SetName("market_pair_time"),
+	})
 	if err != nil {
+		return fmt.Errorf("failed to create indexes on %s: %w", m.collection.Name(), err)
+	}
+	if opts.TimeSeries {
 		return nil
 	}
-	defer cursor.Close(m.ctx)
+	if err := m.setRetentionIndex(ctx, opts.Retention); err != nil {
+		return fmt.Errorf("failed to set the retention period of %s: %w", m.collection.Name(), err)
+	}
+	return nil
+}
+
+// setRetentionIndex creates the TTL index of a regular collection, changes its period with collMod if it
+// exists with another one, or drops it if ticks are kept forever.
+func (m *MongoDBLargeStore) setRetentionIndex(ctx context.Context, retention time.Duration) error {
+	if retention <= 0 {
+		_, err := m.collection.Indexes().DropOne(ctx, retentionIndexName)
+		if isCommandError(err, indexNotFoundCode) {
+			return nil
+		}
+		return err
+	}
+	_, err := m.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "time", Value: 1}},
+		Options: options.Index().SetName(retentionIndexName).SetExpireAfterSeconds(int32(retention.Seconds())),
+	})
+	if isCommandError(err, indexOptionsConflictCode) {
+		return m.collection.Database().RunCommand(ctx, retentionIndexCommand(m.collection.Name(), retention)).Err()
+	}
+	return err
+}
+
+// retentionIndexCommand is the collMod command changing the period of a regular collection's TTL index.
+func retentionIndexCommand(collection string, retention time.Duration) bson.D {
+	return bson.D{
+		{Key: "collMod", Value: collection},
+		{Key: "index", Value: bson.D{
+			{Key: "name", Value: retentionIndexName},
+			{Key: "expireAfterSeconds", Value: int64(retention.Seconds())},
+		}},
+	}
+}
 
-	prices := []float64{}
-	for cursor.Next(m.ctx) {
-		var data types.MarketData
-		cursor.Decode(&data)
-		prices = append(prices, data.Price)
+// collectionExpiryCommand is the collMod command changing the retention period of a time-series collection,
+// turning expiry off if ticks are kept forever.
+func collectionExpiryCommand(collection string, retention time.Duration) bson.D {
+	var expireAfter interface{} = "off"
+	if retention > 0 {
+		expireAfter = int64(retention.Seconds())
+	}
+	return bson.D{{Key: "collMod", Value: collection}, {Key: "expireAfterSeconds", Value: expireAfter}}
+}
+
+// isCommandError reports whether err is a MongoDB command error with the given code.
+func isCommandError(err error, code int32) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Code == code
+}
+
+// Close disconnects from MongoDB. The store cannot be used afterwards.
+func (m *MongoDBLargeStore) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+	return m.client.Disconnect(ctx)
+}
+
+// RecordTick stores new market data of a market and trading pair in MongoDB.
+func (m *MongoDBLargeStore) RecordTick(market, tradingPair string, marketData *types.MarketData) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	_, err := m.collection.InsertOne(ctx, newMongoTick(market, tradingPair, marketData))
+	return err
+}
+
+// RecordTicks stores a batch of ticks in MongoDB with a single unordered insert.
+func (m *MongoDBLargeStore) RecordTicks(records []models.TickRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	docs := make([]interface{}, len(records))
+	for i := range records {
+		docs[i] = newMongoTick(records[i].Market, records[i].TradingPair, &records[i].MarketData)
+	}
+	_, err := m.collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	return err
+}
+
+// QueryPriceHistory retrieves up to period of the most recent prices of a market and trading pair, oldest first.
+func (m *MongoDBLargeStore) QueryPriceHistory(market, tradingPair string, period int) []float64 {
+	ticks := m.QueryTicks(market, tradingPair, 0, period)
+	prices := make([]float64, len(ticks))
+	for i, tick := range ticks {
+		prices[i] = tick.Price
 	}
 	return prices
 }
+
+// QueryTicks retrieves up to count of the most recent ticks of a market and trading pair with a time before
+// the given Unix millisecond time, or the most recent ticks if before is zero, oldest first. Errors are logged
+// and return no ticks.
+func (m *MongoDBLargeStore) QueryTicks(market, tradingPair string, before int64, count int) []types.MarketData {
+	if count <= 0 {
+		return []types.MarketData{}
+	}
+	filter := tickFilter(market, tradingPair, 0, before)
+	findOpts := options.Find().SetSort(bson.D{{Key: "time", Value: -1}}).SetLimit(int64(count))
+	ticks, err := m.find(filter, findOpts)
+	if err != nil {
+		log.Printf("Failed to query ticks of %s %s from MongoDB: %v\n", market, tradingPair, err)
+		return []types.MarketData{}
+	}
+	// Newest first from the query, oldest first to the caller
+	reverseTicks(ticks)
+	return ticks
+}
+
+// QueryTicksRange retrieves the ticks of a market and trading pair with a time from the start up to but not
+// including the end, in Unix milliseconds, oldest first. A zero end leaves the range open.
+func (m *MongoDBLargeStore) QueryTicksRange(market, tradingPair string, start, end int64) ([]types.MarketData, error) {
+	filter := tickFilter(market, tradingPair, start, end)
+	return m.find(filter, options.Find().SetSort(bson.D{{Key: "time", Value: 1}}))
+}
+
+// find runs a query and decodes the ticks it returns.
+func (m *MongoDBLargeStore) find(filter bson.D, findOpts *options.FindOptions) ([]types.MarketData, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	cursor, err := m.collection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	ticks := []types.MarketData{}
+	for cursor.Next(ctx) {
+		var doc mongoTick
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		ticks = append(ticks, doc.marketData())
+	}
+	return ticks, cursor.Err()
+}
+
+// tickFilter matches the ticks of a market and trading pair with a time from start up to but not including
+// end, in Unix milliseconds. Zero leaves that side of the range open.
+func tickFilter(market, tradingPair string, start, end int64) bson.D {
+	filter := bson.D{{Key: "meta.market", Value: market}, {Key: "meta.pair", Value: tradingPair}}
+	timeRange := bson.D{}
+	if start != 0 {
+		timeRange = append(timeRange, bson.E{Key: "$gte", Value: time.UnixMilli(start)})
+	}
+	if end != 0 {
+		timeRange = append(timeRange, bson.E{Key: "$lt", Value: time.UnixMilli(end)})
+	}
+	if len(timeRange) > 0 {
+		filter = append(filter, bson.E{Key: "time", Value: timeRange})
+	}
+	return filter
+}
+
+// newMongoTick builds the document of a tick. Ticks without an exchange time are stored at the time they were
+// received, or the current time if that is unknown too.
+func newMongoTick(market, tradingPair string, data *types.MarketData) mongoTick {
+	tickTime := data.Time
+	if tickTime == 0 {
+		tickTime = data.ReceiveTime
+	}
+	if tickTime == 0 {
+		tickTime = time.Now().UnixMilli()
+	}
+	return mongoTick{
+		Time:        time.UnixMilli(tickTime),
+		Meta:        mongoTickMeta{Market: market, TradingPair: tradingPair},
+		Price:       data.Price,
+		Volume:      data.Volume,
+		Bid:         data.Bid,
+		Ask:         data.Ask,
+		Side:        string(data.Side),
+		TradeID:     data.TradeID,
+		ReceiveTime: data.ReceiveTime,
+	}
+}
+
+// marketData returns the tick a document holds.
+func (t mongoTick) marketData() types.MarketData {
+	return types.MarketData{
+		Price:       t.Price,
+		Volume:      t.Volume,
+		Time:        t.Time.UnixMilli(),
+		Bid:         t.Bid,
+		Ask:         t.Ask,
+		Side:        types.OrderSide(t.Side),
+		TradeID:     t.TradeID,
+		ReceiveTime: t.ReceiveTime,
+	}
+}
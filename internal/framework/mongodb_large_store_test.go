@@ -0,0 +1,81 @@
+package framework
+
+import (
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"testing"
+	"time"
+)
+
+func TestMongoTick_RoundTrip(t *testing.T) {
+	data := types.MarketData{Price: 100, Volume: 2, Time: 1700000000123, Bid: 99.5, Ask: 100.5, Side: types.OrderSideBuy, TradeID: "42", ReceiveTime: 1700000000150}
+	raw, err := bson.Marshal(newMongoTick("Binance", "BTC/USDT", &data))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var doc mongoTick
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if doc.Meta.Market != "Binance" || doc.Meta.TradingPair != "BTC/USDT" {
+		t.Errorf("Expected the tick to be keyed by market and pair, got %+v", doc.Meta)
+	}
+	if got := doc.marketData(); got != data {
+		t.Errorf("Expected %+v back, got %+v", data, got)
+	}
+
+	// Ticks without an exchange time are stored at their receive time
+	doc = newMongoTick("Binance", "BTC/USDT", &types.MarketData{Price: 100, ReceiveTime: 1700000000150})
+	if doc.Time.UnixMilli() != 1700000000150 {
+		t.Errorf("Expected the receive time, got %v", doc.Time)
+	}
+}
+
+func TestTickFilter(t *testing.T) {
+	filter := tickFilter("Binance", "BTC/USDT", 1000, 2000)
+	expected := bson.D{
+		{Key: "meta.market", Value: "Binance"},
+		{Key: "meta.pair", Value: "BTC/USDT"},
+		{Key: "time", Value: bson.D{{Key: "$gte", Value: time.UnixMilli(1000)}, {Key: "$lt", Value: time.UnixMilli(2000)}}},
+	}
+	got, _ := bson.MarshalExtJSON(filter, true, false)
+	want, _ := bson.MarshalExtJSON(expected, true, false)
+	if string(got) != string(want) {
+		t.Errorf("Expected filter %s, got %s", want, got)
+	}
+
+	if filter := tickFilter("Binance", "BTC/USDT", 0, 0); len(filter) != 2 {
+		t.Errorf("Expected no time range for an open query, got %v", filter)
+	}
+}
+
+func TestRetentionCommands(t *testing.T) {
+	tests := []struct {
+		name    string
+		command bson.D
+		want    string
+	}{
+		{
+			name:    "index",
+			command: retentionIndexCommand("ticks", 30*24*time.Hour),
+			want:    `{"collMod":"ticks","index":{"name":"retention","expireAfterSeconds":{"$numberLong":"2592000"}}}`,
+		},
+		{
+			name:    "time-series",
+			command: collectionExpiryCommand("ticks", time.Hour),
+			want:    `{"collMod":"ticks","expireAfterSeconds":{"$numberLong":"3600"}}`,
+		},
+		{
+			name:    "time-series without retention",
+			command: collectionExpiryCommand("ticks", 0),
+			want:    `{"collMod":"ticks","expireAfterSeconds":"off"}`,
+		},
+	}
+	for _, test := range tests {
+		got, _ := bson.MarshalExtJSON(test.command, true, false)
+		if string(got) != test.want {
+			t.Errorf("%s: expected %s, got %s", test.name, test.want, got)
+		}
+	}
+}
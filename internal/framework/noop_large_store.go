@@ -0,0 +1,23 @@
+package framework
+
+import (
+	"github.com/bigmeech/tradingbot/pkg/types"
+)
+
+// NoopLargeStore is a LargeStore that keeps nothing, for bots that only need the recent history in their fast store.
+type NoopLargeStore struct{}
+
+// NewNoopLargeStore initializes a NoopLargeStore.
+func NewNoopLargeStore() *NoopLargeStore {
+	return &NoopLargeStore{}
+}
+
+// RecordTick discards the market data.
+func (NoopLargeStore) RecordTick(market, tradingPair string, marketData *types.MarketData) error {
+	return nil
+}
+
+// QueryPriceHistory returns no history.
+func (NoopLargeStore) QueryPriceHistory(market, tradingPair string, period int) []float64 {
+	return []float64{}
+}
@@ -0,0 +1,166 @@
+package framework
+
+import (
+	"context"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultOrderPollInterval is how often the open orders placed through ticks are looked up on connectors
+// that implement types.OrderQuerier but do not report order updates themselves.
+const DefaultOrderPollInterval = 5 * time.Second
+
+// trackedOrder is an open order placed through a tick, followed until it is filled, cancelled or rejected.
+type trackedOrder struct {
+	marketName string
+	runner     *strategyRunner // Strategy that placed the order, nil if middleware placed it
+	order      *types.Order    // Last state seen
+}
+
+// orderTracker follows the open orders placed through ticks, so fills reported after an order was placed
+// reach the portfolio and the strategy that placed it.
+type orderTracker struct {
+	mu           sync.Mutex
+	orders       map[string]*trackedOrder           // Open orders by market and exchange order ID
+	pending      map[*strategyRunner][]*types.Order // Updates not yet passed to the strategies' OnOrderUpdate
+	pollInterval time.Duration
+}
+
+// orderKey identifies an order on a market.
+func orderKey(marketName, orderID string) string {
+	return marketName + ":" + orderID
+}
+
+// SetOrderPollInterval sets how often the open orders placed through ticks are looked up on connectors that
+// implement types.OrderQuerier but not types.OrderUpdateNotifier. Zero or less disables polling.
+func (f *Framework) SetOrderPollInterval(interval time.Duration) {
+	f.orders.mu.Lock()
+	defer f.orders.mu.Unlock()
+	f.orders.pollInterval = interval
+}
+
+// trackOrder follows an order returned by an order function of a tick while it is open, and stops
+// following it once it is not. runner is the strategy that placed the order, if any.
+func (f *Framework) trackOrder(marketName string, runner *strategyRunner, order *types.Order) {
+	if order == nil || order.ExchangeOrderID == "" {
+		return
+	}
+	t := &f.orders
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := orderKey(marketName, order.ExchangeOrderID)
+	if !order.IsOpen() {
+		delete(t.orders, key)
+		return
+	}
+	tracked, ok := t.orders[key]
+	if !ok {
+		tracked = &trackedOrder{marketName: marketName}
+		if t.orders == nil {
+			t.orders = make(map[string]*trackedOrder)
+		}
+		t.orders[key] = tracked
+	}
+	if runner != nil {
+		tracked.runner = runner
+	}
+	tracked.order = order.Clone()
+}
+
+// untrackOrder stops following an order, e.g. one replaced by an amendment.
+func (f *Framework) untrackOrder(marketName, orderID string) {
+	f.orders.mu.Lock()
+	defer f.orders.mu.Unlock()
+	delete(f.orders.orders, orderKey(marketName, orderID))
+}
+
+// handleOrderUpdate applies a later state of a followed order to the portfolio and queues it for the strategy
+// that placed it, which receives it before its next tick. Orders that are not followed, or have not changed, are ignored.
+func (f *Framework) handleOrderUpdate(marketName string, update *types.Order) {
+	if update == nil {
+		return
+	}
+	t := &f.orders
+	t.mu.Lock()
+	key := orderKey(marketName, update.ExchangeOrderID)
+	tracked, ok := t.orders[key]
+	if !ok || (update.Status == tracked.order.Status && update.FilledQuantity == tracked.order.FilledQuantity) {
+		t.mu.Unlock()
+		return
+	}
+
+	// Keep the identity the order was placed with, so its fills are applied to the portfolio only once
+	order := update.Clone()
+	order.ClientOrderID = tracked.order.ClientOrderID
+	order.TradingPair = tracked.order.TradingPair
+	if order.Side == "" {
+		order.Side = tracked.order.Side
+	}
+	if order.Type == "" {
+		order.Type = tracked.order.Type
+	}
+	tracked.order = order
+	if !order.IsOpen() {
+		delete(t.orders, key)
+	}
+	if tracked.runner != nil {
+		if t.pending == nil {
+			t.pending = make(map[*strategyRunner][]*types.Order)
+		}
+		t.pending[tracked.runner] = append(t.pending[tracked.runner], order.Clone())
+	}
+	t.mu.Unlock()
+
+	f.portfolio.ApplyOrder(marketName, order)
+}
+
+// deliverOrderUpdates passes the order updates queued for a strategy to its OnOrderUpdate.
+func (f *Framework) deliverOrderUpdates(runner *strategyRunner) {
+	f.orders.mu.Lock()
+	updates := f.orders.pending[runner]
+	delete(f.orders.pending, runner)
+	f.orders.mu.Unlock()
+
+	for _, order := range updates {
+		f.updateOrder(runner, order)
+	}
+}
+
+// trackedOrders returns snapshots of the followed orders of a market.
+func (f *Framework) trackedOrders(marketName string) []*types.Order {
+	f.orders.mu.Lock()
+	defer f.orders.mu.Unlock()
+
+	var orders []*types.Order
+	for _, tracked := range f.orders.orders {
+		if tracked.marketName == marketName {
+			orders = append(orders, tracked.order.Clone())
+		}
+	}
+	return orders
+}
+
+// pollOrders looks up the followed orders of a market every poll interval until ctx is done.
+func (f *Framework) pollOrders(ctx context.Context, marketName string, querier types.OrderQuerier, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, order := range f.trackedOrders(marketName) {
+			update, err := querier.GetOrder(order.TradingPair, order.ExchangeOrderID)
+			if err != nil {
+				log.Printf("Failed to look up order %s for %s on %s: %v\n", order.ExchangeOrderID, order.TradingPair, marketName, err)
+				continue
+			}
+			update.ExchangeOrderID = order.ExchangeOrderID
+			f.handleOrderUpdate(marketName, update)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package framework
+
+import (
+	"context"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// restingConnector rests every order it is sent until fill is called.
+type restingConnector struct {
+	MockConnector
+	mu     sync.Mutex
+	orders map[string]*types.Order
+}
+
+func newRestingConnector() *restingConnector {
+	return &restingConnector{orders: make(map[string]*types.Order)}
+}
+
+func (r *restingConnector) ExecuteOrder(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64) (*types.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order := &types.Order{
+		ClientOrderID:   types.NewClientOrderID(),
+		ExchangeOrderID: strconv.Itoa(len(r.orders) + 1),
+		TradingPair:     tradingPair,
+		Type:            orderType,
+		Side:            side,
+		Quantity:        amount,
+		Price:           price,
+		Status:          types.OrderStatusNew,
+	}
+	r.orders[order.ExchangeOrderID] = order.Clone()
+	return order, nil
+}
+
+// fill fills a resting order in full at price, returning the exchange's view of the filled order.
+func (r *restingConnector) fill(orderID string, price float64) *types.Order {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order := r.orders[orderID]
+	order.AddFill(types.Fill{Price: price, Quantity: order.Quantity})
+	return &types.Order{ExchangeOrderID: orderID, Status: order.Status, FilledQuantity: order.FilledQuantity, AveragePrice: order.AveragePrice}
+}
+
+// tick builds a tick whose ExecuteOrder sends orders to the connector.
+func (r *restingConnector) tick(price float64) *types.TickContext {
+	return &types.TickContext{
+		TradingPair: "BTC/USDT",
+		MarketData:  &types.MarketData{Price: price},
+		ExecuteOrder: func(orderType types.OrderType, side types.OrderSide, amount, price float64) (*types.Order, error) {
+			return r.ExecuteOrder(orderType, side, "BTC/USDT", amount, price)
+		},
+	}
+}
+
+// notifyingConnector reports order updates to the handler set with OnOrderUpdate.
+type notifyingConnector struct {
+	*restingConnector
+	handler func(order *types.Order)
+}
+
+func (n *notifyingConnector) OnOrderUpdate(handler func(order *types.Order)) {
+	n.handler = handler
+}
+
+// queryingConnector reports order updates when they are looked up.
+type queryingConnector struct {
+	*restingConnector
+}
+
+func (q *queryingConnector) GetOrder(tradingPair, orderID string) (*types.Order, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.orders[orderID].Clone(), nil
+}
+
+// limitStrategy places a single limit buy and records the order updates it receives.
+type limitStrategy struct {
+	types.BaseStrategy
+	placed bool
+
+	mu     sync.Mutex
+	orders []*types.Order
+}
+
+func (s *limitStrategy) Name() string { return "Limit" }
+
+func (s *limitStrategy) OnTick(ctx *types.TickContext) error {
+	if s.placed {
+		return nil
+	}
+	s.placed = true
+	_, err := ctx.ExecuteOrder(types.OrderTypeLimit, types.OrderSideBuy, 1, 95)
+	return err
+}
+
+func (s *limitStrategy) OnOrderUpdate(ctx *types.StrategyContext, order *types.Order) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orders = append(s.orders, order)
+	return nil
+}
+
+func (s *limitStrategy) statuses() []types.OrderStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var statuses []types.OrderStatus
+	for _, order := range s.orders {
+		statuses = append(statuses, order.Status)
+	}
+	return statuses
+}
+
+func TestFramework_OrderUpdatesFromNotifier(t *testing.T) {
+	framework := NewFramework(NewStoreManager(NewInMemoryFastStore(10), NewMockStore(), 5))
+	connector := &notifyingConnector{restingConnector: newRestingConnector()}
+	framework.RegisterConnector("Resting", connector)
+	strategy := &limitStrategy{}
+	framework.RegisterStrategy("Resting", "BTC/USDT", strategy)
+
+	if err := framework.Start(context.Background(), func(ctx *types.TickContext) {}); err != nil {
+		t.Fatalf("Expected framework to start, got %v", err)
+	}
+	defer framework.Stop(context.Background())
+	if connector.handler == nil {
+		t.Fatal("Expected Start to register an order update handler")
+	}
+
+	framework.handleTick("Resting", connector, connector.tick(100), func(ctx *types.TickContext) {})
+	if position := framework.Portfolio().Position("Resting", "BTC/USDT"); position.Quantity != 0 {
+		t.Fatalf("Expected no position while the order rests, got %+v", position)
+	}
+
+	filled := connector.fill("1", 95)
+	connector.handler(filled)
+	connector.handler(filled)
+	position := framework.Portfolio().Position("Resting", "BTC/USDT")
+	if position.Quantity != 1 || position.AverageEntryPrice != 95 {
+		t.Errorf("Expected the fill to be applied once at 95, got %+v", position)
+	}
+	if statuses := strategy.statuses(); len(statuses) != 1 {
+		t.Errorf("Expected the fill to wait for the strategy's next tick, got %v", statuses)
+	}
+
+	framework.handleTick("Resting", connector, connector.tick(96), func(ctx *types.TickContext) {})
+	statuses := strategy.statuses()
+	if len(statuses) != 2 || statuses[0] != types.OrderStatusNew || statuses[1] != types.OrderStatusFilled {
+		t.Errorf("Expected the strategy to see the order placed then filled, got %v", statuses)
+	}
+	if strategy.orders[1].ClientOrderID != strategy.orders[0].ClientOrderID || strategy.orders[1].Side != types.OrderSideBuy {
+		t.Errorf("Expected the update to keep the order's identity, got %+v", strategy.orders[1])
+	}
+}
+
+func TestFramework_OrderUpdatesFromPolling(t *testing.T) {
+	framework := NewFramework(NewStoreManager(NewInMemoryFastStore(10), NewMockStore(), 5))
+	framework.SetOrderPollInterval(time.Millisecond)
+	connector := &queryingConnector{restingConnector: newRestingConnector()}
+	framework.RegisterConnector("Resting", connector)
+	strategy := &limitStrategy{}
+	framework.RegisterStrategy("Resting", "BTC/USDT", strategy)
+
+	if err := framework.Start(context.Background(), func(ctx *types.TickContext) {}); err != nil {
+		t.Fatalf("Expected framework to start, got %v", err)
+	}
+	framework.handleTick("Resting", connector, connector.tick(100), func(ctx *types.TickContext) {})
+	connector.fill("1", 94)
+
+	deadline := time.Now().Add(time.Second)
+	for framework.Portfolio().Position("Resting", "BTC/USDT").Quantity != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the polled fill to reach the portfolio")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := framework.Stop(ctx); err != nil {
+		t.Fatalf("Expected framework to stop cleanly, got %v", err)
+	}
+	statuses := strategy.statuses()
+	if len(statuses) != 2 || statuses[1] != types.OrderStatusFilled {
+		t.Errorf("Expected the fill to reach the strategy before it stopped, got %v", statuses)
+	}
+	if position := framework.Portfolio().Position("Resting", "BTC/USDT"); position.AverageEntryPrice != 94 {
+		t.Errorf("Expected the position to be entered at 94, got %+v", position)
+	}
+}
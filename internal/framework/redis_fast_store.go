@@ -2,58 +2,206 @@ package framework
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/bigmeech/tradingbot/pkg/types"
 	"github.com/go-redis/redis/v8"
-	"strconv"
+	"log"
+	"time"
 )
 
+// RedisFastStoreOptions configures the keys and timeouts of a RedisFastStore.
+type RedisFastStoreOptions struct {
+	Prefix  string        // Prefix of every key, "tradingbot" if empty
+	Timeout time.Duration // Limit on each Redis call, 2 seconds if zero
+
+	// ReadOnly stops RecordTick and RecordCandle from writing, for processes that share a cache kept up to
+	// date by another process recording the same pairs.
+	ReadOnly bool
+}
+
+// RedisFastStore keeps the most recent ticks and candles of each market and trading pair in capped Redis lists,
+// newest first, as JSON. Lists are keyed by market and trading pair, so several bot processes can share them.
 type RedisFastStore struct {
-	client *redis.Client
-	limit  int
-	ctx    context.Context
-	key    string // Redis key for storing trading data
+	client   *redis.Client
+	limit    int // Ticks and candles kept per list
+	prefix   string
+	timeout  time.Duration
+	readOnly bool
 }
 
-// NewRedisFastStore initializes a RedisFastStore with a Redis client.
-func NewRedisFastStore(addr, password string, db, limit int, key string) *RedisFastStore {
+// NewRedisFastStore initializes a RedisFastStore keeping limit ticks per market and trading pair.
+func NewRedisFastStore(addr, password string, db, limit int, opts RedisFastStoreOptions) *RedisFastStore {
 	client := redis.NewClient(&redis.Options{
 		Addr:     addr,
 		Password: password,
 		DB:       db,
 	})
+	return NewRedisFastStoreWithClient(client, limit, opts)
+}
+
+// NewRedisFastStoreWithClient initializes a RedisFastStore over an existing client.
+func NewRedisFastStoreWithClient(client *redis.Client, limit int, opts RedisFastStoreOptions) *RedisFastStore {
+	if opts.Prefix == "" {
+		opts.Prefix = "tradingbot"
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 2 * time.Second
+	}
 	return &RedisFastStore{
-		client: client,
-		limit:  limit,
-		ctx:    context.Background(),
-		key:    key,
+		client:   client,
+		limit:    limit,
+		prefix:   opts.Prefix,
+		timeout:  opts.Timeout,
+		readOnly: opts.ReadOnly,
 	}
 }
 
-// RecordTick adds new market data to Redis with a capped list.
-func (r *RedisFastStore) RecordTick(tradingPair string, marketData *types.MarketData) error {
-	price := strconv.FormatFloat(marketData.Price, 'f', -1, 64)
-	err := r.client.LPush(r.ctx, r.key, price).Err()
+// tickKey returns the key of the tick list of a market and trading pair, e.g. "tradingbot:ticks:Binance:BTC/USDT".
+func (r *RedisFastStore) tickKey(market, tradingPair string) string {
+	return r.prefix + ":ticks:" + market + ":" + tradingPair
+}
+
+// candleKey returns the key of the candle list of a market, trading pair and interval,
+// e.g. "tradingbot:candles:Binance:BTC/USDT:1m0s".
+func (r *RedisFastStore) candleKey(market, tradingPair string, interval time.Duration) string {
+	return r.prefix + ":candles:" + market + ":" + tradingPair + ":" + interval.String()
+}
+
+// RecordTick adds new market data to the capped list of its market and trading pair.
+func (r *RedisFastStore) RecordTick(market, tradingPair string, marketData *types.MarketData) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	return r.RecordTickContext(ctx, market, tradingPair, marketData)
+}
+
+// RecordTickContext adds new market data to the capped list of its market and trading pair, pushing and
+// trimming in one transaction.
+func (r *RedisFastStore) RecordTickContext(ctx context.Context, market, tradingPair string, marketData *types.MarketData) error {
+	if r.readOnly {
+		return nil
+	}
+	data, err := json.Marshal(marketData)
 	if err != nil {
 		return err
 	}
-	r.client.LTrim(r.ctx, r.key, 0, int64(r.limit-1)) // Trim list to maintain limit
-	return nil
+	return r.push(ctx, r.tickKey(market, tradingPair), data)
+}
+
+// push prepends a value to a list and trims the list to the limit in one transaction.
+func (r *RedisFastStore) push(ctx context.Context, key string, value []byte) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, key, value)
+		pipe.LTrim(ctx, key, 0, int64(r.limit-1))
+		return nil
+	})
+	return err
 }
 
-// QueryPriceHistory retrieves recent price data from Redis.
-func (r *RedisFastStore) QueryPriceHistory(tradingPair string, period int) []float64 {
-	if period > r.limit {
-		period = r.limit
+// QueryPriceHistory retrieves up to period of the most recent prices of a market and trading pair, oldest first.
+func (r *RedisFastStore) QueryPriceHistory(market, tradingPair string, period int) []float64 {
+	ticks := r.QueryTicks(market, tradingPair, 0, period)
+	prices := make([]float64, len(ticks))
+	for i, tick := range ticks {
+		prices[i] = tick.Price
 	}
+	return prices
+}
 
-	data, err := r.client.LRange(r.ctx, r.key, 0, int64(period-1)).Result()
+// QueryTicks retrieves up to count of the most recent ticks of a market and trading pair with a time before
+// the given Unix millisecond time, or the most recent ticks if before is zero, oldest first. Errors are logged
+// and return no ticks.
+func (r *RedisFastStore) QueryTicks(market, tradingPair string, before int64, count int) []types.MarketData {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	ticks, err := r.QueryTicksContext(ctx, market, tradingPair, before, count)
 	if err != nil {
+		log.Printf("Failed to query ticks of %s %s from Redis: %v\n", market, tradingPair, err)
+		return []types.MarketData{}
+	}
+	return ticks
+}
+
+// QueryTicksContext is QueryTicks with a context, returning Redis and decoding errors. Ticks may be recorded
+// out of time order, e.g. by several processes, so the whole list is read and sorted by time.
+func (r *RedisFastStore) QueryTicksContext(ctx context.Context, market, tradingPair string, before int64, count int) ([]types.MarketData, error) {
+	if count <= 0 {
+		return []types.MarketData{}, nil
+	}
+	values, err := r.client.LRange(ctx, r.tickKey(market, tradingPair), 0, int64(r.limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	return latestTicks(values, before, count)
+}
+
+// latestTicks decodes the ticks of a Redis list, newest recorded first, and returns up to count of the most
+// recent ones with a time before the given Unix millisecond time, or the most recent if before is zero,
+// sorted oldest first.
+func latestTicks(values []string, before int64, count int) ([]types.MarketData, error) {
+	ticks := make([]types.MarketData, 0, len(values))
+	for _, value := range values {
+		var tick types.MarketData
+		if err := json.Unmarshal([]byte(value), &tick); err != nil {
+			return nil, err
+		}
+		if before != 0 && tick.Time >= before {
+			continue
+		}
+		ticks = append(ticks, tick)
+	}
+	// The list is newest recorded first, so reversing keeps ticks of the same time in recording order
+	reverseTicks(ticks)
+	sortTicks(ticks)
+	if len(ticks) > count {
+		ticks = ticks[len(ticks)-count:]
+	}
+	return ticks, nil
+}
+
+// RecordCandle adds a closed candle to the capped list of its market, trading pair and interval.
+func (r *RedisFastStore) RecordCandle(market string, candle types.Candle) error {
+	if r.readOnly {
 		return nil
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
 
-	prices := make([]float64, len(data))
-	for i, str := range data {
-		prices[i], _ = strconv.ParseFloat(str, 64)
+	data, err := json.Marshal(candle)
+	if err != nil {
+		return err
+	}
+	return r.push(ctx, r.candleKey(market, candle.TradingPair, candle.Interval), data)
+}
+
+// QueryCandles retrieves up to count of the most recent closed candles of a market, trading pair and interval,
+// oldest first. Errors are logged and return no candles.
+func (r *RedisFastStore) QueryCandles(market, tradingPair string, interval time.Duration, count int) []types.Candle {
+	if count <= 0 {
+		return []types.Candle{}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	values, err := r.client.LRange(ctx, r.candleKey(market, tradingPair, interval), 0, int64(count-1)).Result()
+	if err != nil {
+		log.Printf("Failed to query candles of %s %s from Redis: %v\n", market, tradingPair, err)
+		return []types.Candle{}
+	}
+	candles := make([]types.Candle, len(values))
+	for i, value := range values {
+		// Newest first in Redis, oldest first to the caller
+		if err := json.Unmarshal([]byte(value), &candles[len(values)-1-i]); err != nil {
+			log.Printf("Failed to decode a candle of %s %s from Redis: %v\n", market, tradingPair, err)
+			return []types.Candle{}
+		}
+	}
+	return candles
+}
+
+// reverseTicks reverses ticks in place.
+func reverseTicks(ticks []types.MarketData) {
+	for i, j := 0, len(ticks)-1; i < j; i, j = i+1, j-1 {
+		ticks[i], ticks[j] = ticks[j], ticks[i]
 	}
-	return prices
 }
@@ -0,0 +1,77 @@
+package framework
+
+import (
+	"encoding/json"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"testing"
+	"time"
+)
+
+func TestRedisFastStore_Keys(t *testing.T) {
+	store := NewRedisFastStore("127.0.0.1:1", "", 0, 10, RedisFastStoreOptions{})
+	if key := store.tickKey("Binance", "BTC/USDT"); key != "tradingbot:ticks:Binance:BTC/USDT" {
+		t.Errorf("Unexpected tick key %q", key)
+	}
+	store = NewRedisFastStore("127.0.0.1:1", "", 0, 10, RedisFastStoreOptions{Prefix: "bot1"})
+	if key := store.candleKey("Binance", "BTC/USDT", time.Minute); key != "bot1:candles:Binance:BTC/USDT:1m0s" {
+		t.Errorf("Unexpected candle key %q", key)
+	}
+}
+
+func TestRedisFastStore_Unreachable(t *testing.T) {
+	store := NewRedisFastStore("127.0.0.1:1", "", 0, 10, RedisFastStoreOptions{Timeout: 100 * time.Millisecond})
+	if err := store.RecordTick("Binance", "BTC/USDT", &types.MarketData{Price: 100}); err == nil {
+		t.Error("Expected an error recording to an unreachable server")
+	}
+	if prices := store.QueryPriceHistory("Binance", "BTC/USDT", 5); len(prices) != 0 {
+		t.Errorf("Expected no prices from an unreachable server, got %v", prices)
+	}
+
+	// Read-only stores never write, so they do not fail to
+	store = NewRedisFastStore("127.0.0.1:1", "", 0, 10, RedisFastStoreOptions{Timeout: 100 * time.Millisecond, ReadOnly: true})
+	if err := store.RecordTick("Binance", "BTC/USDT", &types.MarketData{Price: 100}); err != nil {
+		t.Errorf("Expected a read-only store to skip the write, got %v", err)
+	}
+	if err := store.RecordCandle("Binance", types.Candle{TradingPair: "BTC/USDT", Interval: time.Minute}); err != nil {
+		t.Errorf("Expected a read-only store to skip the write, got %v", err)
+	}
+}
+
+func TestLatestTicks(t *testing.T) {
+	// Newest recorded first, as LRANGE returns them, but recorded out of time order
+	var values []string
+	for _, tick := range []int64{5, 6, 3, 4, 1, 2} {
+		data, _ := json.Marshal(types.MarketData{Price: float64(tick * 100), Time: tick})
+		values = append(values, string(data))
+	}
+
+	tests := []struct {
+		before   int64
+		count    int
+		expected []int64
+	}{
+		{0, 3, []int64{4, 5, 6}},
+		{0, 10, []int64{1, 2, 3, 4, 5, 6}},
+		{5, 2, []int64{3, 4}},
+	}
+	for _, test := range tests {
+		ticks, err := latestTicks(values, test.before, test.count)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(ticks) != len(test.expected) {
+			t.Errorf("Before %d, count %d: expected times %v, got %+v", test.before, test.count, test.expected, ticks)
+			continue
+		}
+		for i, tick := range ticks {
+			if tick.Time != test.expected[i] {
+				t.Errorf("Before %d, count %d: expected times %v, got %+v", test.before, test.count, test.expected, ticks)
+				break
+			}
+		}
+	}
+
+	if _, err := latestTicks([]string{"not json"}, 0, 1); err == nil {
+		t.Error("Expected an error for an undecodable tick")
+	}
+}
@@ -1,79 +1,265 @@
 package framework
 
 import (
-	"github.com/bigmeech/tradingbot/internal/store" // For CircularBuffer
+	"errors"
+	"github.com/bigmeech/tradingbot/pkg/models"
 	"github.com/bigmeech/tradingbot/pkg/types"
+	"log"
+	"sort"
 	"sync"
+	"time"
 )
 
+// DefaultCandleLimit is the number of closed candles kept in memory per market, trading pair and interval,
+// unless the fastStore keeps candles itself.
+const DefaultCandleLimit = 1000
+
 // StoreManager manages both fast and persistent storage for market data.
 type StoreManager struct {
-	fastStore  map[string]*store.CircularBuffer // Fast in-memory buffer for recent data
-	largeStore *store.MongoDBStore              // Persistent store for historical data
-	bufferSize int                              // Configurable buffer size for each trading pair
-	threshold  int                              // Threshold period for fastStore vs largeStore
-	storeLock  sync.Mutex                       // For thread-safe access
+	fastStore   models.FastStore          // Recent ticks, e.g. in memory or in Redis
+	largeStore  models.LargeStore         // Persistent store for historical data
+	writeBehind *writeBehind              // Asynchronous writes to the largeStore, if enabled
+	threshold   int                       // Threshold period for fastStore vs largeStore
+	candleLimit int                       // Closed candles kept in candles per market/trading pair/interval
+	candles     map[string][]types.Candle // Most recent closed candles per market/trading pair/interval, oldest first, unless fastStore keeps them
+	storeLock   sync.Mutex                // For thread-safe access to candles and candleLimit
 }
 
-// NewStoreManager initializes a StoreManager with a persistent store and buffer configuration.
-func NewStoreManager(largeStore *store.MongoDBStore, bufferSize int, threshold int) *StoreManager {
+// NewStoreManager initializes a StoreManager with a fast store for recent ticks, a large store for their
+// history and the period threshold between them. Use NewNoopLargeStore to keep no history.
+func NewStoreManager(fastStore models.FastStore, largeStore models.LargeStore, threshold int) *StoreManager {
 	return &StoreManager{
-		fastStore:  make(map[string]*store.CircularBuffer),
-		largeStore: largeStore,
-		bufferSize: bufferSize,
-		threshold:  threshold,
+		fastStore:   fastStore,
+		largeStore:  largeStore,
+		threshold:   threshold,
+		candleLimit: DefaultCandleLimit,
+		candles:     make(map[string][]types.Candle),
 	}
 }
 
-// RecordTick records a new market data point, adding it to both fastStore and largeStore.
-func (s *StoreManager) RecordTick(market, tradingPair string, data *types.MarketData) error {
+// SetCandleLimit sets the number of closed candles kept in memory per market, trading pair and interval when
+// the fastStore does not keep candles. A limit of zero or less restores DefaultCandleLimit.
+func (s *StoreManager) SetCandleLimit(limit int) {
+	if limit <= 0 {
+		limit = DefaultCandleLimit
+	}
 	s.storeLock.Lock()
 	defer s.storeLock.Unlock()
+	s.candleLimit = limit
+}
 
-	// Ensure fastStore is initialized
-	if s.fastStore == nil {
-		s.fastStore = make(map[string]*store.CircularBuffer)
+// EnableWriteBehind makes RecordTick queue ticks for the largeStore instead of writing them itself, so ticks
+// are not held up by its latency. A writer goroutine writes them in batches, every flush interval, when a
+// batch fills up and on Flush. It must be called before ticks are recorded; settings left at zero take
+// their values from DefaultWriteBehindConfig.
+func (s *StoreManager) EnableWriteBehind(cfg WriteBehindConfig) error {
+	writeBehind, err := newWriteBehind(s.largeStore, cfg)
+	if err != nil {
+		return err
 	}
+	s.writeBehind = writeBehind
+	return nil
+}
 
-	// Create a unique key for the market/trading pair combination
-	key := market + ":" + tradingPair
-
-	// Initialize a new CircularBuffer if one doesn't exist for the key
-	if _, exists := s.fastStore[key]; !exists {
-		s.fastStore[key] = store.NewCircularBuffer(s.bufferSize)
+// WriteMetrics returns the counters of the write-behind pipeline, all zero if it is not enabled.
+func (s *StoreManager) WriteMetrics() models.WriteMetrics {
+	if s.writeBehind == nil {
+		return models.WriteMetrics{}
 	}
+	return s.writeBehind.metrics()
+}
 
-	// Record the tick in the fast circular buffer
-	s.fastStore[key].Add(*data)
+// RecordTick records a new market data point, adding it to both fastStore and largeStore.
+func (s *StoreManager) RecordTick(market, tradingPair string, data *types.MarketData) error {
+	if err := s.fastStore.RecordTick(market, tradingPair, data); err != nil {
+		return err
+	}
 
 	// Also store the tick in the largeStore for long-term storage
-	return s.largeStore.RecordTick(tradingPair, data)
+	if s.writeBehind != nil {
+		return s.writeBehind.enqueue(models.TickRecord{Market: market, TradingPair: tradingPair, MarketData: *data})
+	}
+	return s.largeStore.RecordTick(market, tradingPair, data)
 }
 
-// QueryPriceHistory fetches data based on the period from either fastStore or largeStore.
+// QueryPriceHistory fetches up to period of the most recent prices, oldest first. Periods up to the threshold
+// are answered by the fastStore alone if it holds enough prices; longer ones, and those the fastStore cannot
+// fill, e.g. because its buffer is smaller than the threshold, stitch the fastStore's recent ticks onto older
+// history from the largeStore.
 func (s *StoreManager) QueryPriceHistory(market, tradingPair string, period int) []float64 {
+	if period <= s.threshold {
+		if prices := s.fastStore.QueryPriceHistory(market, tradingPair, period); len(prices) >= period {
+			return prices
+		}
+	}
+	return s.stitchHistory(market, tradingPair, period)
+}
+
+// stitchHistory joins the recent ticks in the fastStore and the ticks recorded before them in the largeStore.
+// If either store cannot return whole ticks, or the ticks have no time to join them by, the largeStore's
+// history is used, since it holds the recent ticks too, unless the fastStore holds more. Ticks may be stored
+// out of time order, so both sides are sorted by time. The older side runs up to and including the earliest
+// recent tick's millisecond, since other ticks may share it, and skips the ticks of that millisecond the
+// fastStore already returned.
+func (s *StoreManager) stitchHistory(market, tradingPair string, period int) []float64 {
+	fast, fastOK := s.fastStore.(models.TickHistory)
+	large, largeOK := s.largeStore.(models.TickHistory)
+	if fastOK && largeOK {
+		recent := fast.QueryTicks(market, tradingPair, 0, period)
+		sortTicks(recent)
+		if len(recent) == 0 || recent[0].Time != 0 {
+			var before int64
+			var boundary []types.MarketData // Recent ticks at the earliest recent time, which the older side also returns
+			if len(recent) > 0 {
+				before = recent[0].Time + 1
+				for _, data := range recent {
+					if data.Time == recent[0].Time {
+						boundary = append(boundary, data)
+					}
+				}
+			}
+			var older []types.MarketData
+			if len(recent) < period {
+				older = large.QueryTicks(market, tradingPair, before, period-len(recent)+len(boundary))
+				sortTicks(older)
+			}
+			prices := make([]float64, 0, len(older)+len(recent))
+			for _, data := range older {
+				// Ticks in the fastStore's time range are taken from the fastStore only, so none is counted twice
+				if before != 0 && data.Time >= before {
+					continue
+				}
+				if i := matchingTick(boundary, data); i >= 0 {
+					boundary = append(boundary[:i], boundary[i+1:]...)
+					continue
+				}
+				prices = append(prices, data.Price)
+			}
+			for _, data := range recent {
+				prices = append(prices, data.Price)
+			}
+			if len(prices) > period {
+				prices = prices[len(prices)-period:]
+			}
+			return prices
+		}
+	}
+
+	history := s.largeStore.QueryPriceHistory(market, tradingPair, period)
+	if recent := s.fastStore.QueryPriceHistory(market, tradingPair, period); len(recent) > len(history) {
+		return recent
+	}
+	return history
+}
+
+// WarmStart fills an empty fastStore with up to threshold of the most recent ticks of a market and trading
+// pair from the largeStore, so history queries are answered from it right after a restart. It returns the
+// number of ticks loaded, which is zero if the fastStore already holds ticks for the pair or the largeStore
+// cannot return whole ticks.
+func (s *StoreManager) WarmStart(market, tradingPair string) (int, error) {
+	large, ok := s.largeStore.(models.TickHistory)
+	if !ok || s.threshold <= 0 || len(s.fastStore.QueryPriceHistory(market, tradingPair, 1)) > 0 {
+		return 0, nil
+	}
+	ticks := large.QueryTicks(market, tradingPair, 0, s.threshold)
+	for i := range ticks {
+		if err := s.fastStore.RecordTick(market, tradingPair, &ticks[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(ticks), nil
+}
+
+// Flush writes the ticks queued for the largeStore and persists the writes buffered by the large store,
+// if it buffers them.
+func (s *StoreManager) Flush() error {
+	var errs []error
+	if s.writeBehind != nil {
+		errs = append(errs, s.writeBehind.flush())
+	}
+	if flusher, ok := s.largeStore.(models.Flusher); ok {
+		errs = append(errs, flusher.Flush())
+	}
+	return errors.Join(errs...)
+}
+
+// Close flushes the stores like Flush, stops the write-behind pipeline and closes the stores that hold
+// connections, after which recording ticks fails.
+func (s *StoreManager) Close() error {
+	var errs []error
+	if s.writeBehind != nil {
+		errs = append(errs, s.writeBehind.stop())
+	}
+	if flusher, ok := s.largeStore.(models.Flusher); ok {
+		errs = append(errs, flusher.Flush())
+	}
+	if closer, ok := s.largeStore.(models.Closer); ok {
+		errs = append(errs, closer.Close())
+	}
+	if closer, ok := s.fastStore.(models.Closer); ok {
+		errs = append(errs, closer.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// RecordCandle stores a closed candle in the fastStore if it keeps candles, or otherwise in memory, keeping
+// the most recent candles up to the candle limit per market, trading pair and interval.
+func (s *StoreManager) RecordCandle(market string, candle types.Candle) {
+	if candleStore, ok := s.fastStore.(models.CandleStore); ok {
+		if err := candleStore.RecordCandle(market, candle); err != nil {
+			log.Printf("Failed to record %s candle of %s %s: %v\n", candle.Interval, market, candle.TradingPair, err)
+		}
+		return
+	}
+
 	s.storeLock.Lock()
 	defer s.storeLock.Unlock()
 
-	// Ensure fastStore is initialized
-	if s.fastStore == nil {
-		s.fastStore = make(map[string]*store.CircularBuffer)
+	if s.candles == nil {
+		s.candles = make(map[string][]types.Candle)
+	}
+	key := market + ":" + candle.TradingPair + ":" + candle.Interval.String()
+	candles := append(s.candles[key], candle)
+	if len(candles) > s.candleLimit {
+		candles = append([]types.Candle(nil), candles[len(candles)-s.candleLimit:]...)
 	}
+	s.candles[key] = candles
+}
 
-	// Define the key and check if recent data can be fetched from fastStore
-	key := market + ":" + tradingPair
-	if buffer, exists := s.fastStore[key]; exists {
-		recentData := buffer.GetData(period)
-		priceHistory := make([]float64, len(recentData))
-		for i, entry := range recentData {
-			priceHistory[i] = entry.Price
-		}
-		return priceHistory
-	} else {
-		// Handle the case where the key does not exist
-		return []float64{}
+// QueryCandles returns up to count of the most recent closed candles for a market, trading pair and interval, oldest first.
+func (s *StoreManager) QueryCandles(market, tradingPair string, interval time.Duration, count int) []types.Candle {
+	if candleStore, ok := s.fastStore.(models.CandleStore); ok {
+		return candleStore.QueryCandles(market, tradingPair, interval, count)
 	}
 
-	// For periods beyond threshold, fall back to the largeStore
-	return s.largeStore.QueryPriceHistory(tradingPair, period)
+	s.storeLock.Lock()
+	defer s.storeLock.Unlock()
+
+	candles := s.candles[market+":"+tradingPair+":"+interval.String()]
+	if count < len(candles) {
+		candles = candles[len(candles)-count:]
+	}
+	return append([]types.Candle(nil), candles...)
+}
+
+// sortTicks sorts ticks by time, oldest first, keeping ticks of the same time in the order they were recorded.
+func sortTicks(ticks []types.MarketData) {
+	sort.SliceStable(ticks, func(i, j int) bool { return ticks[i].Time < ticks[j].Time })
+}
+
+// matchingTick returns the index of the tick in ticks that data is a copy of, or -1 if there is none. Ticks
+// with trade IDs match by trade ID, others by time, price and volume.
+func matchingTick(ticks []types.MarketData, data types.MarketData) int {
+	for i, tick := range ticks {
+		if tick.TradeID != "" && data.TradeID != "" {
+			if tick.TradeID == data.TradeID {
+				return i
+			}
+			continue
+		}
+		if tick.Time == data.Time && tick.Price == data.Price && tick.Volume == data.Volume {
+			return i
+		}
+	}
+	return -1
 }
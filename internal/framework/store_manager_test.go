@@ -3,6 +3,7 @@ package framework
 import (
 	"github.com/bigmeech/tradingbot/pkg/types"
 	"testing"
+	"time"
 )
 
 // MockLargeStore simulates a persistent store for testing.
@@ -10,12 +11,12 @@ type MockLargeStore struct {
 	recordedData []types.MarketData
 }
 
-func (m *MockLargeStore) RecordTick(tradingPair string, marketData *types.MarketData) error {
+func (m *MockLargeStore) RecordTick(market, tradingPair string, marketData *types.MarketData) error {
 	m.recordedData = append(m.recordedData, *marketData)
 	return nil
 }
 
-func (m *MockLargeStore) QueryPriceHistory(tradingPair string, period int) []float64 {
+func (m *MockLargeStore) QueryPriceHistory(market, tradingPair string, period int) []float64 {
 	history := make([]float64, 0, period)
 	start := len(m.recordedData) - period
 	if start < 0 {
@@ -27,12 +28,29 @@ func (m *MockLargeStore) QueryPriceHistory(tradingPair string, period int) []flo
 	return history
 }
 
+// MockTickStore also returns whole ticks, in the order they were recorded rather than by time, like a Redis list.
+type MockTickStore struct {
+	MockLargeStore
+}
+
+func (m *MockTickStore) QueryTicks(market, tradingPair string, before int64, count int) []types.MarketData {
+	var ticks []types.MarketData
+	for i := len(m.recordedData) - 1; i >= 0 && len(ticks) < count; i-- {
+		if before == 0 || m.recordedData[i].Time < before {
+			ticks = append(ticks, m.recordedData[i])
+		}
+	}
+	reverseTicks(ticks)
+	return ticks
+}
+
 func TestStoreManager_RecordTickAndQuery(t *testing.T) {
 	// Setup
 	bufferSize := 2 // Limit of recent data in circular buffer
 	threshold := 2  // Period threshold for fastStore vs largeStore
+	fastStore := NewInMemoryFastStore(bufferSize)
 	largeStore := &MockLargeStore{}
-	manager := NewStoreManager(largeStore, bufferSize, threshold)
+	manager := NewStoreManager(fastStore, largeStore, threshold)
 
 	// Record three ticks
 	manager.RecordTick("Market1", "BTC/USDT", &types.MarketData{Price: 100.0, Volume: 1.0})
@@ -40,10 +58,8 @@ func TestStoreManager_RecordTickAndQuery(t *testing.T) {
 	manager.RecordTick("Market1", "BTC/USDT", &types.MarketData{Price: 300.0, Volume: 1.0})
 
 	// Verify fastStore holds only last `bufferSize` entries (circular buffer behavior)
-	if buffer := manager.fastStore["Market1_BTC/USDT"]; buffer != nil {
-		if len(buffer.GetData(bufferSize)) != bufferSize {
-			t.Fatalf("Expected %v entries in fastStore, got %v", bufferSize, len(buffer.GetData(bufferSize)))
-		}
+	if prices := fastStore.QueryPriceHistory("Market1", "BTC/USDT", 3); len(prices) != bufferSize {
+		t.Fatalf("Expected %v entries in fastStore, got %v", bufferSize, len(prices))
 	}
 
 	// Verify fastStore content (should contain the two most recent prices: 200.0, 300.0)
@@ -63,9 +79,237 @@ func TestStoreManager_RecordTickAndQuery(t *testing.T) {
 	// Query beyond threshold - should use largeStore
 	expectedLargeStore := []float64{100.0, 200.0, 300.0}
 	largePrices := manager.QueryPriceHistory("Market1", "BTC/USDT", 3)
+	if len(largePrices) != len(expectedLargeStore) {
+		t.Fatalf("Expected %v prices from largeStore, got %v", len(expectedLargeStore), largePrices)
+	}
 	for i, price := range largePrices {
 		if price != expectedLargeStore[i] {
 			t.Errorf("Expected price %v at index %d in largeStore, got %v", expectedLargeStore[i], i, price)
 		}
 	}
 }
+
+func TestStoreManager_NoopLargeStore(t *testing.T) {
+	manager := NewStoreManager(NewInMemoryFastStore(3), NewNoopLargeStore(), 3)
+	for _, price := range []float64{100, 200, 300, 400} {
+		if err := manager.RecordTick("Market1", "BTC/USDT", &types.MarketData{Price: price}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	prices := manager.QueryPriceHistory("Market1", "BTC/USDT", 3)
+	if len(prices) != 3 || prices[0] != 200 || prices[2] != 400 {
+		t.Errorf("Expected the last three prices from the fast store, got %v", prices)
+	}
+	// The no-op large store has no older history, so longer queries get what the fast store holds
+	if prices := manager.QueryPriceHistory("Market1", "BTC/USDT", 10); len(prices) != 3 {
+		t.Errorf("Expected only the fast store's prices, got %v", prices)
+	}
+}
+
+func TestStoreManager_StitchesHistory(t *testing.T) {
+	fastStore := NewInMemoryFastStore(3)
+	largeStore := NewInMemoryFastStore(100)
+	manager := NewStoreManager(fastStore, largeStore, 3)
+
+	// The large store lags behind and has only the older ticks, overlapping the fast store by one
+	for i, price := range []float64{100, 200, 300} {
+		largeStore.RecordTick("Market1", "BTC/USDT", &types.MarketData{Price: price, Time: int64(i + 1)})
+	}
+	for i, price := range []float64{300, 400, 500} {
+		fastStore.RecordTick("Market1", "BTC/USDT", &types.MarketData{Price: price, Time: int64(i + 3)})
+	}
+
+	tests := []struct {
+		period   int
+		expected []float64
+	}{
+		{2, []float64{400, 500}},                 // Within the threshold, from the fast store only
+		{4, []float64{200, 300, 400, 500}},       // Older history comes from the large store
+		{10, []float64{100, 200, 300, 400, 500}}, // Everything there is, without duplicates
+	}
+	for _, test := range tests {
+		prices := manager.QueryPriceHistory("Market1", "BTC/USDT", test.period)
+		if len(prices) != len(test.expected) {
+			t.Errorf("Period %d: expected %v, got %v", test.period, test.expected, prices)
+			continue
+		}
+		for i := range prices {
+			if prices[i] != test.expected[i] {
+				t.Errorf("Period %d: expected %v, got %v", test.period, test.expected, prices)
+				break
+			}
+		}
+	}
+}
+
+func TestStoreManager_ThresholdAboveFastStoreCapacity(t *testing.T) {
+	manager := NewStoreManager(NewInMemoryFastStore(2), NewInMemoryFastStore(100), 5)
+	for i, price := range []float64{100, 200, 300, 400} {
+		manager.RecordTick("Market1", "BTC/USDT", &types.MarketData{Price: price, Time: int64(i + 1)})
+	}
+
+	// The fast store keeps only two ticks, so periods within the threshold are completed from the large store
+	prices := manager.QueryPriceHistory("Market1", "BTC/USDT", 4)
+	expected := []float64{100, 200, 300, 400}
+	if len(prices) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, prices)
+	}
+	for i := range prices {
+		if prices[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, prices)
+		}
+	}
+}
+
+func TestStoreManager_StitchesOutOfOrderHistory(t *testing.T) {
+	fastStore := NewInMemoryFastStore(3)
+	largeStore := &MockTickStore{}
+	manager := NewStoreManager(fastStore, largeStore, 3)
+
+	// Both stores received the ticks out of time order, and the large store also holds the recent ones
+	for _, tick := range []int64{2, 1, 4, 3, 6, 5} {
+		largeStore.RecordTick("Market1", "BTC/USDT", &types.MarketData{Price: float64(tick * 100), Time: tick})
+	}
+	for _, tick := range []int64{5, 4, 6} {
+		fastStore.RecordTick("Market1", "BTC/USDT", &types.MarketData{Price: float64(tick * 100), Time: tick})
+	}
+
+	prices := manager.QueryPriceHistory("Market1", "BTC/USDT", 6)
+	expected := []float64{100, 200, 300, 400, 500, 600}
+	if len(prices) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, prices)
+	}
+	for i := range prices {
+		if prices[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, prices)
+		}
+	}
+}
+
+func TestStoreManager_StitchesTicksSharingTheBoundary(t *testing.T) {
+	fastStore := NewInMemoryFastStore(3)
+	manager := NewStoreManager(fastStore, NewInMemoryFastStore(100), 3)
+
+	// Two trades share millisecond 3, but only the later one is still in the fast store
+	ticks := []types.MarketData{
+		{Price: 100, Time: 1, TradeID: "1"},
+		{Price: 200, Time: 2, TradeID: "2"},
+		{Price: 300, Time: 3, TradeID: "3"},
+		{Price: 310, Time: 3, TradeID: "4"},
+		{Price: 400, Time: 4, TradeID: "5"},
+		{Price: 500, Time: 5, TradeID: "6"},
+	}
+	for i := range ticks {
+		manager.RecordTick("Market1", "BTC/USDT", &ticks[i])
+	}
+
+	prices := manager.QueryPriceHistory("Market1", "BTC/USDT", 5)
+	expected := []float64{200, 300, 310, 400, 500}
+	if len(prices) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, prices)
+	}
+	for i := range prices {
+		if prices[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, prices)
+		}
+	}
+}
+
+func TestStoreManager_WarmStart(t *testing.T) {
+	largeStore := NewInMemoryFastStore(100)
+	for i, price := range []float64{100, 200, 300, 400, 500} {
+		largeStore.RecordTick("Market1", "BTC/USDT", &types.MarketData{Price: price, Time: int64(i + 1)})
+	}
+	fastStore := NewInMemoryFastStore(10)
+	manager := NewStoreManager(fastStore, largeStore, 3)
+
+	loaded, err := manager.WarmStart("Market1", "BTC/USDT")
+	if err != nil || loaded != 3 {
+		t.Fatalf("Expected 3 ticks to be loaded, got %d (%v)", loaded, err)
+	}
+	if prices := fastStore.QueryPriceHistory("Market1", "BTC/USDT", 10); len(prices) != 3 || prices[0] != 300 || prices[2] != 500 {
+		t.Errorf("Expected the fast store to hold the last three prices, got %v", prices)
+	}
+
+	// A fast store that already holds ticks is left alone
+	if loaded, _ := manager.WarmStart("Market1", "BTC/USDT"); loaded != 0 {
+		t.Errorf("Expected no ticks to be loaded twice, got %d", loaded)
+	}
+}
+
+func TestStoreManager_WriteBehind(t *testing.T) {
+	largeStore := NewInMemoryFastStore(100)
+	manager := NewStoreManager(NewInMemoryFastStore(10), largeStore, 5)
+	if err := manager.EnableWriteBehind(WriteBehindConfig{FlushInterval: time.Hour}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, price := range []float64{100, 200, 300} {
+		if err := manager.RecordTick("Market1", "BTC/USDT", &types.MarketData{Price: price}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := manager.Flush(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if prices := largeStore.QueryPriceHistory("Market1", "BTC/USDT", 10); len(prices) != 3 {
+		t.Errorf("Expected the flush to write all ticks to the large store, got %v", prices)
+	}
+	if metrics := manager.WriteMetrics(); metrics.Written != 3 {
+		t.Errorf("Expected 3 written ticks, got %+v", metrics)
+	}
+
+	if err := manager.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := manager.RecordTick("Market1", "BTC/USDT", &types.MarketData{Price: 400}); err == nil {
+		t.Error("Expected recording to fail once closed")
+	}
+}
+
+// candleFastStore is an in-memory fast store that also keeps candles, like a shared Redis cache.
+type candleFastStore struct {
+	*InMemoryFastStore
+	candles []types.Candle
+}
+
+func (c *candleFastStore) RecordCandle(market string, candle types.Candle) error {
+	c.candles = append(c.candles, candle)
+	return nil
+}
+
+func (c *candleFastStore) QueryCandles(market, tradingPair string, interval time.Duration, count int) []types.Candle {
+	return c.candles
+}
+
+func TestStoreManager_CandlesInFastStore(t *testing.T) {
+	fastStore := &candleFastStore{InMemoryFastStore: NewInMemoryFastStore(10)}
+	manager := NewStoreManager(fastStore, NewNoopLargeStore(), 5)
+
+	manager.RecordCandle("Market1", types.Candle{TradingPair: "BTC/USDT", Interval: time.Minute, Close: 100})
+	if len(fastStore.candles) != 1 {
+		t.Fatalf("Expected the candle to be kept by the fast store, got %v", fastStore.candles)
+	}
+	if candles := manager.QueryCandles("Market1", "BTC/USDT", time.Minute, 5); len(candles) != 1 || candles[0].Close != 100 {
+		t.Errorf("Expected the candle from the fast store, got %v", candles)
+	}
+}
+
+func TestStoreManager_CandleLimit(t *testing.T) {
+	// The threshold of zero sends every history query to the large store, but candles are still kept
+	manager := NewStoreManager(NewInMemoryFastStore(10), NewNoopLargeStore(), 0)
+	manager.RecordCandle("Market1", types.Candle{TradingPair: "BTC/USDT", Interval: time.Minute, Close: 100})
+	if candles := manager.QueryCandles("Market1", "BTC/USDT", time.Minute, 5); len(candles) != 1 {
+		t.Fatalf("Expected the candle to be kept regardless of the threshold, got %v", candles)
+	}
+
+	manager.SetCandleLimit(2)
+	for _, price := range []float64{200, 300} {
+		manager.RecordCandle("Market1", types.Candle{TradingPair: "BTC/USDT", Interval: time.Minute, Close: price})
+	}
+	candles := manager.QueryCandles("Market1", "BTC/USDT", time.Minute, 5)
+	if len(candles) != 2 || candles[0].Close != 200 || candles[1].Close != 300 {
+		t.Errorf("Expected the two most recent candles, got %v", candles)
+	}
+}
@@ -0,0 +1,245 @@
+package framework
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// strategyRunner is a strategy registered for a market and trading pair.
+type strategyRunner struct {
+	marketName  string
+	tradingPair string
+	strategy    types.Strategy
+	warm        atomic.Bool // Set once enough prices have been recorded for the warm-up period
+}
+
+// stateKey identifies the strategy's persisted state.
+func (r *strategyRunner) stateKey() string {
+	return r.marketName + ":" + r.tradingPair + ":" + r.strategy.Name()
+}
+
+// RegisterStrategy registers a strategy for a specific market and trading pair. The strategy's indicators are
+// registered unless an indicator with the same name already is, its OnTick runs as tick middleware and, if it
+// has a bar interval, its OnBar runs as bar close middleware. OnStart and OnStop run when the framework starts and stops.
+// It returns an error, registering nothing, if the strategy's bar interval is shorter than a millisecond.
+func (f *Framework) RegisterStrategy(marketName, tradingPair string, strategy types.Strategy) error {
+	runner := &strategyRunner{marketName: marketName, tradingPair: tradingPair, strategy: strategy}
+	if interval := strategy.BarInterval(); interval > 0 {
+		err := f.RegisterBarCloseMiddleware(marketName, tradingPair, interval, func(ctx *types.TickContext) error {
+			return f.runStrategy(runner, ctx, strategy.OnBar)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to register strategy %s for %s on %s: %w", strategy.Name(), tradingPair, marketName, err)
+		}
+	}
+
+	registered := make(map[string]bool)
+	for _, indicator := range f.GetIndicators(marketName, tradingPair) {
+		registered[indicator.Name()] = true
+	}
+	for _, indicator := range strategy.Indicators() {
+		if !registered[indicator.Name()] {
+			f.RegisterIndicator(marketName, tradingPair, indicator)
+			registered[indicator.Name()] = true
+		}
+	}
+
+	f.RegisterMiddleware(marketName, tradingPair, func(ctx *types.TickContext) error {
+		return f.runStrategy(runner, ctx, strategy.OnTick)
+	})
+	f.strategies = append(f.strategies, runner)
+	return nil
+}
+
+// SetStrategyStateStore sets the store that stateful strategies load their state from when the framework
+// starts and save it to when it stops. Without a store, strategy state is not persisted.
+func (f *Framework) SetStrategyStateStore(store types.StrategyStateStore) {
+	f.strategyState = store
+}
+
+// runStrategy runs a strategy hook once the strategy has warmed up. The hook sees a copy of the context whose
+// order functions also pass the orders they return to the strategy's OnOrderUpdate. Updates of the strategy's
+// resting orders reported since its last hook are passed to OnOrderUpdate first.
+func (f *Framework) runStrategy(runner *strategyRunner, ctx *types.TickContext, hook types.Middleware) error {
+	if !f.warmedUp(runner) {
+		return nil
+	}
+	f.deliverOrderUpdates(runner)
+
+	strategyCtx := *ctx
+	if executeOrder := ctx.ExecuteOrder; executeOrder != nil {
+		strategyCtx.ExecuteOrder = func(orderType types.OrderType, side types.OrderSide, amount, price float64) (*types.Order, error) {
+			order, err := executeOrder(orderType, side, amount, price)
+			f.trackOrder(runner.marketName, runner, order)
+			f.updateOrder(runner, order)
+			return order, err
+		}
+	}
+	if cancelOrder := ctx.CancelOrder; cancelOrder != nil {
+		strategyCtx.CancelOrder = func(orderID string) (*types.Order, error) {
+			order, err := cancelOrder(orderID)
+			f.updateOrder(runner, order)
+			return order, err
+		}
+	}
+	if amendOrder := ctx.AmendOrder; amendOrder != nil {
+		strategyCtx.AmendOrder = func(orderID string, amount, price float64) (*types.Order, error) {
+			order, err := amendOrder(orderID, amount, price)
+			f.trackOrder(runner.marketName, runner, order)
+			f.updateOrder(runner, order)
+			return order, err
+		}
+	}
+	return hook(&strategyCtx)
+}
+
+// warmedUp reports whether enough prices have been recorded for the strategy's warm-up period.
+func (f *Framework) warmedUp(runner *strategyRunner) bool {
+	if runner.warm.Load() {
+		return true
+	}
+	period := runner.strategy.WarmupPeriod()
+	if period > 0 && len(f.QueryPriceHistory(runner.marketName, runner.tradingPair, period)) < period {
+		return false
+	}
+	runner.warm.Store(true)
+	return true
+}
+
+// updateOrder passes an order returned to a strategy to its OnOrderUpdate.
+func (f *Framework) updateOrder(runner *strategyRunner, order *types.Order) {
+	if order == nil {
+		return
+	}
+	if err := runner.strategy.OnOrderUpdate(f.strategyContext(runner), order); err != nil {
+		log.Printf("Strategy %s failed to handle order update for %s on %s: %v\n", runner.strategy.Name(), runner.tradingPair, runner.marketName, err)
+	}
+}
+
+// strategyContext builds the context passed to a strategy's lifecycle hooks.
+func (f *Framework) strategyContext(runner *strategyRunner) *types.StrategyContext {
+	marketName, tradingPair := runner.marketName, runner.tradingPair
+	return &types.StrategyContext{
+		MarketName:  marketName,
+		TradingPair: tradingPair,
+		Portfolio:   f.portfolio,
+		PriceHistory: func(period int) []float64 {
+			return f.QueryPriceHistory(marketName, tradingPair, period)
+		},
+		Candles: func(interval time.Duration, count int) []types.Candle {
+			return f.QueryCandles(marketName, tradingPair, interval, count)
+		},
+	}
+}
+
+// startStrategies loads the state of stateful strategies and calls OnStart on every strategy in registration
+// order. If a strategy fails to start, the strategies already started are stopped and the error is returned.
+func (f *Framework) startStrategies() error {
+	for i, runner := range f.strategies {
+		err := f.loadStrategyState(runner)
+		if err == nil {
+			err = runner.strategy.OnStart(f.strategyContext(runner))
+		}
+		if err != nil {
+			for _, started := range f.strategies[:i] {
+				if stopErr := started.strategy.OnStop(f.strategyContext(started)); stopErr != nil {
+					log.Printf("Strategy %s failed to stop: %v\n", started.strategy.Name(), stopErr)
+				}
+			}
+			return fmt.Errorf("failed to start strategy %s for %s on %s: %w", runner.strategy.Name(), runner.tradingPair, runner.marketName, err)
+		}
+	}
+	return nil
+}
+
+// stopStrategies calls OnStop on every strategy and then saves the state of stateful strategies,
+// returning every error encountered.
+func (f *Framework) stopStrategies() []error {
+	var errs []error
+	for _, runner := range f.strategies {
+		f.deliverOrderUpdates(runner)
+		if err := runner.strategy.OnStop(f.strategyContext(runner)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop strategy %s for %s on %s: %w", runner.strategy.Name(), runner.tradingPair, runner.marketName, err))
+		}
+		if err := f.saveStrategyState(runner); err != nil {
+			errs = append(errs, fmt.Errorf("failed to save state of strategy %s for %s on %s: %w", runner.strategy.Name(), runner.tradingPair, runner.marketName, err))
+		}
+	}
+	return errs
+}
+
+// loadStrategyState restores the saved state of a stateful strategy, if a state store is set and holds any.
+func (f *Framework) loadStrategyState(runner *strategyRunner) error {
+	stateful, ok := runner.strategy.(types.StatefulStrategy)
+	if !ok || f.strategyState == nil {
+		return nil
+	}
+	data, err := f.strategyState.LoadStrategyState(runner.stateKey())
+	if err != nil || data == nil {
+		return err
+	}
+	return stateful.LoadState(data)
+}
+
+// saveStrategyState saves the state of a stateful strategy, if a state store is set.
+func (f *Framework) saveStrategyState(runner *strategyRunner) error {
+	stateful, ok := runner.strategy.(types.StatefulStrategy)
+	if !ok || f.strategyState == nil {
+		return nil
+	}
+	data, err := stateful.SaveState()
+	if err != nil {
+		return err
+	}
+	return f.strategyState.SaveStrategyState(runner.stateKey(), data)
+}
+
+// FileStrategyStateStore persists strategy state as one file per key in a directory.
+type FileStrategyStateStore struct {
+	dir string
+}
+
+// NewFileStrategyStateStore initializes a FileStrategyStateStore writing to dir, which is created on first save.
+func NewFileStrategyStateStore(dir string) *FileStrategyStateStore {
+	return &FileStrategyStateStore{dir: dir}
+}
+
+// LoadStrategyState returns the state saved under key, or nil if there is none.
+func (s *FileStrategyStateStore) LoadStrategyState(key string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// SaveStrategyState saves state under key, writing a temporary file first so a crash never leaves partial state.
+func (s *FileStrategyStateStore) SaveStrategyState(key string, data []byte) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	path := s.path(key)
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// path maps a key to a file name, replacing characters that are not safe in file names.
+func (s *FileStrategyStateStore) path(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		}
+		return '_'
+	}, key)
+	return filepath.Join(s.dir, name+".state")
+}
@@ -0,0 +1,191 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"github.com/bigmeech/tradingbot/internal/indicators"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordingStrategy records the hooks called on it and buys on every tick.
+type recordingStrategy struct {
+	types.BaseStrategy
+	warmup   int
+	startErr error
+
+	events []string // Lifecycle hooks called, in order
+
+	mu     sync.Mutex
+	ticks  []float64
+	bars   []types.Candle
+	orders []*types.Order
+	sma    []float64
+	count  int // Persisted across restarts
+}
+
+func (s *recordingStrategy) Name() string               { return "Recording" }
+func (s *recordingStrategy) WarmupPeriod() int          { return s.warmup }
+func (s *recordingStrategy) BarInterval() time.Duration { return time.Minute }
+func (s *recordingStrategy) Indicators() []types.Indicator {
+	return []types.Indicator{indicators.NewSMA(2)}
+}
+
+func (s *recordingStrategy) OnStart(ctx *types.StrategyContext) error {
+	s.events = append(s.events, "start")
+	return s.startErr
+}
+
+func (s *recordingStrategy) OnStop(ctx *types.StrategyContext) error {
+	s.events = append(s.events, "stop")
+	return nil
+}
+
+func (s *recordingStrategy) OnTick(ctx *types.TickContext) error {
+	s.mu.Lock()
+	s.ticks = append(s.ticks, ctx.MarketData.Price)
+	s.sma = append(s.sma, ctx.Indicators["SMA_2"])
+	s.count++
+	s.mu.Unlock()
+	if ctx.ExecuteOrder == nil {
+		return nil
+	}
+	_, err := ctx.ExecuteOrder(types.OrderTypeMarket, types.OrderSideBuy, 1, 0)
+	return err
+}
+
+func (s *recordingStrategy) OnBar(ctx *types.TickContext) error {
+	s.bars = append(s.bars, *ctx.Candle)
+	return nil
+}
+
+func (s *recordingStrategy) OnOrderUpdate(ctx *types.StrategyContext, order *types.Order) error {
+	s.orders = append(s.orders, order)
+	return nil
+}
+
+func (s *recordingStrategy) SaveState() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return []byte(strconv.Itoa(s.count)), nil
+}
+
+func (s *recordingStrategy) LoadState(data []byte) error {
+	count, err := strconv.Atoi(string(data))
+	s.count = count
+	return err
+}
+
+func TestFramework_RegisterStrategy(t *testing.T) {
+	framework := NewFramework(NewStoreManager(NewInMemoryFastStore(10), NewMockStore(), 5))
+	strategy := &recordingStrategy{warmup: 4}
+	framework.RegisterIndicator("MockConnector", "BTC/USDT", indicators.NewSMA(2))
+	if err := framework.RegisterStrategy("MockConnector", "BTC/USDT", strategy); err != nil {
+		t.Fatalf("Expected strategy to register, got %v", err)
+	}
+
+	if count := len(framework.GetIndicators("MockConnector", "BTC/USDT")); count != 1 {
+		t.Errorf("Expected the already registered SMA_2 to be reused, got %d indicators", count)
+	}
+
+	var middlewareTicks int
+	framework.RegisterMiddleware("MockConnector", "BTC/USDT", func(ctx *types.TickContext) error {
+		middlewareTicks++
+		return nil
+	})
+
+	connector := &MockConnector{}
+	for i, price := range []float64{100, 110, 90, 105, 120} {
+		framework.handleTick("MockConnector", connector, &types.TickContext{
+			TradingPair: "BTC/USDT",
+			MarketData:  &types.MarketData{Price: price, Volume: 1, Time: 1699999980000 + int64(i)*30000},
+			ExecuteOrder: func(orderType types.OrderType, side types.OrderSide, amount, _ float64) (*types.Order, error) {
+				return &types.Order{TradingPair: "BTC/USDT", Type: orderType, Side: side, Quantity: amount, Status: types.OrderStatusNew}, nil
+			},
+		}, func(ctx *types.TickContext) {})
+	}
+
+	if middlewareTicks != 5 {
+		t.Errorf("Expected middleware to keep running on every tick, ran %d times", middlewareTicks)
+	}
+	if len(strategy.ticks) != 2 || strategy.ticks[0] != 105 {
+		t.Fatalf("Expected OnTick from the fourth tick once warmed up, got %v", strategy.ticks)
+	}
+	if strategy.sma[0] != 97.5 {
+		t.Errorf("Expected OnTick to see SMA_2 of 97.5, got %v", strategy.sma[0])
+	}
+	if len(strategy.orders) != 2 || strategy.orders[0].Side != types.OrderSideBuy {
+		t.Errorf("Expected OnOrderUpdate for each order placed, got %+v", strategy.orders)
+	}
+	if len(strategy.bars) != 1 || strategy.bars[0].Close != 105 {
+		t.Errorf("Expected OnBar for the bar closed after warm-up, got %+v", strategy.bars)
+	}
+}
+
+// barStrategy has a configurable bar interval.
+type barStrategy struct {
+	recordingStrategy
+	interval time.Duration
+}
+
+func (s *barStrategy) BarInterval() time.Duration { return s.interval }
+
+func TestFramework_RegisterStrategyRejectsSubMillisecondBars(t *testing.T) {
+	framework := NewFramework(NewStoreManager(NewInMemoryFastStore(10), NewMockStore(), 5))
+	if err := framework.RegisterStrategy("MockConnector", "BTC/USDT", &barStrategy{interval: time.Microsecond}); err == nil {
+		t.Fatal("Expected a sub-millisecond bar interval to be rejected")
+	}
+	if len(framework.GetIndicators("MockConnector", "BTC/USDT")) != 0 || len(framework.GetMiddleware("MockConnector", "BTC/USDT")) != 0 {
+		t.Error("Expected nothing to be registered for the rejected strategy")
+	}
+	if len(framework.strategies) != 0 {
+		t.Errorf("Expected the rejected strategy not to be started, got %d strategies", len(framework.strategies))
+	}
+}
+
+func TestFramework_StrategyLifecycle(t *testing.T) {
+	stateStore := NewFileStrategyStateStore(t.TempDir())
+	run := func(strategy *recordingStrategy) error {
+		framework := NewFramework(NewStoreManager(NewInMemoryFastStore(10), NewMockStore(), 5))
+		framework.SetStrategyStateStore(stateStore)
+		framework.RegisterConnector("Stream", newStreamingConnector())
+		framework.RegisterStrategy("Stream", "BTC/USDT", strategy)
+
+		var processed atomic.Int32
+		if err := framework.Start(context.Background(), func(ctx *types.TickContext) { processed.Add(1) }); err != nil {
+			return err
+		}
+		waitForTicks(t, &processed, 3)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		return framework.Stop(ctx)
+	}
+
+	first := &recordingStrategy{}
+	if err := run(first); err != nil {
+		t.Fatalf("Expected the framework to run, got %v", err)
+	}
+	if len(first.events) != 2 || first.events[0] != "start" || first.events[1] != "stop" {
+		t.Errorf("Expected OnStart then OnStop, got %v", first.events)
+	}
+
+	second := &recordingStrategy{}
+	if err := run(second); err != nil {
+		t.Fatalf("Expected the framework to run again, got %v", err)
+	}
+	if second.count < first.count+3 {
+		t.Errorf("Expected the tick count to resume from %d, got %d", first.count, second.count)
+	}
+
+	failing := &recordingStrategy{startErr: errors.New("no balance")}
+	if err := run(failing); err == nil {
+		t.Error("Expected a strategy failing to start to stop the framework from starting")
+	}
+	if len(failing.ticks) != 0 {
+		t.Errorf("Expected no ticks for a strategy that failed to start, got %v", failing.ticks)
+	}
+}
@@ -0,0 +1,274 @@
+package framework
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bigmeech/tradingbot/pkg/models"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BackpressurePolicy decides what recording a tick does when the write-behind queue is full.
+type BackpressurePolicy string
+
+const (
+	// BackpressureBlock waits for room in the queue, slowing the tick's pair down to the large store's pace.
+	BackpressureBlock BackpressurePolicy = "block"
+
+	// BackpressureDropNewest drops the tick being recorded.
+	BackpressureDropNewest BackpressurePolicy = "drop_newest"
+
+	// BackpressureDropOldest drops the oldest queued tick to make room.
+	BackpressureDropOldest BackpressurePolicy = "drop_oldest"
+)
+
+// WriteBehindConfig configures the asynchronous writes of ticks to the large store.
+type WriteBehindConfig struct {
+	QueueSize     int                // Ticks queued before backpressure applies
+	BatchSize     int                // Ticks written at once; a full batch is written straight away
+	FlushInterval time.Duration      // Longest a queued tick waits for its batch to fill
+	Backpressure  BackpressurePolicy // What to do when the queue is full, block if empty
+	LateAfter     time.Duration      // Ticks written later than this after they were queued are counted as late
+}
+
+// DefaultWriteBehindConfig queues up to 10000 ticks, writing them in batches of 500 at least every second and
+// blocking when the queue is full.
+func DefaultWriteBehindConfig() WriteBehindConfig {
+	return WriteBehindConfig{
+		QueueSize:     10000,
+		BatchSize:     500,
+		FlushInterval: time.Second,
+		Backpressure:  BackpressureBlock,
+		LateAfter:     5 * time.Second,
+	}
+}
+
+// queuedTick is a tick waiting to be written, with the time it was queued.
+type queuedTick struct {
+	record   models.TickRecord
+	queuedAt time.Time
+}
+
+// writeBehind writes ticks to a large store from a bounded queue on its own goroutine, in batches if the
+// store implements models.BatchWriter.
+type writeBehind struct {
+	store   models.LargeStore
+	cfg     WriteBehindConfig
+	queue   chan queuedTick
+	flushes chan chan error // Flush requests, answered with the write errors since the last flush
+	closing chan struct{}
+	done    chan struct{} // Closed once the writer goroutine has returned
+	close   sync.Once
+
+	queued, written, failed, dropped, blocked, late, batches atomic.Uint64
+
+	// Owned by the writer goroutine
+	failedSinceFlush int
+	lastErr          error
+}
+
+// newWriteBehind validates a configuration, filling in defaults for the settings left at zero, and starts the writer.
+func newWriteBehind(store models.LargeStore, cfg WriteBehindConfig) (*writeBehind, error) {
+	defaults := DefaultWriteBehindConfig()
+	if cfg.QueueSize == 0 {
+		cfg.QueueSize = defaults.QueueSize
+	}
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = defaults.BatchSize
+	}
+	if cfg.FlushInterval == 0 {
+		cfg.FlushInterval = defaults.FlushInterval
+	}
+	if cfg.Backpressure == "" {
+		cfg.Backpressure = defaults.Backpressure
+	}
+	if cfg.LateAfter == 0 {
+		cfg.LateAfter = defaults.LateAfter
+	}
+	if cfg.QueueSize < 0 || cfg.BatchSize < 0 || cfg.FlushInterval < 0 || cfg.LateAfter < 0 {
+		return nil, fmt.Errorf("write-behind settings must not be negative, got %+v", cfg)
+	}
+	switch cfg.Backpressure {
+	case BackpressureBlock, BackpressureDropNewest, BackpressureDropOldest:
+	default:
+		return nil, fmt.Errorf("unknown backpressure policy %q; use block, drop_newest or drop_oldest", cfg.Backpressure)
+	}
+
+	w := &writeBehind{
+		store:   store,
+		cfg:     cfg,
+		queue:   make(chan queuedTick, cfg.QueueSize),
+		flushes: make(chan chan error),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+// enqueue queues a tick for writing, applying the backpressure policy if the queue is full.
+func (w *writeBehind) enqueue(record models.TickRecord) error {
+	tick := queuedTick{record: record, queuedAt: time.Now()}
+	for {
+		select {
+		case <-w.closing:
+			return errors.New("write-behind queue is closed")
+		default:
+		}
+		select {
+		case w.queue <- tick:
+			w.queued.Add(1)
+			return nil
+		default:
+		}
+
+		switch w.cfg.Backpressure {
+		case BackpressureDropNewest:
+			w.dropped.Add(1)
+			return nil
+		case BackpressureDropOldest:
+			select {
+			case <-w.queue:
+				w.dropped.Add(1)
+			default:
+			}
+		default:
+			w.blocked.Add(1)
+			select {
+			case <-w.closing:
+				return errors.New("write-behind queue is closed")
+			case w.queue <- tick:
+				w.queued.Add(1)
+				return nil
+			}
+		}
+	}
+}
+
+// run writes batches when they fill up, every flush interval, on flush requests and on close.
+func (w *writeBehind) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]queuedTick, 0, w.cfg.BatchSize)
+	for {
+		select {
+		case tick := <-w.queue:
+			batch = append(batch, tick)
+			if len(batch) >= w.cfg.BatchSize {
+				batch = w.write(batch)
+			}
+		case <-ticker.C:
+			batch = w.write(batch)
+		case reply := <-w.flushes:
+			batch = w.drain(batch)
+			reply <- w.takeErr()
+		case <-w.closing:
+			w.drain(batch)
+			return
+		}
+	}
+}
+
+// drain writes the batch and everything queued, returning the emptied batch.
+func (w *writeBehind) drain(batch []queuedTick) []queuedTick {
+	for {
+		select {
+		case tick := <-w.queue:
+			batch = append(batch, tick)
+			if len(batch) >= w.cfg.BatchSize {
+				batch = w.write(batch)
+			}
+		default:
+			return w.write(batch)
+		}
+	}
+}
+
+// write writes a batch to the store and updates the metrics, returning the emptied batch.
+func (w *writeBehind) write(batch []queuedTick) []queuedTick {
+	if len(batch) == 0 {
+		return batch
+	}
+	var err error
+	if writer, ok := w.store.(models.BatchWriter); ok {
+		records := make([]models.TickRecord, len(batch))
+		for i, tick := range batch {
+			records[i] = tick.record
+		}
+		err = writer.RecordTicks(records)
+	} else {
+		var errs []error
+		for i := range batch {
+			record := &batch[i].record
+			errs = append(errs, w.store.RecordTick(record.Market, record.TradingPair, &record.MarketData))
+		}
+		err = errors.Join(errs...)
+	}
+	w.batches.Add(1)
+
+	if err != nil {
+		w.failed.Add(uint64(len(batch)))
+		w.failedSinceFlush += len(batch)
+		w.lastErr = err
+		log.Printf("Failed to write %d ticks to the large store: %v\n", len(batch), err)
+	} else {
+		w.written.Add(uint64(len(batch)))
+	}
+	now := time.Now()
+	for _, tick := range batch {
+		if now.Sub(tick.queuedAt) > w.cfg.LateAfter {
+			w.late.Add(1)
+		}
+	}
+	return batch[:0]
+}
+
+// takeErr returns an error describing the failed writes since it was last called, or nil if there were none.
+func (w *writeBehind) takeErr() error {
+	if w.failedSinceFlush == 0 {
+		return nil
+	}
+	err := fmt.Errorf("%d ticks failed to write to the large store: %w", w.failedSinceFlush, w.lastErr)
+	w.failedSinceFlush, w.lastErr = 0, nil
+	return err
+}
+
+// flush writes everything queued and returns the write errors since the last flush.
+func (w *writeBehind) flush() error {
+	reply := make(chan error, 1)
+	select {
+	case w.flushes <- reply:
+		return <-reply
+	case <-w.done:
+		return nil
+	}
+}
+
+// stop writes everything queued and stops the writer, returning the write errors since the last flush.
+// Ticks enqueued afterwards are refused.
+func (w *writeBehind) stop() (err error) {
+	w.close.Do(func() {
+		close(w.closing)
+		<-w.done
+		err = w.takeErr()
+	})
+	return err
+}
+
+// metrics returns the counters of the pipeline.
+func (w *writeBehind) metrics() models.WriteMetrics {
+	return models.WriteMetrics{
+		Queued:      w.queued.Load(),
+		Written:     w.written.Load(),
+		Failed:      w.failed.Load(),
+		Dropped:     w.dropped.Load(),
+		Blocked:     w.blocked.Load(),
+		Late:        w.late.Load(),
+		Batches:     w.batches.Load(),
+		QueueLength: len(w.queue),
+	}
+}
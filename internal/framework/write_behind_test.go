@@ -0,0 +1,190 @@
+package framework
+
+import (
+	"errors"
+	"github.com/bigmeech/tradingbot/pkg/models"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedStore is a batch writing large store whose writes can be held until the test releases them.
+type gatedStore struct {
+	MockLargeStore
+	mu      sync.Mutex
+	batches []int
+	gate    chan struct{} // Writes wait for a value or close, if set
+	entered chan struct{} // Receives a value when a write begins, if set
+	err     error
+}
+
+func (g *gatedStore) RecordTicks(records []models.TickRecord) error {
+	if g.entered != nil {
+		g.entered <- struct{}{}
+	}
+	if g.gate != nil {
+		<-g.gate
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.err != nil {
+		return g.err
+	}
+	g.batches = append(g.batches, len(records))
+	for i := range records {
+		g.MockLargeStore.RecordTick(records[i].Market, records[i].TradingPair, &records[i].MarketData)
+	}
+	return nil
+}
+
+func (g *gatedStore) prices() []float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.MockLargeStore.QueryPriceHistory("Mock", "BTC/USDT", len(g.recordedData))
+}
+
+func tickRecord(price float64) models.TickRecord {
+	return models.TickRecord{Market: "Mock", TradingPair: "BTC/USDT", MarketData: types.MarketData{Price: price}}
+}
+
+func TestWriteBehind_BatchesAndFlushes(t *testing.T) {
+	store := &gatedStore{}
+	w, err := newWriteBehind(store, WriteBehindConfig{BatchSize: 3, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer w.stop()
+
+	for i := 1; i <= 7; i++ {
+		if err := w.enqueue(tickRecord(float64(i))); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := w.flush(); err != nil {
+		t.Fatalf("Unexpected flush error: %v", err)
+	}
+
+	if prices := store.prices(); len(prices) != 7 || prices[0] != 1 || prices[6] != 7 {
+		t.Errorf("Expected all seven prices in order, got %v", prices)
+	}
+	if len(store.batches) != 3 || store.batches[0] != 3 || store.batches[1] != 3 || store.batches[2] != 1 {
+		t.Errorf("Expected batches of 3, 3 and 1, got %v", store.batches)
+	}
+	if metrics := w.metrics(); metrics.Queued != 7 || metrics.Written != 7 || metrics.Batches != 3 || metrics.QueueLength != 0 {
+		t.Errorf("Unexpected metrics %+v", metrics)
+	}
+}
+
+func TestWriteBehind_FlushInterval(t *testing.T) {
+	store := &gatedStore{}
+	w, err := newWriteBehind(store, WriteBehindConfig{BatchSize: 100, FlushInterval: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer w.stop()
+
+	w.enqueue(tickRecord(1))
+	deadline := time.Now().Add(time.Second)
+	for len(store.prices()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the tick to be written within the flush interval")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWriteBehind_Backpressure(t *testing.T) {
+	tests := []struct {
+		policy   BackpressurePolicy
+		expected []float64
+		dropped  uint64
+		blocked  uint64
+	}{
+		{BackpressureDropNewest, []float64{1, 2, 3}, 1, 0},
+		{BackpressureDropOldest, []float64{1, 3, 4}, 1, 0},
+		{BackpressureBlock, []float64{1, 2, 3, 4}, 0, 1},
+	}
+	for _, test := range tests {
+		t.Run(string(test.policy), func(t *testing.T) {
+			store := &gatedStore{gate: make(chan struct{}), entered: make(chan struct{}, 10)}
+			w, err := newWriteBehind(store, WriteBehindConfig{QueueSize: 2, BatchSize: 1, FlushInterval: time.Hour, Backpressure: test.policy})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			// The writer holds the first tick while the next two fill the queue
+			w.enqueue(tickRecord(1))
+			<-store.entered
+			w.enqueue(tickRecord(2))
+			w.enqueue(tickRecord(3))
+
+			enqueued := make(chan struct{})
+			go func() {
+				w.enqueue(tickRecord(4))
+				close(enqueued)
+			}()
+			if test.policy == BackpressureBlock {
+				select {
+				case <-enqueued:
+					t.Fatal("Expected the tick to wait for room in the queue")
+				case <-time.After(20 * time.Millisecond):
+				}
+				close(store.gate)
+				<-enqueued
+			} else {
+				<-enqueued // Dropping policies return straight away
+				close(store.gate)
+			}
+			if err := w.stop(); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			prices := store.prices()
+			if len(prices) != len(test.expected) {
+				t.Fatalf("Expected prices %v, got %v", test.expected, prices)
+			}
+			for i := range prices {
+				if prices[i] != test.expected[i] {
+					t.Fatalf("Expected prices %v, got %v", test.expected, prices)
+				}
+			}
+			if metrics := w.metrics(); metrics.Dropped != test.dropped || metrics.Blocked != test.blocked {
+				t.Errorf("Expected %d dropped and %d blocked, got %+v", test.dropped, test.blocked, metrics)
+			}
+		})
+	}
+}
+
+func TestWriteBehind_Failures(t *testing.T) {
+	store := &gatedStore{err: errors.New("connection reset")}
+	w, err := newWriteBehind(store, WriteBehindConfig{FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	w.enqueue(tickRecord(1))
+	w.enqueue(tickRecord(2))
+	if err := w.flush(); err == nil || !errors.Is(err, store.err) {
+		t.Errorf("Expected the write error from flush, got %v", err)
+	}
+	if err := w.flush(); err != nil {
+		t.Errorf("Expected errors to be reported once, got %v", err)
+	}
+	if metrics := w.metrics(); metrics.Failed != 2 || metrics.Written != 0 {
+		t.Errorf("Expected 2 failed ticks, got %+v", metrics)
+	}
+
+	w.stop()
+	if err := w.enqueue(tickRecord(3)); err == nil {
+		t.Error("Expected ticks to be refused once stopped")
+	}
+}
+
+func TestNewWriteBehind_InvalidConfig(t *testing.T) {
+	for _, cfg := range []WriteBehindConfig{{QueueSize: -1}, {Backpressure: "spill"}} {
+		if _, err := newWriteBehind(&gatedStore{}, cfg); err == nil {
+			t.Errorf("Expected an error for %+v", cfg)
+		}
+	}
+}
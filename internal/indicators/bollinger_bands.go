@@ -2,9 +2,17 @@ package indicators
 
 import (
 	"fmt"
+	"github.com/bigmeech/tradingbot/pkg/types"
 	"math"
 )
 
+// Bollinger Bands output names, used with types.IndicatorKey to read the bands from TickContext.Indicators.
+const (
+	BollingerUpper  = "upper"
+	BollingerMiddle = "middle"
+	BollingerLower  = "lower"
+)
+
 // BollingerBands represents the Bollinger Bands indicator.
 type BollingerBands struct {
 	period     int
@@ -21,8 +29,21 @@ func NewBollingerBands(period int, multiplier float64) *BollingerBands {
 	}
 }
 
-// Calculate computes the upper, middle, and lower Bollinger Bands.
-func (b *BollingerBands) Calculate(data []float64) []float64 {
+// Calculate computes the middle band, which is the SMA over the period.
+func (b *BollingerBands) Calculate(data []float64) float64 {
+	if len(data) < b.period {
+		return 0.0 // Insufficient data to calculate the bands
+	}
+	return NewSMA(b.period).Calculate(data)
+}
+
+// Outputs returns the names of the upper, middle and lower bands.
+func (b *BollingerBands) Outputs() []string {
+	return []string{BollingerUpper, BollingerMiddle, BollingerLower}
+}
+
+// CalculateOutputs computes the upper, middle, and lower Bollinger Bands.
+func (b *BollingerBands) CalculateOutputs(data []float64) map[string]float64 {
 	if len(data) < b.period {
 		return nil // Return nil for insufficient data
 	}
@@ -37,11 +58,10 @@ func (b *BollingerBands) Calculate(data []float64) []float64 {
 	}
 	standardDeviation := math.Sqrt(sumSquaredDiffs / float64(b.period))
 
-	// Return a slice representing the upper, middle, and lower bands
-	return []float64{
-		sma + (b.multiplier * standardDeviation), // Upper Band
-		sma,                                      // Middle Band
-		sma - (b.multiplier * standardDeviation), // Lower Band
+	return map[string]float64{
+		BollingerUpper:  sma + (b.multiplier * standardDeviation),
+		BollingerMiddle: sma,
+		BollingerLower:  sma - (b.multiplier * standardDeviation),
 	}
 }
 
@@ -54,3 +74,48 @@ func (b *BollingerBands) Name() string {
 func (b *BollingerBands) Period() int {
 	return b.period
 }
+
+// NewStream returns Bollinger Bands that are updated one price at a time.
+func (b *BollingerBands) NewStream() types.StreamingIndicator {
+	return &bollingerStream{multiplier: b.multiplier, window: newWindow(b.period)}
+}
+
+// bollingerStream keeps running sums of the prices and their squares over the last period prices.
+type bollingerStream struct {
+	multiplier float64
+	window     *window
+	sum        float64
+	sumSquares float64
+}
+
+func (b *bollingerStream) Update(value float64) {
+	evicted, full := b.window.push(value)
+	b.sum += value
+	b.sumSquares += value * value
+	if full {
+		b.sum -= evicted
+		b.sumSquares -= evicted * evicted
+	}
+}
+
+func (b *bollingerStream) Value() float64 {
+	if !b.window.full() {
+		return 0.0
+	}
+	return b.sum / float64(len(b.window.values))
+}
+
+func (b *bollingerStream) OutputValues() map[string]float64 {
+	if !b.window.full() {
+		return nil
+	}
+	n := float64(len(b.window.values))
+	sma := b.sum / n
+	// Clamp the variance at zero against rounding in the running sums
+	standardDeviation := math.Sqrt(math.Max(b.sumSquares/n-sma*sma, 0))
+	return map[string]float64{
+		BollingerUpper:  sma + (b.multiplier * standardDeviation),
+		BollingerMiddle: sma,
+		BollingerLower:  sma - (b.multiplier * standardDeviation),
+	}
+}
@@ -10,23 +10,29 @@ func TestBollingerBands_Calculate(t *testing.T) {
 
 	// Case 1: Not enough data to calculate Bollinger Bands
 	data := []float64{100, 105} // Only 2 points, Bollinger Bands need 3
-	result := bb.Calculate(data)
+	result := bb.CalculateOutputs(data)
 	if result != nil {
 		t.Errorf("Expected nil for insufficient data, got %v", result)
 	}
+	if middle := bb.Calculate(data); middle != 0 {
+		t.Errorf("Expected 0 for insufficient data, got %v", middle)
+	}
 
 	// Case 2: Sufficient data for Bollinger Bands
 	data = []float64{100, 105, 110} // Expected SMA: (100+105+110)/3 = 105
 	expectedSMA := 105.0
 
-	result = bb.Calculate(data)
+	result = bb.CalculateOutputs(data)
 	if len(result) != 3 {
 		t.Errorf("Expected 3 values (upper, middle, lower bands), got %d", len(result))
 	}
 
-	// Validate middle band (SMA)
-	if math.Abs(result[1]-expectedSMA) > 0.1 {
-		t.Errorf("Expected middle band (SMA) of %v, got %v", expectedSMA, result[1])
+	// Validate middle band (SMA), which is also the primary value
+	if math.Abs(result[BollingerMiddle]-expectedSMA) > 0.1 {
+		t.Errorf("Expected middle band (SMA) of %v, got %v", expectedSMA, result[BollingerMiddle])
+	}
+	if middle := bb.Calculate(data); middle != result[BollingerMiddle] {
+		t.Errorf("Expected Calculate to return the middle band %v, got %v", result[BollingerMiddle], middle)
 	}
 
 	// Calculate standard deviation for further validation
@@ -36,10 +42,10 @@ func TestBollingerBands_Calculate(t *testing.T) {
 	// Validate upper and lower bands
 	expectedUpper := expectedSMA + (2 * stdDev)
 	expectedLower := expectedSMA - (2 * stdDev)
-	if math.Abs(result[0]-expectedUpper) > 0.1 {
-		t.Errorf("Expected upper band of %v, got %v", expectedUpper, result[0])
+	if math.Abs(result[BollingerUpper]-expectedUpper) > 0.1 {
+		t.Errorf("Expected upper band of %v, got %v", expectedUpper, result[BollingerUpper])
 	}
-	if math.Abs(result[2]-expectedLower) > 0.1 {
-		t.Errorf("Expected lower band of %v, got %v", expectedLower, result[2])
+	if math.Abs(result[BollingerLower]-expectedLower) > 0.1 {
+		t.Errorf("Expected lower band of %v, got %v", expectedLower, result[BollingerLower])
 	}
 }
@@ -1,6 +1,9 @@
 package indicators
 
-import "fmt"
+import (
+	"fmt"
+	"github.com/bigmeech/tradingbot/pkg/types"
+)
 
 // EMA represents an Exponential Moving Average indicator.
 type EMA struct {
@@ -48,3 +51,35 @@ func (e *EMA) Name() string {
 func (e *EMA) Period() int {
 	return e.period
 }
+
+// NewStream returns an EMA that is updated one price at a time.
+func (e *EMA) NewStream() types.StreamingIndicator {
+	return &emaStream{period: e.period, multiplier: e.multiplier}
+}
+
+// emaStream seeds the EMA with the SMA of the first period prices, like Calculate.
+type emaStream struct {
+	period     int
+	multiplier float64
+	count      int
+	ema        float64
+}
+
+func (e *emaStream) Update(value float64) {
+	e.count++
+	switch {
+	case e.count < e.period:
+		e.ema += value
+	case e.count == e.period:
+		e.ema = (e.ema + value) / float64(e.period)
+	default:
+		e.ema = ((value - e.ema) * e.multiplier) + e.ema
+	}
+}
+
+func (e *emaStream) Value() float64 {
+	if e.count < e.period {
+		return 0.0
+	}
+	return e.ema
+}
@@ -1,6 +1,16 @@
 package indicators
 
-import "fmt"
+import (
+	"fmt"
+	"github.com/bigmeech/tradingbot/pkg/types"
+)
+
+// MACD output names, used with types.IndicatorKey to read the lines from TickContext.Indicators.
+const (
+	MACDLine      = "macd"
+	MACDSignal    = "signal"
+	MACDHistogram = "histogram"
+)
 
 // MACD represents a Moving Average Convergence Divergence indicator.
 type MACD struct {
@@ -34,12 +44,106 @@ func (m *MACD) Calculate(data []float64) float64 {
 	return fastEma - slowEma
 }
 
+// Outputs returns the names of the MACD, signal and histogram lines.
+func (m *MACD) Outputs() []string {
+	return []string{MACDLine, MACDSignal, MACDHistogram}
+}
+
+// CalculateOutputs computes the MACD line, its signal line (an EMA of the MACD line) and the histogram
+// (MACD minus signal). It needs Period() prices so the signal line has enough MACD values.
+func (m *MACD) CalculateOutputs(data []float64) map[string]float64 {
+	if len(data) < m.Period() {
+		return nil // Insufficient data for the signal line
+	}
+
+	// Build the MACD line for every price once both EMAs are seeded
+	fast, slow := NewEMA(m.fastPeriod), NewEMA(m.slowPeriod)
+	fastEma, slowEma := 0.0, 0.0
+	var macdLine []float64
+	for i, price := range data {
+		fastEma = nextEMA(fast, fastEma, data, i, price)
+		slowEma = nextEMA(slow, slowEma, data, i, price)
+		if i >= m.slowPeriod-1 {
+			macdLine = append(macdLine, fastEma-slowEma)
+		}
+	}
+
+	macd := macdLine[len(macdLine)-1]
+	signal := NewEMA(m.signalPeriod).Calculate(macdLine)
+	return map[string]float64{
+		MACDLine:      macd,
+		MACDSignal:    signal,
+		MACDHistogram: macd - signal,
+	}
+}
+
+// nextEMA advances an EMA by the price at index i, seeding it with the SMA of the first period prices
+// the same way EMA.Calculate does.
+func nextEMA(e *EMA, ema float64, data []float64, i int, price float64) float64 {
+	switch {
+	case i < e.period-1:
+		return 0
+	case i == e.period-1:
+		return NewSMA(e.period).Calculate(data[:e.period])
+	default:
+		return ((price - ema) * e.multiplier) + ema
+	}
+}
+
 // Name returns the name of the indicator.
 func (m *MACD) Name() string {
 	return m.name
 }
 
-// Period returns the slow period of the MACD as the minimum data required.
+// Period returns the number of prices needed for the signal line: the slow period to seed
+// the MACD line, plus the signal period of MACD values less the one they share.
 func (m *MACD) Period() int {
-	return m.slowPeriod
+	return m.slowPeriod + m.signalPeriod - 1
+}
+
+// NewStream returns a MACD that is updated one price at a time.
+func (m *MACD) NewStream() types.StreamingIndicator {
+	return &macdStream{
+		slowPeriod: m.slowPeriod,
+		fast:       NewEMA(m.fastPeriod).NewStream(),
+		slow:       NewEMA(m.slowPeriod).NewStream(),
+		signal:     NewEMA(m.signalPeriod).NewStream().(*emaStream),
+	}
+}
+
+// macdStream feeds the MACD line into a signal EMA once the slow EMA is seeded.
+type macdStream struct {
+	slowPeriod int
+	count      int
+	fast       types.StreamingIndicator
+	slow       types.StreamingIndicator
+	signal     *emaStream
+}
+
+func (m *macdStream) Update(value float64) {
+	m.count++
+	m.fast.Update(value)
+	m.slow.Update(value)
+	if m.count >= m.slowPeriod {
+		m.signal.Update(m.Value())
+	}
+}
+
+func (m *macdStream) Value() float64 {
+	if m.count < m.slowPeriod {
+		return 0.0
+	}
+	return m.fast.Value() - m.slow.Value()
+}
+
+func (m *macdStream) OutputValues() map[string]float64 {
+	if m.signal.count < m.signal.period {
+		return nil
+	}
+	macd, signal := m.Value(), m.signal.Value()
+	return map[string]float64{
+		MACDLine:      macd,
+		MACDSignal:    signal,
+		MACDHistogram: macd - signal,
+	}
 }
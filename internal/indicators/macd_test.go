@@ -1,6 +1,7 @@
 package indicators
 
 import (
+	"math"
 	"testing"
 )
 
@@ -27,3 +28,33 @@ func TestMACD_Calculate(t *testing.T) {
 		t.Errorf("Expected MACD of around %v, got %v", expectedMacd, result)
 	}
 }
+
+func TestMACD_CalculateOutputs(t *testing.T) {
+	macd := NewMACD(3, 6, 3)
+	if macd.Period() != 8 {
+		t.Fatalf("Expected period 8 to cover the signal line, got %v", macd.Period())
+	}
+
+	data := []float64{10, 12, 11, 15, 18, 17, 21, 24, 22, 27}
+	if outputs := macd.CalculateOutputs(data[:7]); outputs != nil {
+		t.Errorf("Expected nil for insufficient data, got %v", outputs)
+	}
+
+	// The signal line is the EMA of the MACD line at each price since the slow EMA was seeded
+	var macdLine []float64
+	for end := 6; end <= len(data); end++ {
+		macdLine = append(macdLine, macd.Calculate(data[:end]))
+	}
+	expectedSignal := NewEMA(3).Calculate(macdLine)
+
+	outputs := macd.CalculateOutputs(data)
+	if math.Abs(outputs[MACDLine]-macd.Calculate(data)) > 1e-9 {
+		t.Errorf("Expected MACD line %v, got %v", macd.Calculate(data), outputs[MACDLine])
+	}
+	if math.Abs(outputs[MACDSignal]-expectedSignal) > 1e-9 {
+		t.Errorf("Expected signal %v, got %v", expectedSignal, outputs[MACDSignal])
+	}
+	if math.Abs(outputs[MACDHistogram]-(outputs[MACDLine]-outputs[MACDSignal])) > 1e-9 {
+		t.Errorf("Expected histogram to be MACD minus signal, got %v", outputs[MACDHistogram])
+	}
+}
@@ -1,6 +1,9 @@
 package indicators
 
-import "fmt"
+import (
+	"fmt"
+	"github.com/bigmeech/tradingbot/pkg/types"
+)
 
 // In indicators/rsi.go
 
@@ -45,3 +48,47 @@ func (r *RSI) Name() string {
 func (r *RSI) Period() int {
 	return r.period
 }
+
+// NewStream returns an RSI that is updated one price at a time.
+func (r *RSI) NewStream() types.StreamingIndicator {
+	return &rsiStream{period: r.period, window: newWindow(r.period)}
+}
+
+// rsiStream keeps running gain and loss sums over the price changes within the last period prices.
+type rsiStream struct {
+	period int
+	window *window
+	gain   float64
+	loss   float64
+}
+
+func (r *rsiStream) Update(value float64) {
+	if r.window.count > 0 {
+		r.addChange(value-r.window.at(r.window.count-1), 1)
+	}
+	if r.window.full() {
+		// The change between the two oldest prices leaves the window
+		r.addChange(r.window.at(1)-r.window.at(0), -1)
+	}
+	r.window.push(value)
+}
+
+// addChange adds (sign 1) or removes (sign -1) a price change from the gain and loss sums.
+func (r *rsiStream) addChange(change, sign float64) {
+	if change > 0 {
+		r.gain += sign * change
+	} else {
+		r.loss -= sign * change
+	}
+}
+
+func (r *rsiStream) Value() float64 {
+	if !r.window.full() {
+		return 0.0
+	}
+	if r.loss <= 1e-12 {
+		return 100
+	}
+	rs := (r.gain / float64(r.period)) / (r.loss / float64(r.period))
+	return 100 - (100 / (1 + rs))
+}
@@ -1,6 +1,9 @@
 package indicators
 
-import "fmt"
+import (
+	"fmt"
+	"github.com/bigmeech/tradingbot/pkg/types"
+)
 
 // In indicators/sma.go
 
@@ -34,3 +37,29 @@ func (s *SMA) Name() string {
 func (s *SMA) Period() int {
 	return s.period
 }
+
+// NewStream returns an SMA that is updated one price at a time.
+func (s *SMA) NewStream() types.StreamingIndicator {
+	return &smaStream{window: newWindow(s.period)}
+}
+
+// smaStream keeps a running sum over the last period prices.
+type smaStream struct {
+	window *window
+	sum    float64
+}
+
+func (s *smaStream) Update(value float64) {
+	evicted, full := s.window.push(value)
+	s.sum += value
+	if full {
+		s.sum -= evicted
+	}
+}
+
+func (s *smaStream) Value() float64 {
+	if !s.window.full() {
+		return 0.0
+	}
+	return s.sum / float64(len(s.window.values))
+}
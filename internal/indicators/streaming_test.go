@@ -0,0 +1,48 @@
+package indicators
+
+import (
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"math"
+	"testing"
+)
+
+func TestStreams_MatchCalculate(t *testing.T) {
+	prices := []float64{100, 102, 101, 105, 104, 104, 108, 107, 103, 99, 101, 106, 110, 109, 111, 108}
+
+	tests := []types.StreamableIndicator{
+		NewSMA(4),
+		NewEMA(4),
+		NewRSI(5),
+		NewBollingerBands(5, 2),
+		NewMACD(3, 6, 4),
+	}
+
+	for _, indicator := range tests {
+		t.Run(indicator.Name(), func(t *testing.T) {
+			stream := indicator.NewStream()
+			for i, price := range prices {
+				stream.Update(price)
+				history := prices[:i+1]
+
+				if expected := indicator.Calculate(history); math.Abs(stream.Value()-expected) > 1e-9 {
+					t.Errorf("After %d prices expected %v, got %v", i+1, expected, stream.Value())
+				}
+
+				multi, ok := indicator.(types.MultiValueIndicator)
+				if !ok {
+					continue
+				}
+				expected := multi.CalculateOutputs(history)
+				actual := stream.(types.MultiValueStreamingIndicator).OutputValues()
+				if len(actual) != len(expected) {
+					t.Fatalf("After %d prices expected outputs %v, got %v", i+1, expected, actual)
+				}
+				for output, value := range expected {
+					if math.Abs(actual[output]-value) > 1e-9 {
+						t.Errorf("After %d prices expected %s %v, got %v", i+1, output, value, actual[output])
+					}
+				}
+			}
+		})
+	}
+}
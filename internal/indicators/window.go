@@ -0,0 +1,37 @@
+package indicators
+
+// window holds the most recent values of a stream in a fixed-size ring.
+type window struct {
+	values []float64
+	next   int
+	count  int
+}
+
+func newWindow(size int) *window {
+	return &window{values: make([]float64, size)}
+}
+
+// push adds a value, returning the value it evicted and whether the window was already full.
+func (w *window) push(value float64) (float64, bool) {
+	evicted, full := w.values[w.next], w.full()
+	w.values[w.next] = value
+	w.next = (w.next + 1) % len(w.values)
+	if !full {
+		w.count++
+	}
+	return evicted, full
+}
+
+// full reports whether the window holds size values.
+func (w *window) full() bool {
+	return w.count == len(w.values)
+}
+
+// at returns the i-th oldest value in the window.
+func (w *window) at(i int) float64 {
+	start := 0
+	if w.full() {
+		start = w.next
+	}
+	return w.values[(start+i)%len(w.values)]
+}
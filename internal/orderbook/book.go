@@ -0,0 +1,194 @@
+package orderbook
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"sort"
+)
+
+// maxPending is the number of diffs buffered while a book waits for a snapshot. Older diffs are dropped
+// first; if that leaves a gap after the snapshot, the book goes out of sync again.
+const maxPending = 1000
+
+// ErrOutOfSync is returned by Apply when a book cannot apply a diff, either because it has no snapshot
+// yet or because a sequence gap shows updates were missed. The caller should request a new snapshot;
+// diffs received in the meantime are buffered and replayed on top of it.
+var ErrOutOfSync = errors.New("order book is out of sync")
+
+// Book is a local L2 order book for one trading pair, built from a snapshot and the diffs that follow it.
+// A Book is not safe for concurrent use.
+type Book struct {
+	tradingPair string
+	maxDepth    int                // Levels kept per side, zero for all
+	bids        []types.PriceLevel // Best (highest) bid first
+	asks        []types.PriceLevel // Best (lowest) ask first
+	sequence    int64
+	time        int64
+
+	synced   bool                     // A snapshot has been applied and no gap has been seen since
+	awaiting bool                     // ErrOutOfSync was returned and a snapshot is expected
+	pending  []*types.OrderBookUpdate // Diffs received while awaiting a snapshot
+}
+
+// NewBook initializes an empty Book that keeps up to maxDepth levels per side, or every level if maxDepth is zero.
+func NewBook(tradingPair string, maxDepth int) *Book {
+	return &Book{tradingPair: tradingPair, maxDepth: maxDepth}
+}
+
+// Apply applies a snapshot or diff. Diffs already covered by the book's sequence are ignored. A diff that
+// arrives before the first snapshot or after a sequence gap returns ErrOutOfSync once; later diffs are
+// buffered until a snapshot arrives, when the ones newer than the snapshot are applied on top of it.
+func (b *Book) Apply(update *types.OrderBookUpdate) error {
+	if update.Snapshot {
+		return b.applySnapshot(update)
+	}
+	if !b.synced {
+		b.buffer(update)
+		if b.awaiting {
+			return nil
+		}
+		b.awaiting = true
+		return fmt.Errorf("%w: no snapshot for %s", ErrOutOfSync, b.tradingPair)
+	}
+	if err := b.applyDiff(update); err != nil {
+		b.synced, b.awaiting = false, true
+		b.pending = []*types.OrderBookUpdate{update}
+		return err
+	}
+	return nil
+}
+
+// applySnapshot replaces the book and replays the buffered diffs that are newer than the snapshot.
+// Diffs without sequence numbers cannot be ordered against the snapshot, so they are dropped.
+func (b *Book) applySnapshot(update *types.OrderBookUpdate) error {
+	b.bids = b.bids[:0]
+	b.asks = b.asks[:0]
+	b.setLevels(update)
+	b.sequence, b.time = update.LastSequence, update.Time
+	b.synced, b.awaiting = true, false
+
+	pending := b.pending
+	b.pending = nil
+	for _, diff := range pending {
+		if diff.LastSequence == 0 {
+			continue
+		}
+		if err := b.applyDiff(diff); err != nil {
+			b.synced, b.awaiting = false, true
+			return err
+		}
+	}
+	return nil
+}
+
+// applyDiff applies a diff to a synced book, returning ErrOutOfSync if it does not follow on from the book's sequence.
+func (b *Book) applyDiff(update *types.OrderBookUpdate) error {
+	if update.LastSequence != 0 && update.LastSequence <= b.sequence {
+		return nil // Already included in the snapshot or an earlier diff
+	}
+	if update.FirstSequence != 0 && b.sequence != 0 && update.FirstSequence > b.sequence+1 {
+		return fmt.Errorf("%w: %s expected update %d, got %d", ErrOutOfSync, b.tradingPair, b.sequence+1, update.FirstSequence)
+	}
+	b.setLevels(update)
+	if update.LastSequence != 0 {
+		b.sequence = update.LastSequence
+	}
+	if update.Time != 0 {
+		b.time = update.Time
+	}
+	return nil
+}
+
+// buffer keeps a diff to replay once a snapshot arrives.
+func (b *Book) buffer(update *types.OrderBookUpdate) {
+	if len(b.pending) >= maxPending {
+		b.pending = b.pending[1:]
+	}
+	b.pending = append(b.pending, update)
+}
+
+// setLevels applies the levels of an update to both sides of the book.
+func (b *Book) setLevels(update *types.OrderBookUpdate) {
+	for _, level := range update.Bids {
+		b.bids = setLevel(b.bids, level, true)
+	}
+	for _, level := range update.Asks {
+		b.asks = setLevel(b.asks, level, false)
+	}
+	if b.maxDepth > 0 {
+		if len(b.bids) > b.maxDepth {
+			b.bids = b.bids[:b.maxDepth]
+		}
+		if len(b.asks) > b.maxDepth {
+			b.asks = b.asks[:b.maxDepth]
+		}
+	}
+}
+
+// setLevel sets, or with a zero quantity removes, a price level in a side sorted best first.
+func setLevel(side []types.PriceLevel, level types.PriceLevel, descending bool) []types.PriceLevel {
+	i := sort.Search(len(side), func(i int) bool {
+		if descending {
+			return side[i].Price <= level.Price
+		}
+		return side[i].Price >= level.Price
+	})
+	found := i < len(side) && side[i].Price == level.Price
+	switch {
+	case level.Quantity <= 0 && found:
+		return append(side[:i], side[i+1:]...)
+	case level.Quantity <= 0:
+		return side
+	case found:
+		side[i].Quantity = level.Quantity
+		return side
+	}
+	side = append(side, types.PriceLevel{})
+	copy(side[i+1:], side[i:])
+	side[i] = level
+	return side
+}
+
+// Synced reports whether the book has a snapshot and has seen no gap since.
+func (b *Book) Synced() bool {
+	return b.synced
+}
+
+// MarkOutOfSync puts a synced book out of sync, as a sequence gap does, e.g. when it fails an exchange
+// checksum. Diffs are then buffered until the snapshot the caller requests arrives.
+func (b *Book) MarkOutOfSync() {
+	b.synced, b.awaiting = false, true
+	b.pending = nil
+}
+
+// Reset empties the book and drops buffered diffs, so the next diff returns ErrOutOfSync again.
+// Callers reset a book when a requested snapshot could not be fetched.
+func (b *Book) Reset() {
+	b.bids, b.asks = nil, nil
+	b.sequence, b.time = 0, 0
+	b.synced, b.awaiting = false, false
+	b.pending = nil
+}
+
+// Snapshot returns a copy of the best levels of each side, or nil if the book is not synced.
+// A levels value of zero or less copies every level.
+func (b *Book) Snapshot(levels int) *types.OrderBook {
+	if !b.synced {
+		return nil
+	}
+	return &types.OrderBook{
+		TradingPair: b.tradingPair,
+		Bids:        copyLevels(b.bids, levels),
+		Asks:        copyLevels(b.asks, levels),
+		Sequence:    b.sequence,
+		Time:        b.time,
+	}
+}
+
+func copyLevels(side []types.PriceLevel, levels int) []types.PriceLevel {
+	if levels <= 0 || levels > len(side) {
+		levels = len(side)
+	}
+	return append([]types.PriceLevel(nil), side[:levels]...)
+}
@@ -0,0 +1,150 @@
+package orderbook
+
+import (
+	"errors"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"reflect"
+	"testing"
+)
+
+func levels(pairs ...float64) []types.PriceLevel {
+	var result []types.PriceLevel
+	for i := 0; i+1 < len(pairs); i += 2 {
+		result = append(result, types.PriceLevel{Price: pairs[i], Quantity: pairs[i+1]})
+	}
+	return result
+}
+
+func snapshot(sequence int64) *types.OrderBookUpdate {
+	return &types.OrderBookUpdate{
+		TradingPair:  "BTC/USDT",
+		Snapshot:     true,
+		Bids:         levels(99, 1, 98, 2, 97, 3),
+		Asks:         levels(101, 1, 102, 2, 103, 3),
+		LastSequence: sequence,
+	}
+}
+
+func diff(first, last int64, bids, asks []types.PriceLevel) *types.OrderBookUpdate {
+	return &types.OrderBookUpdate{TradingPair: "BTC/USDT", Bids: bids, Asks: asks, FirstSequence: first, LastSequence: last}
+}
+
+func TestBook_ApplyDiffs(t *testing.T) {
+	book := NewBook("BTC/USDT", 0)
+	if err := book.Apply(snapshot(100)); err != nil {
+		t.Fatalf("Expected the snapshot to apply, got %v", err)
+	}
+
+	// The first diff may straddle the snapshot; levels are inserted, updated and removed
+	if err := book.Apply(diff(95, 101, levels(99.5, 4, 98, 0), levels(101, 5))); err != nil {
+		t.Fatalf("Expected the diff to apply, got %v", err)
+	}
+	// Diffs already covered by the book are ignored
+	if err := book.Apply(diff(90, 100, levels(99.5, 0), nil)); err != nil {
+		t.Fatalf("Expected a stale diff to be ignored, got %v", err)
+	}
+
+	got := book.Snapshot(0)
+	if want := levels(99.5, 4, 99, 1, 97, 3); !reflect.DeepEqual(got.Bids, want) {
+		t.Errorf("Expected bids %v, got %v", want, got.Bids)
+	}
+	if want := levels(101, 5, 102, 2, 103, 3); !reflect.DeepEqual(got.Asks, want) {
+		t.Errorf("Expected asks %v, got %v", want, got.Asks)
+	}
+	if got.Sequence != 101 || got.Spread() != 1.5 {
+		t.Errorf("Expected sequence 101 and spread 1.5, got %d and %v", got.Sequence, got.Spread())
+	}
+	if top := book.Snapshot(1); len(top.Bids) != 1 || len(top.Asks) != 1 {
+		t.Errorf("Expected one level per side, got %+v", top)
+	}
+}
+
+func TestBook_GapResync(t *testing.T) {
+	book := NewBook("BTC/USDT", 0)
+
+	// Diffs before the first snapshot report once that the book is out of sync, then buffer
+	if err := book.Apply(diff(99, 101, levels(99, 5), nil)); !errors.Is(err, ErrOutOfSync) {
+		t.Fatalf("Expected ErrOutOfSync before a snapshot, got %v", err)
+	}
+	if err := book.Apply(diff(102, 102, nil, levels(101, 0))); err != nil {
+		t.Fatalf("Expected the diff to be buffered, got %v", err)
+	}
+	if book.Synced() || book.Snapshot(0) != nil {
+		t.Fatal("Expected no book before a snapshot")
+	}
+
+	// Buffered diffs newer than the snapshot are replayed on top of it
+	if err := book.Apply(snapshot(100)); err != nil {
+		t.Fatalf("Expected the snapshot to apply, got %v", err)
+	}
+	got := book.Snapshot(0)
+	if bid, _ := got.BestBid(); bid.Quantity != 5 || got.Sequence != 102 {
+		t.Errorf("Expected the buffered diffs to be replayed, got %+v", got)
+	}
+	if ask, _ := got.BestAsk(); ask.Price != 102 {
+		t.Errorf("Expected the best ask to move to 102, got %v", ask.Price)
+	}
+
+	// A gap puts the book out of sync until the next snapshot
+	if err := book.Apply(diff(105, 106, levels(99, 7), nil)); !errors.Is(err, ErrOutOfSync) {
+		t.Fatalf("Expected ErrOutOfSync for a gap, got %v", err)
+	}
+	if err := book.Apply(diff(107, 107, levels(96, 1), nil)); err != nil {
+		t.Fatalf("Expected the diff after a gap to be buffered, got %v", err)
+	}
+	if book.Synced() {
+		t.Fatal("Expected the book to be out of sync after a gap")
+	}
+	if err := book.Apply(snapshot(106)); err != nil {
+		t.Fatalf("Expected the resync snapshot to apply, got %v", err)
+	}
+	if got := book.Snapshot(0); got.Sequence != 107 || len(got.Bids) != 4 {
+		t.Errorf("Expected only the diff after the snapshot to be replayed, got %+v", got)
+	}
+
+	// A failed checksum puts the book out of sync without reporting it again
+	book.MarkOutOfSync()
+	if err := book.Apply(diff(108, 108, levels(95, 1), nil)); err != nil || book.Synced() {
+		t.Fatalf("Expected the diff to be buffered until a snapshot, got %v", err)
+	}
+	if err := book.Apply(snapshot(107)); err != nil || !book.Synced() || len(book.Snapshot(0).Bids) != 4 {
+		t.Fatalf("Expected the snapshot and the buffered diff to apply, got %+v, %v", book.Snapshot(0), err)
+	}
+
+	// Reset forgets the book, so the next diff asks for a snapshot again
+	book.Reset()
+	if err := book.Apply(diff(109, 109, nil, nil)); !errors.Is(err, ErrOutOfSync) {
+		t.Errorf("Expected ErrOutOfSync after a reset, got %v", err)
+	}
+}
+
+func TestBook_MaxDepth(t *testing.T) {
+	book := NewBook("BTC/USD", 2)
+	book.Apply(snapshot(0))
+	book.Apply(diff(0, 0, levels(100, 1), levels(100.5, 1)))
+
+	got := book.Snapshot(0)
+	if want := levels(100, 1, 99, 1); !reflect.DeepEqual(got.Bids, want) {
+		t.Errorf("Expected bids %v, got %v", want, got.Bids)
+	}
+	if want := levels(100.5, 1, 101, 1); !reflect.DeepEqual(got.Asks, want) {
+		t.Errorf("Expected asks %v, got %v", want, got.Asks)
+	}
+}
+
+func TestBooks_Apply(t *testing.T) {
+	books := NewBooks(0)
+	if err := books.Apply("Binance", snapshot(1)); err != nil {
+		t.Fatalf("Expected the snapshot to apply, got %v", err)
+	}
+	if books.Snapshot("Binance", "BTC/USDT", 5).MidPrice() != 100 {
+		t.Errorf("Expected the 100 mid price, got %+v", books.Snapshot("Binance", "BTC/USDT", 5))
+	}
+	if books.Snapshot("Kraken", "BTC/USDT", 5) != nil {
+		t.Error("Expected no book on another market")
+	}
+	books.Reset("Binance", "BTC/USDT")
+	if books.Snapshot("Binance", "BTC/USDT", 5) != nil {
+		t.Error("Expected no book after a reset")
+	}
+}
@@ -0,0 +1,56 @@
+package orderbook
+
+import (
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"sync"
+)
+
+// Books maintains a local order book per market and trading pair from streamed depth updates.
+// Books is safe for concurrent use.
+type Books struct {
+	mu       sync.Mutex
+	books    map[string]*Book // Book per market/trading pair key
+	maxDepth int
+}
+
+// NewBooks initializes an empty set of books keeping up to maxDepth levels per side, or every level if maxDepth is zero.
+func NewBooks(maxDepth int) *Books {
+	return &Books{
+		books:    make(map[string]*Book),
+		maxDepth: maxDepth,
+	}
+}
+
+// Apply applies an update to the book of its trading pair on a market, creating the book on first use.
+// It returns ErrOutOfSync as Book.Apply does, when the caller should request a new snapshot.
+func (b *Books) Apply(marketName string, update *types.OrderBookUpdate) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := marketName + ":" + update.TradingPair
+	book := b.books[key]
+	if book == nil {
+		book = NewBook(update.TradingPair, b.maxDepth)
+		b.books[key] = book
+	}
+	return book.Apply(update)
+}
+
+// Reset empties the book of a market and trading pair, so its next diff returns ErrOutOfSync again.
+func (b *Books) Reset(marketName, tradingPair string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if book := b.books[marketName+":"+tradingPair]; book != nil {
+		book.Reset()
+	}
+}
+
+// Snapshot returns a copy of the best levels of a market and trading pair's book, or nil if it has no synced book.
+func (b *Books) Snapshot(marketName, tradingPair string, levels int) *types.OrderBook {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if book := b.books[marketName+":"+tradingPair]; book != nil {
+		return book.Snapshot(levels)
+	}
+	return nil
+}
@@ -0,0 +1,415 @@
+package paper
+
+import (
+	"fmt"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"sync"
+)
+
+// Config holds the starting balances and cost model of a simulated exchange.
+type Config struct {
+	Balances map[string]float64 // Starting balance per asset, e.g. {"USDT": 10000}
+	FeeRate  float64            // Fee charged in the quote asset on the notional of each fill, e.g. 0.001 for 0.1%
+	Slippage float64            // Fraction of the price applied against market fills, e.g. 0.0005 for 5 bps
+}
+
+// Fill records a simulated execution.
+type Fill struct {
+	OrderID     string
+	Time        int64
+	TradingPair string
+	Side        types.OrderSide
+	OrderType   types.OrderType
+	Amount      float64
+	Price       float64
+	Fee         float64
+}
+
+// Exchange is an in-process matching engine that fills orders against a live price stream.
+// Market orders fill immediately at the last price plus slippage, limit orders rest until the
+// price trades through them, and stop-loss and take-profit orders trigger on the last price.
+// Resting orders hold the balance they need until they fill or are cancelled, so it cannot be spent twice.
+type Exchange struct {
+	mu         sync.Mutex
+	feeRate    float64
+	slippage   float64
+	balances   map[string]float64
+	lastPrices map[string]float64
+	lastTimes  map[string]int64
+	orders     map[string]*types.Order // Every order placed, by exchange order ID
+	resting    []*types.Order          // Open orders waiting for their limit or trigger price (the Price field), holding their funds
+	fills      []Fill
+	nextID     int64
+	onUpdate   func(order *types.Order) // Called with resting orders once they fill or are rejected
+}
+
+// NewExchange initializes a simulated exchange from the given configuration.
+func NewExchange(cfg Config) *Exchange {
+	balances := make(map[string]float64, len(cfg.Balances))
+	for asset, amount := range cfg.Balances {
+		balances[asset] = amount
+	}
+	return &Exchange{
+		feeRate:    cfg.FeeRate,
+		slippage:   cfg.Slippage,
+		balances:   balances,
+		lastPrices: make(map[string]float64),
+		lastTimes:  make(map[string]int64),
+		orders:     make(map[string]*types.Order),
+	}
+}
+
+// UpdatePrice records the latest traded price for a trading pair and fills any resting orders it reaches.
+// Each resting order it fills or rejects is passed to the OnOrderUpdate handler, once the book is updated.
+func (e *Exchange) UpdatePrice(tradingPair string, price float64, timestamp int64) {
+	e.mu.Lock()
+	e.lastPrices[tradingPair] = price
+	e.lastTimes[tradingPair] = timestamp
+
+	// Reached orders leave the book first, releasing the funds they hold for their own fills
+	var reached []*types.Order
+	var fillPrices []float64
+	remaining := make([]*types.Order, 0, len(e.resting))
+	for _, order := range e.resting {
+		if order.TradingPair == tradingPair {
+			if fillPrice, ok := e.matchResting(order, price); ok {
+				reached = append(reached, order)
+				fillPrices = append(fillPrices, fillPrice)
+				continue
+			}
+		}
+		remaining = append(remaining, order)
+	}
+	e.resting = remaining
+
+	updated := make([]*types.Order, 0, len(reached))
+	for i, order := range reached {
+		// Orders that can no longer be funded, e.g. stops that slipped past their price, are rejected
+		_ = e.fill(order, fillPrices[i])
+		order.UpdatedAt = timestamp
+		updated = append(updated, order.Clone())
+	}
+	onUpdate := e.onUpdate
+	e.mu.Unlock()
+
+	// The handler is called without the lock, so it may place or query orders
+	if onUpdate != nil {
+		for _, order := range updated {
+			onUpdate(order)
+		}
+	}
+}
+
+// OnOrderUpdate sets the handler called with a snapshot of each resting order that fills, or is rejected
+// because it can no longer be funded, when the price reaches it.
+func (e *Exchange) OnOrderUpdate(handler func(order *types.Order)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onUpdate = handler
+}
+
+// matchResting reports whether a resting order is reached at price and the price it fills at.
+func (e *Exchange) matchResting(order *types.Order, price float64) (float64, bool) {
+	buy := order.Side == types.OrderSideBuy
+	switch order.Type {
+	case types.OrderTypeLimit:
+		if (buy && price <= order.Price) || (!buy && price >= order.Price) {
+			return order.Price, true
+		}
+	case types.OrderTypeStopLoss, types.OrderTypeTakeProfit:
+		if e.triggered(order, price) {
+			return e.slipped(order.Side, price), true
+		}
+	case types.OrderTypeStopLossLimit, types.OrderTypeTakeProfitLimit:
+		// With a single price the trigger and the limit coincide, so the order fills at that price
+		if e.triggered(order, price) {
+			return order.Price, true
+		}
+	}
+	return 0, false
+}
+
+// triggered reports whether a stop-loss or take-profit order has reached its trigger price.
+func (e *Exchange) triggered(order *types.Order, price float64) bool {
+	buy := order.Side == types.OrderSideBuy
+	switch order.Type {
+	case types.OrderTypeStopLoss, types.OrderTypeStopLossLimit:
+		// Stops protect against adverse moves: sell stops trigger on a fall, buy stops on a rise
+		return (buy && price >= order.Price) || (!buy && price <= order.Price)
+	case types.OrderTypeTakeProfit, types.OrderTypeTakeProfitLimit:
+		return (buy && price <= order.Price) || (!buy && price >= order.Price)
+	}
+	return false
+}
+
+// slipped applies the configured slippage against the taker.
+func (e *Exchange) slipped(side types.OrderSide, price float64) float64 {
+	if side == types.OrderSideBuy {
+		return price * (1 + e.slippage)
+	}
+	return price * (1 - e.slippage)
+}
+
+// ExecuteOrder places an order on the simulated exchange. Market orders and marketable limit orders
+// fill immediately; limit, stop-loss and take-profit orders otherwise rest until the price reaches them.
+// The returned order is a snapshot and is not updated by later fills.
+func (e *Exchange) ExecuteOrder(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64) (*types.Order, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.placeOrder(orderType, side, tradingPair, amount, price)
+}
+
+// placeOrder places an order as ExecuteOrder does. Callers must hold e.mu.
+func (e *Exchange) placeOrder(orderType types.OrderType, side types.OrderSide, tradingPair string, amount, price float64) (*types.Order, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("invalid order amount %v", amount)
+	}
+	if side != types.OrderSideBuy && side != types.OrderSideSell {
+		return nil, fmt.Errorf("invalid order side %q", side)
+	}
+	lastPrice, ok := e.lastPrices[tradingPair]
+	if !ok {
+		return nil, fmt.Errorf("no market price for %s yet", tradingPair)
+	}
+
+	e.nextID++
+	order := &types.Order{
+		ClientOrderID:   types.NewClientOrderID(),
+		ExchangeOrderID: fmt.Sprintf("paper-%d", e.nextID),
+		TradingPair:     tradingPair,
+		Type:            orderType,
+		Side:            side,
+		Status:          types.OrderStatusNew,
+		Price:           price,
+		Quantity:        amount,
+		CreatedAt:       e.lastTimes[tradingPair],
+		UpdatedAt:       e.lastTimes[tradingPair],
+	}
+	e.orders[order.ExchangeOrderID] = order
+
+	switch orderType {
+	case types.OrderTypeMarket:
+		err := e.fill(order, e.slipped(side, lastPrice))
+		return order.Clone(), err
+	case types.OrderTypeLimit, types.OrderTypeStopLoss, types.OrderTypeStopLossLimit,
+		types.OrderTypeTakeProfit, types.OrderTypeTakeProfitLimit:
+		if price <= 0 {
+			return e.reject(order), fmt.Errorf("%s order requires a price", orderType)
+		}
+		if err := e.checkFunds(side, tradingPair, amount, price); err != nil {
+			return e.reject(order), err
+		}
+		if orderType == types.OrderTypeLimit {
+			// A marketable limit order takes liquidity at the better of its limit and the last price
+			if (side == types.OrderSideBuy && lastPrice <= price) || (side == types.OrderSideSell && lastPrice >= price) {
+				err := e.fill(order, lastPrice)
+				return order.Clone(), err
+			}
+		}
+		e.resting = append(e.resting, order)
+		return order.Clone(), nil
+	default:
+		return e.reject(order), fmt.Errorf("order type %s is not supported by the paper exchange", orderType)
+	}
+}
+
+// reject marks an order as rejected and returns a snapshot of it. Callers must hold e.mu.
+func (e *Exchange) reject(order *types.Order) *types.Order {
+	order.Status = types.OrderStatusRejected
+	return order.Clone()
+}
+
+// checkFunds verifies there is enough balance not held by resting orders to settle an order at price.
+// Callers must hold e.mu.
+func (e *Exchange) checkFunds(side types.OrderSide, tradingPair string, amount, price float64) error {
+	asset, required := e.required(side, tradingPair, amount, price)
+	if available := e.available(asset); available < required {
+		return fmt.Errorf("insufficient %s balance: have %v available, need %v", asset, available, required)
+	}
+	return nil
+}
+
+// required returns the asset and amount needed to settle an order at price: the quote cost of a buy
+// including fees, or the base quantity of a sell.
+func (e *Exchange) required(side types.OrderSide, tradingPair string, amount, price float64) (string, float64) {
+	base, quote := types.SplitTradingPair(tradingPair)
+	if side == types.OrderSideBuy {
+		return quote, amount * price * (1 + e.feeRate)
+	}
+	return base, amount
+}
+
+// available returns the balance of an asset not held by resting orders. Callers must hold e.mu.
+func (e *Exchange) available(asset string) float64 {
+	available := e.balances[asset]
+	for _, order := range e.resting {
+		if held, amount := e.required(order.Side, order.TradingPair, order.Quantity, order.Price); held == asset {
+			available -= amount
+		}
+	}
+	return available
+}
+
+// fill settles the order's full quantity at price against the balances, or rejects it if it
+// cannot be funded. The order must not be in the book. Callers must hold e.mu.
+func (e *Exchange) fill(order *types.Order, price float64) error {
+	if err := e.checkFunds(order.Side, order.TradingPair, order.Quantity, price); err != nil {
+		order.Status = types.OrderStatusRejected
+		return err
+	}
+
+	base, quote := types.SplitTradingPair(order.TradingPair)
+	notional := order.Quantity * price
+	fee := notional * e.feeRate
+	if order.Side == types.OrderSideBuy {
+		e.balances[quote] -= notional + fee
+		e.balances[base] += order.Quantity
+	} else {
+		e.balances[base] -= order.Quantity
+		e.balances[quote] += notional - fee
+	}
+
+	timestamp := e.lastTimes[order.TradingPair]
+	order.AddFill(types.Fill{
+		TradeID:  fmt.Sprintf("%s-%d", order.ExchangeOrderID, len(order.Fills)+1),
+		Price:    price,
+		Quantity: order.Quantity,
+		Fee:      fee,
+		FeeAsset: quote,
+		Time:     timestamp,
+	})
+	e.fills = append(e.fills, Fill{
+		OrderID:     order.ExchangeOrderID,
+		Time:        timestamp,
+		TradingPair: order.TradingPair,
+		Side:        order.Side,
+		OrderType:   order.Type,
+		Amount:      order.Quantity,
+		Price:       price,
+		Fee:         fee,
+	})
+	return nil
+}
+
+// CancelOrder removes a resting order from the book, releasing the funds it held.
+func (e *Exchange) CancelOrder(tradingPair, orderID string) (*types.Order, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	order, err := e.removeResting(tradingPair, orderID)
+	if err != nil {
+		return nil, err
+	}
+	order.Status = types.OrderStatusCancelled
+	order.UpdatedAt = e.lastTimes[tradingPair]
+	return order.Clone(), nil
+}
+
+// AmendOrder cancels a resting order and places a replacement of the same type and side
+// with the new amount and price, returning the replacement. Both happen at once, so no price update
+// falls between them; if the replacement is rejected, the original order is left resting unchanged.
+func (e *Exchange) AmendOrder(tradingPair, orderID string, amount, price float64) (*types.Order, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	i, err := e.findResting(tradingPair, orderID)
+	if err != nil {
+		return nil, err
+	}
+	order := e.resting[i]
+	e.resting = append(e.resting[:i], e.resting[i+1:]...)
+
+	replacement, err := e.placeOrder(order.Type, order.Side, tradingPair, amount, price)
+	if err != nil {
+		e.resting = append(e.resting[:i], append([]*types.Order{order}, e.resting[i:]...)...)
+		return replacement, fmt.Errorf("failed to amend order %s: %w", orderID, err)
+	}
+	order.Status = types.OrderStatusCancelled
+	order.UpdatedAt = e.lastTimes[tradingPair]
+	return replacement, nil
+}
+
+// OpenOrders returns snapshots of the resting orders for a trading pair, oldest first.
+func (e *Exchange) OpenOrders(tradingPair string) []*types.Order {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var orders []*types.Order
+	for _, order := range e.resting {
+		if order.TradingPair == tradingPair {
+			orders = append(orders, order.Clone())
+		}
+	}
+	return orders
+}
+
+// removeResting takes an order out of the book. Callers must hold e.mu.
+func (e *Exchange) removeResting(tradingPair, orderID string) (*types.Order, error) {
+	i, err := e.findResting(tradingPair, orderID)
+	if err != nil {
+		return nil, err
+	}
+	order := e.resting[i]
+	e.resting = append(e.resting[:i], e.resting[i+1:]...)
+	return order, nil
+}
+
+// findResting returns the position of an order in the book. Callers must hold e.mu.
+func (e *Exchange) findResting(tradingPair, orderID string) (int, error) {
+	for i, order := range e.resting {
+		if order.ExchangeOrderID == orderID && order.TradingPair == tradingPair {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("order %s is not open on %s", orderID, tradingPair)
+}
+
+// Order returns a snapshot of an order by its exchange order ID.
+func (e *Exchange) Order(exchangeOrderID string) (*types.Order, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	order, ok := e.orders[exchangeOrderID]
+	if !ok {
+		return nil, false
+	}
+	return order.Clone(), true
+}
+
+// Balance returns the current balance of an asset, including the funds held by resting orders.
+func (e *Exchange) Balance(asset string) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.balances[asset]
+}
+
+// Available returns the balance of an asset that resting orders do not hold, which new orders can spend.
+func (e *Exchange) Available(asset string) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.available(asset)
+}
+
+// Balances returns a copy of all asset balances.
+func (e *Exchange) Balances() map[string]float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	balances := make(map[string]float64, len(e.balances))
+	for asset, amount := range e.balances {
+		balances[asset] = amount
+	}
+	return balances
+}
+
+// Fills returns every execution so far in the order they happened.
+func (e *Exchange) Fills() []Fill {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]Fill(nil), e.fills...)
+}
+
+// OpenOrderCount returns the number of orders resting in the book.
+func (e *Exchange) OpenOrderCount() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.resting)
+}
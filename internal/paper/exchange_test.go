@@ -0,0 +1,265 @@
+package paper
+
+import (
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"math"
+	"testing"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestExchange_MarketOrderWithFeesAndSlippage(t *testing.T) {
+	exchange := NewExchange(Config{
+		Balances: map[string]float64{"USDT": 1000},
+		FeeRate:  0.001,
+		Slippage: 0.01,
+	})
+
+	if _, err := exchange.ExecuteOrder(types.OrderTypeMarket, types.OrderSideBuy, "BTC/USDT", 1, 0); err == nil {
+		t.Fatal("Expected error before any price is known")
+	}
+
+	exchange.UpdatePrice("BTC/USDT", 100, 1)
+	order, err := exchange.ExecuteOrder(types.OrderTypeMarket, types.OrderSideBuy, "BTC/USDT", 2, 0)
+	if err != nil {
+		t.Fatalf("Expected market buy to fill, got %v", err)
+	}
+	if order.Status != types.OrderStatusFilled || !almostEqual(order.AveragePrice, 101) || order.FeeAsset != "USDT" {
+		t.Errorf("Unexpected market order %+v", order)
+	}
+
+	// Filled at 101 after 1% slippage, plus 0.1% fee on 202
+	if !almostEqual(exchange.Balance("USDT"), 1000-202-0.202) {
+		t.Errorf("Unexpected USDT balance %v", exchange.Balance("USDT"))
+	}
+	if exchange.Balance("BTC") != 2 {
+		t.Errorf("Expected BTC balance 2, got %v", exchange.Balance("BTC"))
+	}
+
+	if _, err := exchange.ExecuteOrder(types.OrderTypeMarket, types.OrderSideSell, "BTC/USDT", 3, 0); err == nil {
+		t.Error("Expected sell beyond the BTC balance to be rejected")
+	}
+	rejected, err := exchange.ExecuteOrder(types.OrderTypeMarket, types.OrderSideBuy, "BTC/USDT", 100, 0)
+	if err == nil || rejected.Status != types.OrderStatusRejected {
+		t.Errorf("Expected buy beyond the USDT balance to be rejected, got %+v", rejected)
+	}
+}
+
+func TestExchange_RestingOrders(t *testing.T) {
+	exchange := NewExchange(Config{Balances: map[string]float64{"USDT": 1000, "BTC": 2}})
+	exchange.UpdatePrice("BTC/USDT", 100, 1)
+
+	limitOrder, err := exchange.ExecuteOrder(types.OrderTypeLimit, types.OrderSideBuy, "BTC/USDT", 1, 95)
+	if err != nil {
+		t.Fatalf("Expected limit buy to rest, got %v", err)
+	}
+	if limitOrder.Status != types.OrderStatusNew || limitOrder.ExchangeOrderID == "" {
+		t.Errorf("Expected a new resting order with an ID, got %+v", limitOrder)
+	}
+	if _, err := exchange.ExecuteOrder(types.OrderTypeStopLoss, types.OrderSideSell, "BTC/USDT", 1, 90); err != nil {
+		t.Fatalf("Expected stop-loss to rest, got %v", err)
+	}
+	if _, err := exchange.ExecuteOrder(types.OrderTypeTakeProfit, types.OrderSideSell, "BTC/USDT", 1, 120); err != nil {
+		t.Fatalf("Expected take-profit to rest, got %v", err)
+	}
+	if _, err := exchange.ExecuteOrder(types.OrderTypeTrailingStop, types.OrderSideSell, "BTC/USDT", 1, 120); err == nil {
+		t.Error("Expected unsupported order type to be rejected")
+	}
+	if exchange.OpenOrderCount() != 3 {
+		t.Fatalf("Expected 3 resting orders, got %v", exchange.OpenOrderCount())
+	}
+
+	// Ticks on another pair do not touch the book
+	exchange.UpdatePrice("ETH/USDT", 50, 2)
+	if len(exchange.Fills()) != 0 {
+		t.Fatal("Expected no fills from another pair")
+	}
+
+	// Dropping to 94 fills the limit buy at its limit price
+	exchange.UpdatePrice("BTC/USDT", 94, 3)
+	fills := exchange.Fills()
+	if len(fills) != 1 || fills[0].Price != 95 || fills[0].Time != 3 {
+		t.Fatalf("Expected limit buy filled at 95, got %+v", fills)
+	}
+	if filled, ok := exchange.Order(limitOrder.ExchangeOrderID); !ok || filled.Status != types.OrderStatusFilled || filled.AveragePrice != 95 {
+		t.Errorf("Expected the resting order to be marked filled, got %+v", filled)
+	}
+
+	// Dropping to 89 triggers the stop-loss
+	exchange.UpdatePrice("BTC/USDT", 89, 4)
+	// Rising to 121 triggers the take-profit
+	exchange.UpdatePrice("BTC/USDT", 121, 5)
+
+	fills = exchange.Fills()
+	if len(fills) != 3 {
+		t.Fatalf("Expected 3 fills, got %+v", fills)
+	}
+	if fills[1].OrderType != types.OrderTypeStopLoss || fills[1].Price != 89 {
+		t.Errorf("Expected stop-loss fill at 89, got %+v", fills[1])
+	}
+	if fills[2].OrderType != types.OrderTypeTakeProfit || fills[2].Price != 121 {
+		t.Errorf("Expected take-profit fill at 121, got %+v", fills[2])
+	}
+	if exchange.OpenOrderCount() != 0 {
+		t.Errorf("Expected empty book, got %v resting orders", exchange.OpenOrderCount())
+	}
+
+	balances := exchange.Balances()
+	if balances["BTC"] != 1 || !almostEqual(balances["USDT"], 1000-95+89+121) {
+		t.Errorf("Unexpected balances %v", balances)
+	}
+}
+
+func TestExchange_MarketableLimitOrder(t *testing.T) {
+	exchange := NewExchange(Config{Balances: map[string]float64{"USDT": 1000}})
+	exchange.UpdatePrice("BTC/USDT", 100, 1)
+
+	if _, err := exchange.ExecuteOrder(types.OrderTypeLimit, types.OrderSideBuy, "BTC/USDT", 1, 105); err != nil {
+		t.Fatalf("Expected limit buy to fill, got %v", err)
+	}
+	fills := exchange.Fills()
+	if len(fills) != 1 || fills[0].Price != 100 {
+		t.Errorf("Expected marketable limit to fill at the last price, got %+v", fills)
+	}
+}
+
+func TestExchange_CancelAndAmend(t *testing.T) {
+	exchange := NewExchange(Config{Balances: map[string]float64{"USDT": 1000}})
+	exchange.UpdatePrice("BTC/USDT", 100, 1)
+
+	first, _ := exchange.ExecuteOrder(types.OrderTypeLimit, types.OrderSideBuy, "BTC/USDT", 1, 90)
+	second, _ := exchange.ExecuteOrder(types.OrderTypeLimit, types.OrderSideBuy, "BTC/USDT", 1, 80)
+
+	open := exchange.OpenOrders("BTC/USDT")
+	if len(open) != 2 || open[0].ExchangeOrderID != first.ExchangeOrderID {
+		t.Fatalf("Expected both orders open oldest first, got %+v", open)
+	}
+
+	cancelled, err := exchange.CancelOrder("BTC/USDT", first.ExchangeOrderID)
+	if err != nil || cancelled.Status != types.OrderStatusCancelled {
+		t.Fatalf("Expected order to be cancelled, got %+v, err %v", cancelled, err)
+	}
+	if _, err := exchange.CancelOrder("BTC/USDT", first.ExchangeOrderID); err == nil {
+		t.Error("Expected cancelling twice to fail")
+	}
+
+	replacement, err := exchange.AmendOrder("BTC/USDT", second.ExchangeOrderID, 2, 95)
+	if err != nil {
+		t.Fatalf("Expected amend to succeed, got %v", err)
+	}
+	if replacement.ExchangeOrderID == second.ExchangeOrderID || replacement.Quantity != 2 || replacement.Type != types.OrderTypeLimit {
+		t.Errorf("Unexpected replacement %+v", replacement)
+	}
+	if original, _ := exchange.Order(second.ExchangeOrderID); original.Status != types.OrderStatusCancelled {
+		t.Errorf("Expected the original order to be cancelled, got %v", original.Status)
+	}
+
+	// Only the replacement is left and it fills at its new price
+	exchange.UpdatePrice("BTC/USDT", 95, 2)
+	if len(exchange.OpenOrders("BTC/USDT")) != 0 || exchange.Balance("BTC") != 2 {
+		t.Errorf("Expected the replacement to fill, balances %v", exchange.Balances())
+	}
+}
+
+func TestExchange_AmendRejectedKeepsOriginal(t *testing.T) {
+	exchange := NewExchange(Config{Balances: map[string]float64{"USDT": 1000}})
+	exchange.UpdatePrice("BTC/USDT", 100, 1)
+
+	first, _ := exchange.ExecuteOrder(types.OrderTypeLimit, types.OrderSideBuy, "BTC/USDT", 1, 90)
+	second, _ := exchange.ExecuteOrder(types.OrderTypeLimit, types.OrderSideBuy, "BTC/USDT", 1, 80)
+
+	// The replacement cannot be funded, so the original keeps resting in its place
+	if _, err := exchange.AmendOrder("BTC/USDT", first.ExchangeOrderID, 20, 90); err == nil {
+		t.Fatal("Expected an unfunded amendment to fail")
+	}
+	if _, err := exchange.AmendOrder("BTC/USDT", first.ExchangeOrderID, 0, 90); err == nil {
+		t.Fatal("Expected an amendment without an amount to fail")
+	}
+	open := exchange.OpenOrders("BTC/USDT")
+	if len(open) != 2 || open[0].ExchangeOrderID != first.ExchangeOrderID || open[1].ExchangeOrderID != second.ExchangeOrderID {
+		t.Fatalf("Expected both original orders to rest, got %+v", open)
+	}
+	if original, _ := exchange.Order(first.ExchangeOrderID); original.Status != types.OrderStatusNew {
+		t.Errorf("Expected the original order to stay open, got %v", original.Status)
+	}
+
+	exchange.UpdatePrice("BTC/USDT", 90, 2)
+	if exchange.Balance("BTC") != 1 {
+		t.Errorf("Expected the original order to fill, balances %v", exchange.Balances())
+	}
+}
+
+func TestExchange_OrderUpdates(t *testing.T) {
+	exchange := NewExchange(Config{Balances: map[string]float64{"USDT": 1000}})
+	exchange.UpdatePrice("BTC/USDT", 100, 1)
+
+	var updates []*types.Order
+	exchange.OnOrderUpdate(func(order *types.Order) {
+		updates = append(updates, order)
+	})
+	order, _ := exchange.ExecuteOrder(types.OrderTypeLimit, types.OrderSideBuy, "BTC/USDT", 1, 95)
+	if len(updates) != 0 {
+		t.Fatalf("Expected no update for an order returned to the caller, got %+v", updates)
+	}
+
+	exchange.UpdatePrice("BTC/USDT", 97, 2)
+	exchange.UpdatePrice("BTC/USDT", 94, 3)
+	if len(updates) != 1 {
+		t.Fatalf("Expected a single update for the fill, got %+v", updates)
+	}
+	if updates[0].ExchangeOrderID != order.ExchangeOrderID || updates[0].Status != types.OrderStatusFilled || updates[0].UpdatedAt != 3 {
+		t.Errorf("Expected the resting order to be reported filled, got %+v", updates[0])
+	}
+}
+
+func TestExchange_RestingOrdersHoldFunds(t *testing.T) {
+	exchange := NewExchange(Config{Balances: map[string]float64{"USDT": 1000, "BTC": 1}})
+	exchange.UpdatePrice("BTC/USDT", 700, 1)
+
+	first, err := exchange.ExecuteOrder(types.OrderTypeLimit, types.OrderSideBuy, "BTC/USDT", 1, 600)
+	if err != nil {
+		t.Fatalf("Expected limit buy to rest, got %v", err)
+	}
+	if exchange.Available("USDT") != 400 || exchange.Balance("USDT") != 1000 {
+		t.Errorf("Expected 600 USDT to be held of 1000, got %v available", exchange.Available("USDT"))
+	}
+	if _, err := exchange.ExecuteOrder(types.OrderTypeLimit, types.OrderSideBuy, "BTC/USDT", 1, 500); err == nil {
+		t.Error("Expected a second buy spending the held USDT to be rejected")
+	}
+	if _, err := exchange.ExecuteOrder(types.OrderTypeLimit, types.OrderSideSell, "BTC/USDT", 1, 800); err != nil {
+		t.Fatalf("Expected limit sell to rest, got %v", err)
+	}
+	if _, err := exchange.ExecuteOrder(types.OrderTypeMarket, types.OrderSideSell, "BTC/USDT", 1, 0); err == nil {
+		t.Error("Expected a market sell of the held BTC to be rejected")
+	}
+
+	// Amending releases the original's funds to the replacement
+	amended, err := exchange.AmendOrder("BTC/USDT", first.ExchangeOrderID, 2, 450)
+	if err != nil {
+		t.Fatalf("Expected amend to succeed, got %v", err)
+	}
+	if exchange.Available("USDT") != 100 {
+		t.Errorf("Expected 900 USDT to be held by the replacement, got %v available", exchange.Available("USDT"))
+	}
+	if _, err := exchange.AmendOrder("BTC/USDT", amended.ExchangeOrderID, 3, 450); err == nil {
+		t.Error("Expected an amendment beyond the balance to be rejected")
+	}
+	if exchange.Available("USDT") != 100 {
+		t.Errorf("Expected the rejected amendment to keep the original's funds held, got %v available", exchange.Available("USDT"))
+	}
+
+	if _, err := exchange.CancelOrder("BTC/USDT", amended.ExchangeOrderID); err != nil {
+		t.Fatalf("Expected cancel to succeed, got %v", err)
+	}
+	if exchange.Available("USDT") != 1000 {
+		t.Errorf("Expected cancelling to release the held USDT, got %v available", exchange.Available("USDT"))
+	}
+
+	// A resting order spends its own held funds when it fills
+	exchange.UpdatePrice("BTC/USDT", 800, 2)
+	if exchange.Balance("BTC") != 0 || exchange.Available("BTC") != 0 || exchange.Balance("USDT") != 1800 {
+		t.Errorf("Expected the held BTC to be sold at 800, got %v", exchange.Balances())
+	}
+}
@@ -0,0 +1,213 @@
+package portfolio
+
+import (
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"math"
+	"sort"
+	"sync"
+)
+
+// appliedOrder records how much of an order's execution has already been applied, so the same
+// order can be passed in again after it has filled further.
+type appliedOrder struct {
+	fills    int
+	quantity float64
+	value    float64
+	fee      float64
+}
+
+// Tracker implements types.Portfolio, keeping asset balances and per market/trading pair positions
+// up to date from the fills of the orders it is given.
+type Tracker struct {
+	mu        sync.RWMutex
+	balances  map[string]float64
+	positions map[string]map[string]*types.Position
+	applied   map[string]*appliedOrder
+}
+
+// NewTracker initializes a Tracker with the given starting balances per asset.
+func NewTracker(balances map[string]float64) *Tracker {
+	t := &Tracker{
+		balances:  make(map[string]float64, len(balances)),
+		positions: make(map[string]map[string]*types.Position),
+		applied:   make(map[string]*appliedOrder),
+	}
+	for asset, amount := range balances {
+		t.balances[asset] = amount
+	}
+	return t
+}
+
+// SetBalance overrides the balance of an asset, e.g. after syncing with an exchange account.
+func (t *Tracker) SetBalance(asset string, amount float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.balances[asset] = amount
+}
+
+// Balance returns the current balance of an asset.
+func (t *Tracker) Balance(asset string) float64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.balances[asset]
+}
+
+// Balances returns a copy of all asset balances.
+func (t *Tracker) Balances() map[string]float64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	balances := make(map[string]float64, len(t.balances))
+	for asset, amount := range t.balances {
+		balances[asset] = amount
+	}
+	return balances
+}
+
+// Position returns the position on a market and trading pair, which is flat if nothing has been traded.
+func (t *Tracker) Position(marketName, tradingPair string) types.Position {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if position, ok := t.positions[marketName][tradingPair]; ok {
+		return *position
+	}
+	return types.Position{MarketName: marketName, TradingPair: tradingPair}
+}
+
+// Positions returns every position that has been traded, sorted by market and trading pair.
+func (t *Tracker) Positions() []types.Position {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var positions []types.Position
+	for _, pairs := range t.positions {
+		for _, position := range pairs {
+			positions = append(positions, *position)
+		}
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].MarketName != positions[j].MarketName {
+			return positions[i].MarketName < positions[j].MarketName
+		}
+		return positions[i].TradingPair < positions[j].TradingPair
+	})
+	return positions
+}
+
+// MarkPrice revalues the position on a market and trading pair at the latest price.
+func (t *Tracker) MarkPrice(marketName, tradingPair string, price float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if position, ok := t.positions[marketName][tradingPair]; ok {
+		position.LastPrice = price
+		position.UnrealizedPnL = (price - position.AverageEntryPrice) * position.Quantity
+	}
+}
+
+// ApplyOrder applies any fills of the order that have not been applied yet. Orders that report a
+// filled quantity without individual fills are applied as a single fill at their average price.
+func (t *Tracker) ApplyOrder(marketName string, order *types.Order) {
+	if order == nil || order.FilledQuantity <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := order.ClientOrderID + "/" + order.ExchangeOrderID
+	applied, ok := t.applied[key]
+	if !ok {
+		applied = &appliedOrder{}
+		t.applied[key] = applied
+	}
+
+	if len(order.Fills) > applied.fills {
+		for _, fill := range order.Fills[applied.fills:] {
+			t.applyFill(marketName, order.TradingPair, order.Side, fill)
+			applied.quantity += fill.Quantity
+			applied.value += fill.Price * fill.Quantity
+			applied.fee += fill.Fee
+		}
+		applied.fills = len(order.Fills)
+		return
+	}
+
+	quantity := order.FilledQuantity - applied.quantity
+	if len(order.Fills) > 0 || quantity <= 0 {
+		return
+	}
+	value := order.AveragePrice*order.FilledQuantity - applied.value
+	fill := types.Fill{
+		Price:    value / quantity,
+		Quantity: quantity,
+		Fee:      order.Fee - applied.fee,
+		FeeAsset: order.FeeAsset,
+		Time:     order.UpdatedAt,
+	}
+	t.applyFill(marketName, order.TradingPair, order.Side, fill)
+	applied.quantity += fill.Quantity
+	applied.value += value
+	applied.fee += fill.Fee
+}
+
+// applyFill settles a fill against the balances and the position. Callers must hold t.mu.
+func (t *Tracker) applyFill(marketName, tradingPair string, side types.OrderSide, fill types.Fill) {
+	base, quote := types.SplitTradingPair(tradingPair)
+	signed := fill.Quantity
+	if side == types.OrderSideSell {
+		signed = -fill.Quantity
+	}
+	t.balances[base] += signed
+	t.balances[quote] -= signed * fill.Price
+
+	feeAsset := fill.FeeAsset
+	if feeAsset == "" {
+		feeAsset = quote
+	}
+	t.balances[feeAsset] -= fill.Fee
+
+	position := t.position(marketName, tradingPair)
+	if feeAsset == quote {
+		position.Fees += fill.Fee
+	} else if feeAsset == base {
+		// Fees taken from the base asset reduce the quantity received
+		signed -= fill.Fee
+		position.Fees += fill.Fee * fill.Price
+	}
+
+	switch {
+	case position.Quantity == 0 || (position.Quantity > 0) == (signed > 0):
+		// Opening or adding to the position moves the average entry price
+		total := position.Quantity + signed
+		position.AverageEntryPrice = (position.AverageEntryPrice*position.Quantity + fill.Price*signed) / total
+		position.Quantity = total
+	default:
+		// Reducing the position realizes PnL on the closed quantity, and any excess opens the other way
+		closed := math.Min(math.Abs(signed), math.Abs(position.Quantity))
+		direction := 1.0
+		if position.Quantity < 0 {
+			direction = -1
+		}
+		position.RealizedPnL += (fill.Price - position.AverageEntryPrice) * closed * direction
+		position.Quantity += signed
+		if position.IsFlat() {
+			position.Quantity = 0
+			position.AverageEntryPrice = 0
+		} else if (position.Quantity > 0) != (direction > 0) {
+			position.AverageEntryPrice = fill.Price
+		}
+	}
+
+	position.LastPrice = fill.Price
+	position.UnrealizedPnL = (fill.Price - position.AverageEntryPrice) * position.Quantity
+}
+
+// position returns the position for a market and trading pair, creating it if needed. Callers must hold t.mu.
+func (t *Tracker) position(marketName, tradingPair string) *types.Position {
+	if t.positions[marketName] == nil {
+		t.positions[marketName] = make(map[string]*types.Position)
+	}
+	position, ok := t.positions[marketName][tradingPair]
+	if !ok {
+		position = &types.Position{MarketName: marketName, TradingPair: tradingPair}
+		t.positions[marketName][tradingPair] = position
+	}
+	return position
+}
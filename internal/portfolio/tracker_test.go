@@ -0,0 +1,89 @@
+package portfolio
+
+import (
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"math"
+	"testing"
+)
+
+func filledOrder(id string, side types.OrderSide, quantity, price, fee float64) *types.Order {
+	order := &types.Order{ClientOrderID: id, TradingPair: "BTC/USDT", Side: side, Quantity: quantity}
+	order.AddFill(types.Fill{Price: price, Quantity: quantity, Fee: fee, FeeAsset: "USDT"})
+	return order
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestTracker_ApplyOrder(t *testing.T) {
+	tracker := NewTracker(map[string]float64{"USDT": 1000})
+
+	tracker.ApplyOrder("Binance", filledOrder("1", types.OrderSideBuy, 1, 100, 0.1))
+	tracker.ApplyOrder("Binance", filledOrder("2", types.OrderSideBuy, 1, 110, 0.1))
+
+	position := tracker.Position("Binance", "BTC/USDT")
+	if !position.IsLong() || position.Quantity != 2 || position.AverageEntryPrice != 105 {
+		t.Fatalf("Expected long 2 at 105, got %+v", position)
+	}
+	if !almostEqual(tracker.Balance("USDT"), 1000-210-0.2) || tracker.Balance("BTC") != 2 {
+		t.Errorf("Unexpected balances %v", tracker.Balances())
+	}
+
+	tracker.MarkPrice("Binance", "BTC/USDT", 120)
+	if position := tracker.Position("Binance", "BTC/USDT"); position.UnrealizedPnL != 30 {
+		t.Errorf("Expected unrealized PnL 30, got %v", position.UnrealizedPnL)
+	}
+
+	// Selling 3 closes the long at a profit and opens a short of 1 at the sale price
+	tracker.ApplyOrder("Binance", filledOrder("3", types.OrderSideSell, 3, 115, 0))
+	position = tracker.Position("Binance", "BTC/USDT")
+	if !position.IsShort() || position.Quantity != -1 || position.AverageEntryPrice != 115 {
+		t.Fatalf("Expected short 1 at 115, got %+v", position)
+	}
+	if position.RealizedPnL != 20 || !almostEqual(position.Fees, 0.2) {
+		t.Errorf("Expected realized PnL 20 and fees 0.2, got %+v", position)
+	}
+
+	// Buying back closes the short at a loss
+	tracker.ApplyOrder("Binance", filledOrder("4", types.OrderSideBuy, 1, 118, 0))
+	position = tracker.Position("Binance", "BTC/USDT")
+	if !position.IsFlat() || position.RealizedPnL != 17 || position.UnrealizedPnL != 0 {
+		t.Errorf("Expected flat with realized PnL 17, got %+v", position)
+	}
+
+	if other := tracker.Position("Kraken", "BTC/USDT"); !other.IsFlat() {
+		t.Errorf("Expected positions to be tracked per market, got %+v", other)
+	}
+}
+
+func TestTracker_ApplyOrderIncrementally(t *testing.T) {
+	tracker := NewTracker(nil)
+
+	// An order reported again after filling further only applies the new fills
+	order := &types.Order{ClientOrderID: "1", ExchangeOrderID: "9", TradingPair: "ETH/USDT", Side: types.OrderSideBuy, Quantity: 2}
+	order.AddFill(types.Fill{Price: 10, Quantity: 1})
+	tracker.ApplyOrder("Paper", order)
+	tracker.ApplyOrder("Paper", order)
+	order.AddFill(types.Fill{Price: 12, Quantity: 1})
+	tracker.ApplyOrder("Paper", order)
+
+	position := tracker.Position("Paper", "ETH/USDT")
+	if position.Quantity != 2 || position.AverageEntryPrice != 11 {
+		t.Errorf("Expected 2 at 11, got %+v", position)
+	}
+
+	// Orders without individual fills are applied from their filled quantity and average price
+	summary := &types.Order{ClientOrderID: "2", TradingPair: "ETH/USDT", Side: types.OrderSideSell, Quantity: 2, FilledQuantity: 1, AveragePrice: 14}
+	tracker.ApplyOrder("Paper", summary)
+	summary.FilledQuantity, summary.AveragePrice = 2, 15
+	tracker.ApplyOrder("Paper", summary)
+
+	position = tracker.Position("Paper", "ETH/USDT")
+	if !position.IsFlat() || position.RealizedPnL != 8 {
+		t.Errorf("Expected flat with realized PnL 8, got %+v", position)
+	}
+	if tracker.Balance("USDT") != 8 || len(tracker.Positions()) != 1 {
+		t.Errorf("Unexpected balances %v or positions %v", tracker.Balances(), tracker.Positions())
+	}
+}
@@ -0,0 +1,161 @@
+package risk
+
+import (
+	"fmt"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limits configures the pre-trade risk checks. A zero value disables the corresponding check.
+type Limits struct {
+	MaxPositionSize    float64 // Largest absolute position per market and trading pair, in the base asset
+	MaxOrderNotional   float64 // Largest order value (amount * price), in the quote asset
+	MaxOrdersPerMinute int     // Most orders accepted in any rolling minute across all markets
+	MaxDailyLoss       float64 // Largest loss since the start of the UTC day, summed over all positions net of fees
+}
+
+// Engine runs pre-trade risk checks against the portfolio before orders reach an exchange.
+type Engine struct {
+	mu          sync.Mutex
+	limits      Limits
+	portfolio   types.Portfolio
+	orderTimes  []time.Time // Times of orders accepted in the last minute
+	halted      bool
+	haltReason  string
+	day         string  // UTC date the daily loss is measured from
+	dayStartPnL float64 // Total PnL at the start of day
+	now         func() time.Time
+}
+
+// NewEngine initializes a risk engine with the given limits, reading positions from portfolio.
+func NewEngine(limits Limits, portfolio types.Portfolio) *Engine {
+	e := &Engine{
+		limits:    limits,
+		portfolio: portfolio,
+		now:       time.Now,
+	}
+	e.dailyLoss(e.now()) // Measure today's loss from the portfolio as it is now
+	return e
+}
+
+// SetLimits replaces the risk limits.
+func (e *Engine) SetLimits(limits Limits) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.limits = limits
+}
+
+// Halt engages the kill switch, rejecting every order until Resume is called.
+func (e *Engine) Halt(reason string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.halted = true
+	e.haltReason = reason
+}
+
+// Resume releases the kill switch.
+func (e *Engine) Resume() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.halted = false
+	e.haltReason = ""
+}
+
+// Halted reports whether the kill switch is engaged and why.
+func (e *Engine) Halted() (bool, string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.halted, e.haltReason
+}
+
+// CheckOrder runs every risk check against an order about to be placed and returns a
+// *types.RiskRejectionError for the first one that fails. Accepted orders count towards
+// the orders per minute limit. price is the order price, or the market price for market orders.
+func (e *Engine) CheckOrder(marketName, tradingPair string, side types.OrderSide, amount, price float64) error {
+	return e.CheckOrderAt(time.Time{}, marketName, tradingPair, side, amount, price)
+}
+
+// CheckOrderAt runs the checks of CheckOrder at the given time, e.g. the time of the tick placing the order,
+// so the orders per minute and the daily loss follow the market's clock when ticks are replayed. A zero time
+// is the current time.
+func (e *Engine) CheckOrderAt(at time.Time, marketName, tradingPair string, side types.OrderSide, amount, price float64) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := at
+	if now.IsZero() {
+		now = e.now()
+	}
+
+	if e.halted {
+		return &types.RiskRejectionError{Rule: types.RiskRuleKillSwitch, Reason: fmt.Sprintf("trading is halted: %s", e.haltReason)}
+	}
+
+	if e.limits.MaxOrderNotional > 0 {
+		if notional := amount * price; notional > e.limits.MaxOrderNotional {
+			return &types.RiskRejectionError{
+				Rule:   types.RiskRuleMaxOrderNotional,
+				Reason: fmt.Sprintf("order notional %v exceeds %v", notional, e.limits.MaxOrderNotional),
+			}
+		}
+	}
+
+	current := e.portfolio.Position(marketName, tradingPair).Quantity
+	projected := current + amount
+	if side == types.OrderSideSell {
+		projected = current - amount
+	}
+	reducing := math.Abs(projected) <= math.Abs(current)
+
+	if e.limits.MaxPositionSize > 0 && !reducing && math.Abs(projected) > e.limits.MaxPositionSize {
+		return &types.RiskRejectionError{
+			Rule:   types.RiskRuleMaxPositionSize,
+			Reason: fmt.Sprintf("position on %s %s would be %v, above %v", marketName, tradingPair, projected, e.limits.MaxPositionSize),
+		}
+	}
+
+	if e.limits.MaxDailyLoss > 0 && !reducing {
+		if loss := e.dailyLoss(now); loss >= e.limits.MaxDailyLoss {
+			return &types.RiskRejectionError{
+				Rule:   types.RiskRuleDailyLossLimit,
+				Reason: fmt.Sprintf("daily loss %v has reached %v; only orders reducing a position are allowed", loss, e.limits.MaxDailyLoss),
+			}
+		}
+	}
+
+	if e.limits.MaxOrdersPerMinute > 0 {
+		// Tick times of different markets need not arrive in order, so every time is checked
+		cutoff := now.Add(-time.Minute)
+		recent := e.orderTimes[:0]
+		for _, orderTime := range e.orderTimes {
+			if orderTime.After(cutoff) {
+				recent = append(recent, orderTime)
+			}
+		}
+		e.orderTimes = recent
+		if len(e.orderTimes) >= e.limits.MaxOrdersPerMinute {
+			return &types.RiskRejectionError{
+				Rule:   types.RiskRuleMaxOrdersPerMinute,
+				Reason: fmt.Sprintf("%d orders already placed in the last minute", len(e.orderTimes)),
+			}
+		}
+		e.orderTimes = append(e.orderTimes, now)
+	}
+	return nil
+}
+
+// dailyLoss returns the loss since the start of the UTC day of now, resetting the baseline when the day changes.
+// Callers must hold e.mu.
+func (e *Engine) dailyLoss(now time.Time) float64 {
+	total := 0.0
+	for _, position := range e.portfolio.Positions() {
+		total += position.RealizedPnL + position.UnrealizedPnL - position.Fees
+	}
+	if day := now.UTC().Format("2006-01-02"); day != e.day {
+		e.day = day
+		e.dayStartPnL = total
+	}
+	return e.dayStartPnL - total
+}
@@ -0,0 +1,116 @@
+package risk
+
+import (
+	"errors"
+	"github.com/bigmeech/tradingbot/internal/portfolio"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"testing"
+	"time"
+)
+
+func buy(tracker *portfolio.Tracker, id string, quantity, price float64) {
+	order := &types.Order{ClientOrderID: id, TradingPair: "BTC/USDT", Side: types.OrderSideBuy, Quantity: quantity}
+	order.AddFill(types.Fill{Price: price, Quantity: quantity})
+	tracker.ApplyOrder("Binance", order)
+}
+
+func expectRule(t *testing.T, err error, rule types.RiskRule) {
+	t.Helper()
+	var rejection *types.RiskRejectionError
+	if !errors.As(err, &rejection) || rejection.Rule != rule {
+		t.Errorf("Expected rejection by %s, got %v", rule, err)
+	}
+}
+
+func TestEngine_CheckOrder(t *testing.T) {
+	tracker := portfolio.NewTracker(nil)
+	engine := NewEngine(Limits{MaxPositionSize: 2, MaxOrderNotional: 1000}, tracker)
+
+	if err := engine.CheckOrder("Binance", "BTC/USDT", types.OrderSideBuy, 2, 100); err != nil {
+		t.Fatalf("Expected order within limits to pass, got %v", err)
+	}
+	expectRule(t, engine.CheckOrder("Binance", "BTC/USDT", types.OrderSideBuy, 11, 100), types.RiskRuleMaxOrderNotional)
+	expectRule(t, engine.CheckOrder("Binance", "BTC/USDT", types.OrderSideBuy, 3, 100), types.RiskRuleMaxPositionSize)
+
+	buy(tracker, "1", 2, 100)
+	expectRule(t, engine.CheckOrder("Binance", "BTC/USDT", types.OrderSideBuy, 1, 100), types.RiskRuleMaxPositionSize)
+	if err := engine.CheckOrder("Binance", "BTC/USDT", types.OrderSideSell, 2, 100); err != nil {
+		t.Errorf("Expected an order reducing the position to pass, got %v", err)
+	}
+	if err := engine.CheckOrder("Kraken", "BTC/USDT", types.OrderSideBuy, 2, 100); err != nil {
+		t.Errorf("Expected positions to be limited per market, got %v", err)
+	}
+
+	engine.Halt("manual")
+	expectRule(t, engine.CheckOrder("Binance", "BTC/USDT", types.OrderSideSell, 1, 100), types.RiskRuleKillSwitch)
+	if halted, reason := engine.Halted(); !halted || reason != "manual" {
+		t.Errorf("Expected engine to be halted for manual, got %v %q", halted, reason)
+	}
+	engine.Resume()
+	if err := engine.CheckOrder("Binance", "BTC/USDT", types.OrderSideSell, 1, 100); err != nil {
+		t.Errorf("Expected orders to pass after resuming, got %v", err)
+	}
+}
+
+func TestEngine_OrdersPerMinute(t *testing.T) {
+	engine := NewEngine(Limits{MaxOrdersPerMinute: 2}, portfolio.NewTracker(nil))
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	engine.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if err := engine.CheckOrder("Binance", "BTC/USDT", types.OrderSideBuy, 0.1, 100); err != nil {
+			t.Fatalf("Expected order %d to pass, got %v", i+1, err)
+		}
+		now = now.Add(10 * time.Second)
+	}
+	expectRule(t, engine.CheckOrder("Binance", "BTC/USDT", types.OrderSideBuy, 0.1, 100), types.RiskRuleMaxOrdersPerMinute)
+
+	// Once the first order is a minute old there is room again
+	now = now.Add(40 * time.Second)
+	if err := engine.CheckOrder("Binance", "BTC/USDT", types.OrderSideBuy, 0.1, 100); err != nil {
+		t.Errorf("Expected order to pass after the window moved, got %v", err)
+	}
+}
+
+func TestEngine_DailyLossLimit(t *testing.T) {
+	tracker := portfolio.NewTracker(nil)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	engine := NewEngine(Limits{MaxDailyLoss: 50}, tracker)
+	engine.now = func() time.Time { return now }
+	engine.dailyLoss(now) // Start the day on the test clock
+
+	buy(tracker, "1", 1, 100)
+	tracker.MarkPrice("Binance", "BTC/USDT", 40)
+	expectRule(t, engine.CheckOrder("Binance", "BTC/USDT", types.OrderSideBuy, 1, 40), types.RiskRuleDailyLossLimit)
+	if err := engine.CheckOrder("Binance", "BTC/USDT", types.OrderSideSell, 1, 40); err != nil {
+		t.Errorf("Expected closing the losing position to be allowed, got %v", err)
+	}
+
+	// The loss is measured from the start of each day
+	now = now.Add(24 * time.Hour)
+	if err := engine.CheckOrder("Binance", "BTC/USDT", types.OrderSideBuy, 1, 40); err != nil {
+		t.Errorf("Expected the limit to reset on a new day, got %v", err)
+	}
+}
+
+func TestEngine_CheckOrderAt(t *testing.T) {
+	tracker := portfolio.NewTracker(nil)
+	engine := NewEngine(Limits{MaxDailyLoss: 50, MaxOrdersPerMinute: 1}, tracker)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	engine.now = func() time.Time { return now }
+
+	// Replayed ticks a day in the past count orders and measure the loss on their own clock
+	tickTime := now.Add(-24 * time.Hour)
+	if err := engine.CheckOrderAt(tickTime, "Binance", "BTC/USDT", types.OrderSideBuy, 1, 100); err != nil {
+		t.Fatalf("Expected the first order to pass, got %v", err)
+	}
+	buy(tracker, "1", 1, 100)
+	tracker.MarkPrice("Binance", "BTC/USDT", 40)
+	expectRule(t, engine.CheckOrderAt(tickTime.Add(30*time.Second), "Binance", "BTC/USDT", types.OrderSideSell, 1, 40), types.RiskRuleMaxOrdersPerMinute)
+
+	tickTime = tickTime.Add(2 * time.Minute)
+	expectRule(t, engine.CheckOrderAt(tickTime, "Binance", "BTC/USDT", types.OrderSideBuy, 1, 40), types.RiskRuleDailyLossLimit)
+	if err := engine.CheckOrderAt(tickTime.Add(24*time.Hour), "Binance", "BTC/USDT", types.OrderSideBuy, 1, 40); err != nil {
+		t.Errorf("Expected the limit to reset on the next day of the ticks, got %v", err)
+	}
+}
@@ -38,7 +38,7 @@ func (cb *CircularBuffer) GetData(count int) []types.MarketData {
 		if count > cb.index {
 			count = cb.index
 		}
-		return cb.data[:count]
+		return cb.data[cb.index-count : cb.index]
 	}
 
 	// For a full buffer, return the most recent `count` data points in correct order
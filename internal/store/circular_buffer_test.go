@@ -0,0 +1,32 @@
+package store
+
+import (
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"testing"
+)
+
+func TestCircularBuffer_GetData(t *testing.T) {
+	cb := NewCircularBuffer(3)
+
+	// Partially filled buffer should return the most recent entries
+	cb.Add(types.MarketData{Price: 100.0})
+	cb.Add(types.MarketData{Price: 200.0})
+	data := cb.GetData(1)
+	if len(data) != 1 || data[0].Price != 200.0 {
+		t.Fatalf("Expected [200], got %v", data)
+	}
+
+	// Wrapped buffer should return the most recent entries in chronological order
+	cb.Add(types.MarketData{Price: 300.0})
+	cb.Add(types.MarketData{Price: 400.0})
+	data = cb.GetData(3)
+	expected := []float64{200.0, 300.0, 400.0}
+	if len(data) != len(expected) {
+		t.Fatalf("Expected %v entries, got %v", len(expected), len(data))
+	}
+	for i, entry := range data {
+		if entry.Price != expected[i] {
+			t.Errorf("Expected price %v at index %d, got %v", expected[i], i, entry.Price)
+		}
+	}
+}
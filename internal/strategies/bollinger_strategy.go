@@ -0,0 +1,137 @@
+package strategies
+
+import (
+	"fmt"
+	"github.com/bigmeech/tradingbot/internal/indicators"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"strings"
+	"time"
+)
+
+// BollingerMode selects how a BollingerStrategy trades the bands.
+type BollingerMode string
+
+const (
+	// BollingerBreakout buys when the price closes above the upper band and sells when it falls back below the middle band.
+	BollingerBreakout BollingerMode = "breakout"
+
+	// BollingerMeanReversion buys when the price closes below the lower band and sells when it reverts to the middle band.
+	BollingerMeanReversion BollingerMode = "mean_reversion"
+)
+
+// BollingerConfig configures a BollingerStrategy.
+type BollingerConfig struct {
+	Mode       BollingerMode // Breakout or mean reversion, mean reversion if empty
+	Period     int           // Period of the bands
+	Multiplier float64       // Standard deviations between the middle and outer bands
+
+	StopLoss float64       // Sell when the price falls this fraction below the entry price, zero to disable
+	Cooldown time.Duration // Minimum time between an order and the next buy
+
+	// Quantity is the base asset quantity bought per entry. If QuoteFraction is set instead,
+	// entries spend that fraction of the quote asset balance.
+	Quantity      float64
+	QuoteFraction float64
+
+	OrderType types.OrderType // Market or limit at the tick's price, market if empty
+}
+
+// DefaultBollingerConfig is 20-period bands two standard deviations wide, trading mean reversion one unit
+// at a time with market orders.
+func DefaultBollingerConfig() BollingerConfig {
+	return BollingerConfig{
+		Mode:       BollingerMeanReversion,
+		Period:     20,
+		Multiplier: 2,
+		Quantity:   1,
+		OrderType:  types.OrderTypeMarket,
+	}
+}
+
+// BollingerStrategy trades a long position on Bollinger Bands, either buying breakouts above the upper band
+// or buying dips below the lower band, and selling when the price returns to the middle band or the stop
+// loss is hit. It tracks the cooldown of one trading pair, so each pair needs its own instance.
+type BollingerStrategy struct {
+	types.BaseStrategy
+	cfg   BollingerConfig
+	rules positionRules
+	bands *indicators.BollingerBands
+}
+
+// NewBollingerStrategy validates a configuration and builds the strategy.
+func NewBollingerStrategy(cfg BollingerConfig) (*BollingerStrategy, error) {
+	switch BollingerMode(strings.ToLower(string(cfg.Mode))) {
+	case "", BollingerMeanReversion:
+		cfg.Mode = BollingerMeanReversion
+	case BollingerBreakout:
+		cfg.Mode = BollingerBreakout
+	default:
+		return nil, fmt.Errorf("unknown Bollinger strategy mode %q", cfg.Mode)
+	}
+	if cfg.Period <= 1 || cfg.Multiplier <= 0 {
+		return nil, fmt.Errorf("Bollinger strategy requires a period above 1 and a positive multiplier, got %d/%v", cfg.Period, cfg.Multiplier)
+	}
+	orders, err := newSizing(cfg.Quantity, cfg.QuoteFraction, cfg.OrderType)
+	if err != nil {
+		return nil, fmt.Errorf("Bollinger strategy %w", err)
+	}
+	rules, err := newPositionRules(orders, cfg.StopLoss, cfg.Cooldown)
+	if err != nil {
+		return nil, fmt.Errorf("Bollinger strategy %w", err)
+	}
+	return &BollingerStrategy{cfg: cfg, rules: rules, bands: indicators.NewBollingerBands(cfg.Period, cfg.Multiplier)}, nil
+}
+
+// Name identifies the strategy by its mode and bands, e.g. "Bollinger_breakout(BollingerBands_20)".
+func (s *BollingerStrategy) Name() string {
+	return fmt.Sprintf("Bollinger_%s(%s)", s.cfg.Mode, s.bands.Name())
+}
+
+// Indicators returns the Bollinger Bands. Bands are named by period only, so if bands of the same period
+// are already registered for the pair, their multiplier is used.
+func (s *BollingerStrategy) Indicators() []types.Indicator {
+	return []types.Indicator{s.bands}
+}
+
+// WarmupPeriod waits for enough prices to calculate the bands.
+func (s *BollingerStrategy) WarmupPeriod() int {
+	return s.cfg.Period
+}
+
+// OnTick buys on an entry signal when flat, and sells when long and the price is back at the middle band or stopped out.
+func (s *BollingerStrategy) OnTick(ctx *types.TickContext) error {
+	if ctx.MarketData == nil {
+		return nil
+	}
+	name := s.bands.Name()
+	upper, okUpper := ctx.Indicators[types.IndicatorKey(name, indicators.BollingerUpper)]
+	middle, okMiddle := ctx.Indicators[types.IndicatorKey(name, indicators.BollingerMiddle)]
+	lower, okLower := ctx.Indicators[types.IndicatorKey(name, indicators.BollingerLower)]
+	if !okUpper || !okMiddle || !okLower {
+		return nil
+	}
+	price := ctx.MarketData.Price
+
+	exit, entry := price >= middle, price < lower
+	if s.cfg.Mode == BollingerBreakout {
+		exit, entry = price < middle, price > upper
+	}
+
+	position := s.rules.position(ctx)
+	if position.IsLong() {
+		if exit || s.rules.stopped(ctx, position) {
+			return s.rules.sell(ctx, position)
+		}
+		return nil
+	}
+	if entry && s.rules.canEnter(ctx) {
+		return s.rules.buy(ctx)
+	}
+	return nil
+}
+
+// OnOrderUpdate stops awaiting the strategy's last order once it is filled, canceled or rejected.
+func (s *BollingerStrategy) OnOrderUpdate(ctx *types.StrategyContext, order *types.Order) error {
+	s.rules.orderUpdate(order)
+	return nil
+}
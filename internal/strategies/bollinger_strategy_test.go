@@ -0,0 +1,68 @@
+package strategies
+
+import (
+	"github.com/bigmeech/tradingbot/internal/portfolio"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"testing"
+)
+
+func TestBollingerStrategy_OnTick(t *testing.T) {
+	market := types.OrderTypeMarket
+	buy, sell := types.OrderSideBuy, types.OrderSideSell
+	tests := []struct {
+		name     string
+		cfg      BollingerConfig
+		prices   []float64
+		expected []placedOrder
+	}{
+		{
+			name:     "Mean reversion buys below the lower band and sells at the middle band",
+			cfg:      BollingerConfig{Mode: BollingerMeanReversion, Period: 3, Multiplier: 1, Quantity: 1},
+			prices:   []float64{10, 10, 10, 7, 8, 9},
+			expected: []placedOrder{{market, buy, 1, 7}, {market, sell, 1, 9}},
+		},
+		{
+			name:     "Breakout buys above the upper band and sells below the middle band",
+			cfg:      BollingerConfig{Mode: BollingerBreakout, Period: 3, Multiplier: 1, Quantity: 1},
+			prices:   []float64{10, 10, 10, 13, 14, 11},
+			expected: []placedOrder{{market, buy, 1, 13}, {market, sell, 1, 11}},
+		},
+		{
+			name:   "No orders inside the bands",
+			cfg:    BollingerConfig{Mode: BollingerBreakout, Period: 3, Multiplier: 2, Quantity: 1},
+			prices: []float64{10, 11, 10, 11, 10, 11},
+		},
+		{
+			name:     "Sells at the stop loss before reaching the middle band",
+			cfg:      BollingerConfig{Period: 3, Multiplier: 1, StopLoss: 0.05, Quantity: 1},
+			prices:   []float64{10, 10, 10, 7, 6.5},
+			expected: []placedOrder{{market, buy, 1, 7}, {market, sell, 1, 6.5}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			strategy, err := NewBollingerStrategy(test.cfg)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			checkOrders(t, runSeries(t, strategy, portfolio.NewTracker(nil), test.prices), test.expected)
+		})
+	}
+}
+
+func TestNewBollingerStrategy(t *testing.T) {
+	if _, err := NewBollingerStrategy(BollingerConfig{Mode: "squeeze", Period: 20, Multiplier: 2, Quantity: 1}); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+	if _, err := NewBollingerStrategy(BollingerConfig{Period: 20, Quantity: 1}); err == nil {
+		t.Error("Expected an error without a multiplier")
+	}
+	strategy, err := NewBollingerStrategy(BollingerConfig{Mode: "Breakout", Period: 20, Multiplier: 2, Quantity: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strategy.Name() != "Bollinger_breakout(BollingerBands_20)" {
+		t.Errorf("Unexpected name %s", strategy.Name())
+	}
+}
@@ -1,28 +1,123 @@
 package strategies
 
 import (
+	"fmt"
+	"github.com/bigmeech/tradingbot/internal/indicators"
 	"github.com/bigmeech/tradingbot/pkg/types"
+	"strings"
 )
 
-func MovingAverageCrossoverStrategy() types.Middleware {
-	return func(ctx *types.TickContext) error {
-		shortSMA := ctx.Indicators["SMA_50"]
-		longSMA := ctx.Indicators["SMA_200"]
-
-		if shortSMA > longSMA {
-			// Execute a market buy order if the short SMA crosses above the long SMA
-			err := ctx.ExecuteOrder(types.OrderTypeMarket, types.OrderSideBuy, 1.0, ctx.MarketData.Price)
-			if err != nil {
-				return err
-			}
-		} else if shortSMA < longSMA {
-			// Execute a market sell order if the short SMA crosses below the long SMA
-			err := ctx.ExecuteOrder(types.OrderTypeMarket, types.OrderSideSell, 1.0, ctx.MarketData.Price)
-			if err != nil {
-				return err
-			}
-		}
+// MovingAverageType selects the moving average a crossover strategy compares.
+type MovingAverageType string
 
+const (
+	SimpleMovingAverage      MovingAverageType = "SMA"
+	ExponentialMovingAverage MovingAverageType = "EMA"
+)
+
+// MovingAverageCrossoverConfig configures a MovingAverageCrossover.
+type MovingAverageCrossoverConfig struct {
+	Average     MovingAverageType // SMA or EMA, SMA if empty
+	ShortPeriod int               // Period of the fast average
+	LongPeriod  int               // Period of the slow average, greater than ShortPeriod
+
+	// Quantity is the base asset quantity bought on a bullish cross. If QuoteFraction is set instead,
+	// buys spend that fraction of the quote asset balance, e.g. 0.5 spends half the USDT of BTC/USDT.
+	Quantity      float64
+	QuoteFraction float64
+
+	OrderType types.OrderType // Market or limit at the tick's price, market if empty
+}
+
+// DefaultMovingAverageCrossoverConfig is the classic 50/200 SMA cross buying one unit with market orders.
+func DefaultMovingAverageCrossoverConfig() MovingAverageCrossoverConfig {
+	return MovingAverageCrossoverConfig{
+		Average:     SimpleMovingAverage,
+		ShortPeriod: 50,
+		LongPeriod:  200,
+		Quantity:    1,
+		OrderType:   types.OrderTypeMarket,
+	}
+}
+
+// MovingAverageCrossover buys when the short moving average crosses above the long one and sells the long
+// position when it crosses back below. It acts only on the tick where the averages cross, not on every tick
+// one is above the other, and only buys when flat and sells when long.
+// It remembers the last cross of one trading pair, so each pair needs its own instance.
+type MovingAverageCrossover struct {
+	types.BaseStrategy
+	cfg         MovingAverageCrossoverConfig
+	rules       positionRules
+	short, long types.Indicator
+
+	lastDiff float64 // Short minus long average on the previous tick
+	seen     bool    // Set once lastDiff holds a value
+}
+
+// NewMovingAverageCrossover validates a configuration and builds the strategy.
+func NewMovingAverageCrossover(cfg MovingAverageCrossoverConfig) (*MovingAverageCrossover, error) {
+	if cfg.ShortPeriod <= 0 || cfg.LongPeriod <= cfg.ShortPeriod {
+		return nil, fmt.Errorf("moving average crossover requires 0 < short < long, got %d/%d", cfg.ShortPeriod, cfg.LongPeriod)
+	}
+	orders, err := newSizing(cfg.Quantity, cfg.QuoteFraction, cfg.OrderType)
+	if err != nil {
+		return nil, fmt.Errorf("moving average crossover %w", err)
+	}
+
+	s := &MovingAverageCrossover{cfg: cfg, rules: positionRules{sizing: orders}}
+	switch MovingAverageType(strings.ToUpper(string(cfg.Average))) {
+	case "", SimpleMovingAverage:
+		s.short, s.long = indicators.NewSMA(cfg.ShortPeriod), indicators.NewSMA(cfg.LongPeriod)
+	case ExponentialMovingAverage:
+		s.short, s.long = indicators.NewEMA(cfg.ShortPeriod), indicators.NewEMA(cfg.LongPeriod)
+	default:
+		return nil, fmt.Errorf("unknown moving average type %q", cfg.Average)
+	}
+	return s, nil
+}
+
+// Name identifies the strategy by its averages, e.g. "MA_Crossover(SMA_50,SMA_200)".
+func (s *MovingAverageCrossover) Name() string {
+	return fmt.Sprintf("MA_Crossover(%s,%s)", s.short.Name(), s.long.Name())
+}
+
+// Indicators returns the short and long moving averages.
+func (s *MovingAverageCrossover) Indicators() []types.Indicator {
+	return []types.Indicator{s.short, s.long}
+}
+
+// WarmupPeriod waits for enough prices to calculate the long average.
+func (s *MovingAverageCrossover) WarmupPeriod() int {
+	return s.cfg.LongPeriod
+}
+
+// OnTick places an order when the averages cross.
+func (s *MovingAverageCrossover) OnTick(ctx *types.TickContext) error {
+	if ctx.MarketData == nil {
 		return nil
 	}
+	diff := ctx.Indicators[s.short.Name()] - ctx.Indicators[s.long.Name()]
+	lastDiff, seen := s.lastDiff, s.seen
+	// A zero difference is not a side, so a cross is only detected once the averages separate again
+	if diff != 0 {
+		s.lastDiff, s.seen = diff, true
+	}
+	if !seen || diff == 0 || (diff > 0) == (lastDiff > 0) {
+		return nil
+	}
+
+	position := s.rules.position(ctx)
+	if diff > 0 && !position.IsLong() {
+		return s.rules.buy(ctx)
+	}
+	if diff < 0 && position.IsLong() {
+		return s.rules.sell(ctx, position)
+	}
+	return nil
+}
+
+// OnOrderUpdate stops awaiting the strategy's last order once it is filled, canceled or rejected.
+func (s *MovingAverageCrossover) OnOrderUpdate(ctx *types.StrategyContext, order *types.Order) error {
+	s.rules.orderUpdate(order)
+	return nil
 }
@@ -0,0 +1,180 @@
+package strategies
+
+import (
+	"github.com/bigmeech/tradingbot/internal/portfolio"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"math"
+	"testing"
+)
+
+// placedOrder is an order placed by a strategy under test.
+type placedOrder struct {
+	OrderType types.OrderType
+	Side      types.OrderSide
+	Quantity  float64
+	Price     float64
+}
+
+// runSeries feeds prices to a strategy a minute apart as the framework would, calculating its indicators over
+// the price history once it has warmed up and filling every order it places at the tick's price. Without a
+// tracker, ticks have no portfolio.
+func runSeries(t *testing.T, strategy types.Strategy, tracker *portfolio.Tracker, prices []float64) []placedOrder {
+	t.Helper()
+	var placed []placedOrder
+	var history []float64
+	for i, price := range prices {
+		history = append(history, price)
+		if len(history) < strategy.WarmupPeriod() {
+			continue
+		}
+		ctx := &types.TickContext{
+			MarketName:  "Mock",
+			TradingPair: "BTC/USDT",
+			MarketData:  &types.MarketData{Price: price, Time: 1700000000000 + int64(i)*60000},
+			Indicators:  make(map[string]float64),
+			ExecuteOrder: func(orderType types.OrderType, side types.OrderSide, amount, limit float64) (*types.Order, error) {
+				placed = append(placed, placedOrder{orderType, side, amount, limit})
+				order := &types.Order{ClientOrderID: types.NewClientOrderID(), TradingPair: "BTC/USDT", Type: orderType, Side: side, Quantity: amount}
+				order.AddFill(types.Fill{Price: price, Quantity: amount})
+				if tracker != nil {
+					tracker.ApplyOrder("Mock", order)
+				}
+				return order, nil
+			},
+		}
+		if tracker != nil {
+			ctx.Portfolio = tracker
+		}
+		for _, indicator := range strategy.Indicators() {
+			ctx.Indicators[indicator.Name()] = indicator.Calculate(history)
+			if multi, ok := indicator.(types.MultiValueIndicator); ok {
+				for output, value := range multi.CalculateOutputs(history) {
+					ctx.Indicators[types.IndicatorKey(indicator.Name(), output)] = value
+				}
+			}
+		}
+		if err := strategy.OnTick(ctx); err != nil {
+			t.Fatalf("Unexpected error at price %v: %v", price, err)
+		}
+	}
+	return placed
+}
+
+// checkOrders compares the orders placed with the expected ones.
+func checkOrders(t *testing.T, placed, expected []placedOrder) {
+	t.Helper()
+	if len(placed) != len(expected) {
+		t.Fatalf("Expected orders %+v, got %+v", expected, placed)
+	}
+	for i, order := range placed {
+		if order.OrderType != expected[i].OrderType || order.Side != expected[i].Side || order.Price != expected[i].Price ||
+			math.Abs(order.Quantity-expected[i].Quantity) > 1e-9 {
+			t.Errorf("Expected order %d to be %+v, got %+v", i, expected[i], order)
+		}
+	}
+}
+
+func TestMovingAverageCrossover_OnTick(t *testing.T) {
+	market := types.OrderTypeMarket
+	buy, sell := types.OrderSideBuy, types.OrderSideSell
+	tests := []struct {
+		name     string
+		cfg      MovingAverageCrossoverConfig
+		balances map[string]float64
+		long     float64 // Quantity already held before the series
+		prices   []float64
+		expected []placedOrder
+	}{
+		{
+			name:   "No orders without a cross",
+			cfg:    MovingAverageCrossoverConfig{ShortPeriod: 2, LongPeriod: 4, Quantity: 1},
+			prices: []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+		},
+		{
+			name:     "Buys once on a bullish cross and sells on the bearish cross",
+			cfg:      MovingAverageCrossoverConfig{ShortPeriod: 2, LongPeriod: 4, Quantity: 1},
+			prices:   []float64{10, 9, 8, 7, 8, 9, 10, 11, 10, 8, 6},
+			expected: []placedOrder{{market, buy, 1, 9}, {market, sell, 1, 8}},
+		},
+		{
+			name:   "Does not buy when already long",
+			cfg:    MovingAverageCrossoverConfig{ShortPeriod: 2, LongPeriod: 4, Quantity: 1},
+			long:   2,
+			prices: []float64{10, 9, 8, 7, 8, 9, 10},
+		},
+		{
+			name:   "Does not sell when flat",
+			cfg:    MovingAverageCrossoverConfig{ShortPeriod: 2, LongPeriod: 4, Quantity: 1},
+			prices: []float64{1, 2, 3, 4, 5, 4, 3, 2},
+		},
+		{
+			name:     "Sells the whole long position",
+			cfg:      MovingAverageCrossoverConfig{ShortPeriod: 2, LongPeriod: 4, Quantity: 1},
+			long:     2.5,
+			prices:   []float64{1, 2, 3, 4, 5, 4, 3, 2},
+			expected: []placedOrder{{market, sell, 2.5, 3}},
+		},
+		{
+			name:     "Sizes buys from the quote balance",
+			cfg:      MovingAverageCrossoverConfig{ShortPeriod: 2, LongPeriod: 4, QuoteFraction: 0.5},
+			balances: map[string]float64{"USDT": 900},
+			prices:   []float64{10, 9, 8, 7, 8, 9},
+			expected: []placedOrder{{market, buy, 50, 9}},
+		},
+		{
+			name:     "Places limit orders at the tick's price",
+			cfg:      MovingAverageCrossoverConfig{ShortPeriod: 2, LongPeriod: 4, Quantity: 0.1, OrderType: types.OrderTypeLimit},
+			prices:   []float64{10, 9, 8, 7, 8, 9},
+			expected: []placedOrder{{types.OrderTypeLimit, buy, 0.1, 9}},
+		},
+		{
+			name:     "Crosses exponential moving averages",
+			cfg:      MovingAverageCrossoverConfig{Average: ExponentialMovingAverage, ShortPeriod: 2, LongPeriod: 4, Quantity: 1},
+			prices:   []float64{10, 9, 8, 7, 6, 5, 10, 15, 20},
+			expected: []placedOrder{{market, buy, 1, 10}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			strategy, err := NewMovingAverageCrossover(test.cfg)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			tracker := portfolio.NewTracker(test.balances)
+			if test.long > 0 {
+				order := &types.Order{TradingPair: "BTC/USDT", Side: types.OrderSideBuy, Quantity: test.long}
+				order.AddFill(types.Fill{Price: 1, Quantity: test.long})
+				tracker.ApplyOrder("Mock", order)
+			}
+
+			checkOrders(t, runSeries(t, strategy, tracker, test.prices), test.expected)
+		})
+	}
+}
+
+func TestNewMovingAverageCrossover(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  MovingAverageCrossoverConfig
+	}{
+		{"Short period not below long", MovingAverageCrossoverConfig{ShortPeriod: 4, LongPeriod: 4, Quantity: 1}},
+		{"No sizing", MovingAverageCrossoverConfig{ShortPeriod: 2, LongPeriod: 4}},
+		{"Quote fraction above one", MovingAverageCrossoverConfig{ShortPeriod: 2, LongPeriod: 4, QuoteFraction: 1.5}},
+		{"Unsupported order type", MovingAverageCrossoverConfig{ShortPeriod: 2, LongPeriod: 4, Quantity: 1, OrderType: types.OrderTypeIceberg}},
+		{"Unknown average", MovingAverageCrossoverConfig{Average: "WMA", ShortPeriod: 2, LongPeriod: 4, Quantity: 1}},
+	}
+	for _, test := range tests {
+		if _, err := NewMovingAverageCrossover(test.cfg); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+
+	strategy, err := NewMovingAverageCrossover(DefaultMovingAverageCrossoverConfig())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strategy.Name() != "MA_Crossover(SMA_50,SMA_200)" || strategy.WarmupPeriod() != 200 {
+		t.Errorf("Unexpected default strategy %s warming up over %d prices", strategy.Name(), strategy.WarmupPeriod())
+	}
+}
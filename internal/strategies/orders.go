@@ -0,0 +1,162 @@
+package strategies
+
+import (
+	"fmt"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"time"
+)
+
+// sizing is how much a strategy buys and the type of order it places.
+type sizing struct {
+	quantity      float64 // Base asset quantity per buy, unless quoteFraction is set
+	quoteFraction float64 // Share of the quote asset balance spent per buy
+	orderType     types.OrderType
+}
+
+// newSizing validates the sizing settings shared by the strategies, defaulting to market orders.
+func newSizing(quantity, quoteFraction float64, orderType types.OrderType) (sizing, error) {
+	if quantity < 0 || quoteFraction < 0 || quoteFraction > 1 {
+		return sizing{}, fmt.Errorf("requires a non-negative quantity and a quote fraction between 0 and 1, got %v/%v", quantity, quoteFraction)
+	}
+	if quantity == 0 && quoteFraction == 0 {
+		return sizing{}, fmt.Errorf("requires a quantity or a quote fraction")
+	}
+	if orderType == "" {
+		orderType = types.OrderTypeMarket
+	}
+	if orderType != types.OrderTypeMarket && orderType != types.OrderTypeLimit {
+		return sizing{}, fmt.Errorf("supports market and limit orders, got %q", orderType)
+	}
+	return sizing{quantity: quantity, quoteFraction: quoteFraction, orderType: orderType}, nil
+}
+
+// buyQuantity returns the configured quantity, or the share of the quote asset balance it buys at the
+// tick's price. It is zero if the quote balance is empty or unknown.
+func (s sizing) buyQuantity(ctx *types.TickContext) float64 {
+	if s.quoteFraction == 0 {
+		return s.quantity
+	}
+	price := ctx.MarketData.Price
+	if ctx.Portfolio == nil || price <= 0 {
+		return 0
+	}
+	_, quote := types.SplitTradingPair(ctx.TradingPair)
+	return ctx.Portfolio.Balance(quote) * s.quoteFraction / price
+}
+
+// placeOrder places an order of the configured type, limit orders at the tick's price, and returns it.
+func (s sizing) placeOrder(ctx *types.TickContext, side types.OrderSide, quantity float64) (*types.Order, error) {
+	if ctx.ExecuteOrder == nil {
+		return nil, fmt.Errorf("cannot place %s order for %s: no order execution", side, ctx.TradingPair)
+	}
+	order, err := ctx.ExecuteOrder(s.orderType, side, quantity, ctx.MarketData.Price)
+	if err != nil {
+		return nil, fmt.Errorf("failed to place %s order for %s: %w", side, ctx.TradingPair, err)
+	}
+	return order, nil
+}
+
+// positionRules track the long position of the strategies that buy on one signal and sell on another, with
+// an optional stop loss and a cooldown between an order and the next entry. The position comes from the
+// tick's portfolio, or from the strategy's own orders if the tick has none. While the strategy's last order
+// is still open, e.g. a resting limit order, no other order is placed until an order update shows it closed.
+type positionRules struct {
+	sizing   sizing
+	stopLoss float64       // Exit when the price falls this fraction below the entry price, zero to disable
+	cooldown time.Duration // Minimum time between an order and the next entry
+	lastTime int64         // Tick time of the last order placed successfully
+	pending  *types.Order  // Last order placed while it may still be open
+	own      types.Position
+}
+
+// newPositionRules validates the sizing, stop loss and cooldown settings.
+func newPositionRules(orders sizing, stopLoss float64, cooldown time.Duration) (positionRules, error) {
+	if stopLoss < 0 || stopLoss >= 1 || cooldown < 0 {
+		return positionRules{}, fmt.Errorf("requires a stop loss between 0 and 1 and a non-negative cooldown, got %v/%v", stopLoss, cooldown)
+	}
+	return positionRules{sizing: orders, stopLoss: stopLoss, cooldown: cooldown}, nil
+}
+
+// position returns the tick's position from its portfolio, or the position built up by the strategy's own orders.
+func (r *positionRules) position(ctx *types.TickContext) types.Position {
+	if ctx.Portfolio == nil {
+		return r.own
+	}
+	return ctx.Portfolio.Position(ctx.MarketName, ctx.TradingPair)
+}
+
+// stopped reports whether the tick's price has fallen through the stop loss of a long position.
+func (r *positionRules) stopped(ctx *types.TickContext, position types.Position) bool {
+	return r.stopLoss > 0 && position.IsLong() && ctx.MarketData.Price <= position.AverageEntryPrice*(1-r.stopLoss)
+}
+
+// canEnter reports whether the cooldown since the last order placed has passed at the tick's time.
+func (r *positionRules) canEnter(ctx *types.TickContext) bool {
+	return r.lastTime == 0 || tickTime(ctx)-r.lastTime >= r.cooldown.Milliseconds()
+}
+
+// awaiting reports whether the strategy's last order may still be open. It is awaited until an order update
+// shows it filled, cancelled or rejected; an order that merely stops being listed as open may have filled
+// without the fill reaching the portfolio yet.
+func (r *positionRules) awaiting() bool {
+	return r.pending != nil
+}
+
+// orderUpdate stops awaiting the strategy's last order once an update shows it is no longer open.
+func (r *positionRules) orderUpdate(order *types.Order) {
+	if r.pending != nil && order != nil && sameOrder(order, r.pending) && !order.IsOpen() {
+		r.pending = nil
+	}
+}
+
+// buy opens a position sized by the strategy's sizing, unless there is nothing to buy with or the last order is still open.
+func (r *positionRules) buy(ctx *types.TickContext) error {
+	quantity := r.sizing.buyQuantity(ctx)
+	if quantity <= 0 || r.awaiting() {
+		return nil
+	}
+	order, err := r.sizing.placeOrder(ctx, types.OrderSideBuy, quantity)
+	if err != nil {
+		return err
+	}
+	r.lastTime = tickTime(ctx)
+	r.await(order)
+	r.own = types.Position{Quantity: quantity, AverageEntryPrice: ctx.MarketData.Price}
+	return nil
+}
+
+// sell closes a long position, unless the last order is still open.
+func (r *positionRules) sell(ctx *types.TickContext, position types.Position) error {
+	if r.awaiting() {
+		return nil
+	}
+	order, err := r.sizing.placeOrder(ctx, types.OrderSideSell, position.Quantity)
+	if err != nil {
+		return err
+	}
+	r.lastTime = tickTime(ctx)
+	r.await(order)
+	r.own = types.Position{}
+	return nil
+}
+
+// await keeps an order that was placed but not filled yet, so no other order is placed while it is open.
+func (r *positionRules) await(order *types.Order) {
+	if order != nil && order.IsOpen() {
+		r.pending = order.Clone()
+	}
+}
+
+// sameOrder reports whether two orders share an exchange or client order ID.
+func sameOrder(a, b *types.Order) bool {
+	return (a.ExchangeOrderID != "" && a.ExchangeOrderID == b.ExchangeOrderID) ||
+		(a.ClientOrderID != "" && a.ClientOrderID == b.ClientOrderID)
+}
+
+// tickTime returns the tick's exchange time, or the current time if the exchange sent none, in Unix milliseconds.
+func tickTime(ctx *types.TickContext) int64 {
+	if ctx.MarketData.Time != 0 {
+		return ctx.MarketData.Time
+	}
+	return time.Now().UnixMilli()
+}
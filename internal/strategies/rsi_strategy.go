@@ -0,0 +1,119 @@
+package strategies
+
+import (
+	"fmt"
+	"github.com/bigmeech/tradingbot/internal/indicators"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"time"
+)
+
+// RSIConfig configures an RSIStrategy.
+type RSIConfig struct {
+	Period     int     // RSI period
+	Oversold   float64 // Buy when the RSI falls to or below this level
+	Overbought float64 // Sell when the RSI rises to or above this level
+
+	// ExitLevel sells earlier, once the RSI has recovered to this level, e.g. 50. Zero exits at Overbought.
+	ExitLevel float64
+
+	StopLoss float64       // Sell when the price falls this fraction below the entry price, zero to disable
+	Cooldown time.Duration // Minimum time between an order and the next buy
+
+	// Quantity is the base asset quantity bought per entry. If QuoteFraction is set instead,
+	// entries spend that fraction of the quote asset balance.
+	Quantity      float64
+	QuoteFraction float64
+
+	OrderType types.OrderType // Market or limit at the tick's price, market if empty
+}
+
+// DefaultRSIConfig is the classic 14-period RSI buying one unit at 30 and selling at 70 with market orders.
+func DefaultRSIConfig() RSIConfig {
+	return RSIConfig{
+		Period:     14,
+		Oversold:   30,
+		Overbought: 70,
+		Quantity:   1,
+		OrderType:  types.OrderTypeMarket,
+	}
+}
+
+// RSIStrategy is a mean-reversion strategy: it buys when the RSI shows the pair oversold and sells the long
+// position once the RSI shows it overbought, or has recovered to the exit level, or the stop loss is hit.
+// It tracks the cooldown of one trading pair, so each pair needs its own instance.
+type RSIStrategy struct {
+	types.BaseStrategy
+	cfg   RSIConfig
+	rules positionRules
+	rsi   *indicators.RSI
+}
+
+// NewRSIStrategy validates a configuration and builds the strategy.
+func NewRSIStrategy(cfg RSIConfig) (*RSIStrategy, error) {
+	if cfg.Period <= 1 {
+		return nil, fmt.Errorf("RSI strategy requires a period above 1, got %d", cfg.Period)
+	}
+	if cfg.Oversold <= 0 || cfg.Overbought >= 100 || cfg.Oversold >= cfg.Overbought {
+		return nil, fmt.Errorf("RSI strategy requires 0 < oversold < overbought < 100, got %v/%v", cfg.Oversold, cfg.Overbought)
+	}
+	if cfg.ExitLevel != 0 && (cfg.ExitLevel <= cfg.Oversold || cfg.ExitLevel > cfg.Overbought) {
+		return nil, fmt.Errorf("RSI strategy requires an exit level above oversold and up to overbought, got %v", cfg.ExitLevel)
+	}
+	orders, err := newSizing(cfg.Quantity, cfg.QuoteFraction, cfg.OrderType)
+	if err != nil {
+		return nil, fmt.Errorf("RSI strategy %w", err)
+	}
+	rules, err := newPositionRules(orders, cfg.StopLoss, cfg.Cooldown)
+	if err != nil {
+		return nil, fmt.Errorf("RSI strategy %w", err)
+	}
+	return &RSIStrategy{cfg: cfg, rules: rules, rsi: indicators.NewRSI(cfg.Period)}, nil
+}
+
+// Name identifies the strategy by its RSI, e.g. "RSI(RSI_14)".
+func (s *RSIStrategy) Name() string {
+	return fmt.Sprintf("RSI(%s)", s.rsi.Name())
+}
+
+// Indicators returns the RSI.
+func (s *RSIStrategy) Indicators() []types.Indicator {
+	return []types.Indicator{s.rsi}
+}
+
+// WarmupPeriod waits for enough prices to calculate the RSI.
+func (s *RSIStrategy) WarmupPeriod() int {
+	return s.cfg.Period
+}
+
+// OnTick buys when oversold and flat, and sells when long and overbought, recovered or stopped out.
+func (s *RSIStrategy) OnTick(ctx *types.TickContext) error {
+	if ctx.MarketData == nil {
+		return nil
+	}
+	rsi, ok := ctx.Indicators[s.rsi.Name()]
+	if !ok {
+		return nil
+	}
+
+	position := s.rules.position(ctx)
+	if position.IsLong() {
+		exitLevel := s.cfg.Overbought
+		if s.cfg.ExitLevel != 0 {
+			exitLevel = s.cfg.ExitLevel
+		}
+		if rsi >= exitLevel || s.rules.stopped(ctx, position) {
+			return s.rules.sell(ctx, position)
+		}
+		return nil
+	}
+	if rsi <= s.cfg.Oversold && s.rules.canEnter(ctx) {
+		return s.rules.buy(ctx)
+	}
+	return nil
+}
+
+// OnOrderUpdate stops awaiting the strategy's last order once it is filled, canceled or rejected.
+func (s *RSIStrategy) OnOrderUpdate(ctx *types.StrategyContext, order *types.Order) error {
+	s.rules.orderUpdate(order)
+	return nil
+}
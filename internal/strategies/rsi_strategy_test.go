@@ -0,0 +1,178 @@
+package strategies
+
+import (
+	"fmt"
+	"github.com/bigmeech/tradingbot/internal/portfolio"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"testing"
+	"time"
+)
+
+func TestRSIStrategy_OnTick(t *testing.T) {
+	market := types.OrderTypeMarket
+	buy, sell := types.OrderSideBuy, types.OrderSideSell
+	base := RSIConfig{Period: 3, Oversold: 30, Overbought: 70, Quantity: 1}
+	with := func(change func(cfg *RSIConfig)) RSIConfig {
+		cfg := base
+		change(&cfg)
+		return cfg
+	}
+	tests := []struct {
+		name        string
+		cfg         RSIConfig
+		noPortfolio bool
+		prices      []float64
+		expected    []placedOrder
+	}{
+		{
+			name:     "Buys oversold and sells overbought",
+			cfg:      base,
+			prices:   []float64{10, 9, 8, 7, 8, 9, 10},
+			expected: []placedOrder{{market, buy, 1, 8}, {market, sell, 1, 9}},
+		},
+		{
+			name:     "Exits early at the exit level",
+			cfg:      with(func(cfg *RSIConfig) { cfg.ExitLevel = 50 }),
+			prices:   []float64{10, 9, 8, 7, 8, 9, 10},
+			expected: []placedOrder{{market, buy, 1, 8}, {market, sell, 1, 8}},
+		},
+		{
+			name:     "Waits for the cooldown before buying again",
+			cfg:      with(func(cfg *RSIConfig) { cfg.Cooldown = 3 * time.Minute }),
+			prices:   []float64{10, 9, 8, 9, 10, 9, 8, 7},
+			expected: []placedOrder{{market, buy, 1, 8}, {market, sell, 1, 10}, {market, buy, 1, 7}},
+		},
+		{
+			name:     "Sells at the stop loss",
+			cfg:      with(func(cfg *RSIConfig) { cfg.StopLoss = 0.1 }),
+			prices:   []float64{10, 9, 8, 7.5, 7},
+			expected: []placedOrder{{market, buy, 1, 8}, {market, sell, 1, 7}},
+		},
+		{
+			name:        "Tracks its own position without a portfolio",
+			cfg:         base,
+			noPortfolio: true,
+			prices:      []float64{10, 9, 8, 7, 8, 9, 10},
+			expected:    []placedOrder{{market, buy, 1, 8}, {market, sell, 1, 9}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			strategy, err := NewRSIStrategy(test.cfg)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			tracker := portfolio.NewTracker(nil)
+			if test.noPortfolio {
+				tracker = nil
+			}
+			checkOrders(t, runSeries(t, strategy, tracker, test.prices), test.expected)
+		})
+	}
+}
+
+func TestRSIStrategy_OnTickWithUnfilledOrder(t *testing.T) {
+	strategy, err := NewRSIStrategy(RSIConfig{Period: 3, Oversold: 30, Overbought: 70, Quantity: 1, OrderType: types.OrderTypeLimit})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Orders rest on the exchange without filling, so the position stays flat while the RSI stays oversold
+	var placed []*types.Order
+	tick := func(i int) {
+		ctx := &types.TickContext{
+			MarketName:  "Mock",
+			TradingPair: "BTC/USDT",
+			MarketData:  &types.MarketData{Price: 8, Time: 1700000000000 + int64(i)*60000},
+			Indicators:  map[string]float64{"RSI_3": 20},
+			Portfolio:   portfolio.NewTracker(nil),
+			ExecuteOrder: func(orderType types.OrderType, side types.OrderSide, amount, price float64) (*types.Order, error) {
+				order := &types.Order{ExchangeOrderID: fmt.Sprint(len(placed) + 1), TradingPair: "BTC/USDT", Type: orderType, Side: side, Status: types.OrderStatusNew, Price: price, Quantity: amount}
+				placed = append(placed, order)
+				return order, nil
+			},
+			GetOpenOrders: func() ([]*types.Order, error) {
+				return nil, nil // Already gone from the exchange's open orders, with the fill not seen yet
+			},
+		}
+		if err := strategy.OnTick(ctx); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		tick(i)
+	}
+	if len(placed) != 1 {
+		t.Fatalf("Expected a single buy until an update shows the order closed, got %d orders", len(placed))
+	}
+
+	strategy.OnOrderUpdate(nil, &types.Order{ExchangeOrderID: "1", Status: types.OrderStatusPartiallyFilled, FilledQuantity: 0.5})
+	tick(3)
+	if len(placed) != 1 {
+		t.Fatalf("Expected no new buy while the order is partially filled, got %d orders", len(placed))
+	}
+
+	strategy.OnOrderUpdate(nil, &types.Order{ExchangeOrderID: "1", Status: types.OrderStatusCancelled})
+	tick(4)
+	if len(placed) != 2 {
+		t.Errorf("Expected a new buy once the order is cancelled, got %d orders", len(placed))
+	}
+}
+
+func TestRSIStrategy_FailedOrderSkipsCooldown(t *testing.T) {
+	strategy, err := NewRSIStrategy(RSIConfig{Period: 3, Oversold: 30, Overbought: 70, Quantity: 1, Cooldown: 3 * time.Minute})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	attempts := 0
+	tick := func(i int) error {
+		return strategy.OnTick(&types.TickContext{
+			MarketName:  "Mock",
+			TradingPair: "BTC/USDT",
+			MarketData:  &types.MarketData{Price: 8, Time: 1700000000000 + int64(i)*60000},
+			Indicators:  map[string]float64{"RSI_3": 20},
+			Portfolio:   portfolio.NewTracker(nil),
+			ExecuteOrder: func(orderType types.OrderType, side types.OrderSide, amount, price float64) (*types.Order, error) {
+				attempts++
+				if attempts == 1 {
+					return &types.Order{Status: types.OrderStatusRejected}, fmt.Errorf("insufficient balance")
+				}
+				return &types.Order{ExchangeOrderID: "1", Status: types.OrderStatusFilled, Quantity: amount, FilledQuantity: amount}, nil
+			},
+		})
+	}
+
+	if err := tick(0); err == nil {
+		t.Fatal("Expected the rejected buy to return an error")
+	}
+	if err := tick(1); err != nil || attempts != 2 {
+		t.Fatalf("Expected the next tick to buy without waiting for the cooldown, got %d attempts, err %v", attempts, err)
+	}
+	if err := tick(2); err != nil || attempts != 2 {
+		t.Errorf("Expected the successful buy to start the cooldown, got %d attempts, err %v", attempts, err)
+	}
+}
+
+func TestNewRSIStrategy(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  RSIConfig
+	}{
+		{"Period too short", RSIConfig{Period: 1, Oversold: 30, Overbought: 70, Quantity: 1}},
+		{"Oversold above overbought", RSIConfig{Period: 14, Oversold: 70, Overbought: 30, Quantity: 1}},
+		{"Exit level below oversold", RSIConfig{Period: 14, Oversold: 30, Overbought: 70, ExitLevel: 20, Quantity: 1}},
+		{"Negative cooldown", RSIConfig{Period: 14, Oversold: 30, Overbought: 70, Cooldown: -time.Second, Quantity: 1}},
+		{"Stop loss of the whole price", RSIConfig{Period: 14, Oversold: 30, Overbought: 70, StopLoss: 1, Quantity: 1}},
+	}
+	for _, test := range tests {
+		if _, err := NewRSIStrategy(test.cfg); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+	if _, err := NewRSIStrategy(DefaultRSIConfig()); err != nil {
+		t.Errorf("Expected the default config to be valid, got %v", err)
+	}
+}
@@ -1,13 +1,80 @@
 package models
 
-import "github.com/bigmeech/tradingbot/pkg/types"
+import (
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"time"
+)
 
+// FastStore keeps the most recent ticks of each market and trading pair for quick access,
+// e.g. in memory or in Redis. QueryPriceHistory returns up to period prices, oldest first.
+// Implementations must be safe for concurrent use, since ticks of different pairs are recorded in parallel.
 type FastStore interface {
-	RecordTick(tradingPair string, marketData *types.MarketData) error
-	QueryPriceHistory(tradingPair string, period int) []float64
+	RecordTick(market, tradingPair string, marketData *types.MarketData) error
+	QueryPriceHistory(market, tradingPair string, period int) []float64
 }
 
+// LargeStore persists the tick history of each market and trading pair, e.g. in MongoDB.
+// QueryPriceHistory returns up to period prices, oldest first. Implementations must be safe for concurrent use.
 type LargeStore interface {
-	RecordTick(tradingPair string, marketData *types.MarketData) error
-	QueryPriceHistory(tradingPair string, period int) []float64
+	RecordTick(market, tradingPair string, marketData *types.MarketData) error
+	QueryPriceHistory(market, tradingPair string, period int) []float64
+}
+
+// TickHistory is implemented by stores that return whole ticks, so a StoreManager can stitch the history
+// of its stores together by time and warm start its fast store from its large store.
+type TickHistory interface {
+	// QueryTicks returns up to count of the most recent ticks of a market and trading pair with a time
+	// before the given Unix millisecond time, or the most recent ticks if before is zero, oldest first.
+	QueryTicks(market, tradingPair string, before int64, count int) []types.MarketData
+}
+
+// TickRangeHistory is implemented by stores that return the ticks recorded between two times, e.g. to
+// backtest over a period.
+type TickRangeHistory interface {
+	// QueryTicksRange returns the ticks of a market and trading pair with a time from the start up to but not
+	// including the end, in Unix milliseconds, oldest first. A zero end leaves the range open.
+	QueryTicksRange(market, tradingPair string, start, end int64) ([]types.MarketData, error)
+}
+
+// CandleStore is implemented by fast stores that also keep closed candles, e.g. to share them between
+// processes. QueryCandles returns up to count of the most recent candles, oldest first.
+type CandleStore interface {
+	RecordCandle(market string, candle types.Candle) error
+	QueryCandles(market, tradingPair string, interval time.Duration, count int) []types.Candle
+}
+
+// TickRecord is a tick of a market and trading pair, as written to a store in a batch.
+type TickRecord struct {
+	Market      string
+	TradingPair string
+	MarketData  types.MarketData
+}
+
+// BatchWriter is implemented by stores that write many ticks at once more cheaply than one at a time,
+// e.g. with a single insert.
+type BatchWriter interface {
+	RecordTicks(records []TickRecord) error
+}
+
+// WriteMetrics counts the ticks passing through an asynchronous write pipeline since it was created.
+type WriteMetrics struct {
+	Queued  uint64 // Ticks accepted into the queue
+	Written uint64 // Ticks written to the store
+	Failed  uint64 // Ticks whose write returned an error
+	Dropped uint64 // Ticks dropped because the queue was full
+	Blocked uint64 // Ticks that waited for room in a full queue
+	Late    uint64 // Ticks written later than the pipeline's late threshold after they were queued
+	Batches uint64 // Writes made to the store
+
+	QueueLength int // Ticks waiting in the queue now
+}
+
+// Flusher is implemented by stores that buffer writes; Flush persists everything buffered so far.
+type Flusher interface {
+	Flush() error
+}
+
+// Closer is implemented by stores that hold connections; Close releases them once the store is no longer used.
+type Closer interface {
+	Close() error
 }
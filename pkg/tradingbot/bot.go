@@ -1,11 +1,16 @@
 package tradingbot
 
 import (
+	"context"
 	"fmt"
+	"github.com/bigmeech/tradingbot/internal/backtest"
 	"github.com/bigmeech/tradingbot/internal/framework"
-	"github.com/bigmeech/tradingbot/internal/store"
+	"github.com/bigmeech/tradingbot/internal/risk"
+	"github.com/bigmeech/tradingbot/pkg/models"
 	"github.com/bigmeech/tradingbot/pkg/types"
 	"github.com/rs/zerolog"
+	"strings"
+	"time"
 )
 
 type Bot struct {
@@ -14,10 +19,12 @@ type Bot struct {
 	debugMode bool
 }
 
-// NewBot initializes a new Bot instance with a StoreManager.
-func NewBot(largeStore *store.MongoDBStore, bufferSize, threshold int, logger zerolog.Logger) *Bot {
+// NewBot initializes a new Bot instance with a StoreManager over a fast store for recent ticks and a large
+// store for their history, e.g. framework.NewInMemoryFastStore and framework.NewNoopLargeStore.
+// Price history queries longer than threshold are answered by the large store.
+func NewBot(fastStore models.FastStore, largeStore models.LargeStore, threshold int, logger zerolog.Logger) *Bot {
 	// Create StoreManager
-	storeManager := framework.NewStoreManager(largeStore, bufferSize, threshold)
+	storeManager := framework.NewStoreManager(fastStore, largeStore, threshold)
 
 	return &Bot{
 		fw:        framework.NewFramework(storeManager),
@@ -26,12 +33,174 @@ func NewBot(largeStore *store.MongoDBStore, bufferSize, threshold int, logger ze
 	}
 }
 
+// Defaults for store settings left out of a config.
+const (
+	defaultBufferSize      = 1000
+	defaultLargeStoreLimit = 100000
+)
+
+// NewBotFromConfig validates a configuration and builds a Bot with its connectors, indicators, strategies,
+// risk limits and starting balances registered. Connectors, indicators and strategies are looked up in the
+// registries by ConnectorConfig.Name, IndicatorConfig.Type and StrategyConfig.Type.
+func NewBotFromConfig(cfg *BotConfig, logger zerolog.Logger) (*Bot, error) {
+	return newBotFromConfig(cfg, logger, true)
+}
+
+// NewBacktestBotFromConfig builds a Bot like NewBotFromConfig but without registering the configured
+// connectors, so it can replay recorded ticks with Backtest under one of the configured market names.
+// The configured stores are replaced by in-memory ones and write-behind is ignored, so a backtest neither
+// warm starts from live history nor writes replayed ticks to stores shared with live bots.
+func NewBacktestBotFromConfig(cfg *BotConfig, logger zerolog.Logger) (*Bot, error) {
+	return newBotFromConfig(cfg, logger, false)
+}
+
+// newBotFromConfig builds a Bot from a validated configuration, registering connectors and using the
+// configured stores only if asked to.
+func newBotFromConfig(cfg *BotConfig, logger zerolog.Logger, withConnectors bool) (*Bot, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	bufferSize := cfg.Store.BufferSize
+	if bufferSize == 0 {
+		bufferSize = defaultBufferSize
+	}
+	threshold := cfg.Store.Threshold
+	if threshold == 0 {
+		threshold = bufferSize
+	}
+	var fastStore models.FastStore
+	var largeStore models.LargeStore
+	if withConnectors {
+		fastStore = newFastStore(cfg.Store.FastStore, bufferSize)
+		var err error
+		if largeStore, err = newLargeStore(cfg.Store.LargeStore); err != nil {
+			return nil, err
+		}
+	} else {
+		fastStore, largeStore = newBacktestStores(cfg.Store.LargeStore, bufferSize)
+	}
+
+	bot := NewBot(fastStore, largeStore, threshold, logger)
+	bot.SetCandleLimit(cfg.Store.CandleLimit)
+	if writeBehind := cfg.Store.WriteBehind; writeBehind.Enabled && withConnectors {
+		err := bot.EnableWriteBehind(framework.WriteBehindConfig{
+			QueueSize:     writeBehind.QueueSize,
+			BatchSize:     writeBehind.BatchSize,
+			FlushInterval: time.Duration(writeBehind.FlushIntervalMs) * time.Millisecond,
+			LateAfter:     time.Duration(writeBehind.LateAfterMs) * time.Millisecond,
+			Backpressure:  framework.BackpressurePolicy(strings.ToLower(writeBehind.Backpressure)),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("store.writeBehind: %w", err)
+		}
+	}
+	if cfg.Debug {
+		bot.EnableDebug()
+	}
+	for asset, amount := range cfg.Balances {
+		bot.SetBalance(asset, amount)
+	}
+	bot.SetRiskLimits(cfg.Risk)
+	bot.SetCancelOrdersOnStop(cfg.CancelOrdersOnStop)
+	if cfg.StrategyStateDir != "" {
+		bot.SetStrategyStateStore(framework.NewFileStrategyStateStore(cfg.StrategyStateDir))
+	}
+
+	for i, connectorCfg := range cfg.Connectors {
+		if !withConnectors {
+			break
+		}
+		connector, err := NewConnector(connectorCfg)
+		if err != nil {
+			return nil, fmt.Errorf("connectors[%d]: %w", i, err)
+		}
+		bot.RegisterConnector(connectorCfg.Name, connector)
+	}
+	for i, indicatorCfg := range cfg.Indicators {
+		indicator, err := NewIndicator(indicatorCfg)
+		if err != nil {
+			return nil, fmt.Errorf("indicators[%d]: %w", i, err)
+		}
+		bot.RegisterIndicator(indicatorCfg.MarketName, indicatorCfg.TradingPair, indicator)
+	}
+	for i, strategyCfg := range cfg.Strategies {
+		strategy, err := NewStrategy(strategyCfg)
+		if err != nil {
+			return nil, fmt.Errorf("strategies[%d]: %w", i, err)
+		}
+		if err := bot.RegisterStrategy(strategyCfg.MarketName, strategyCfg.TradingPair, strategy); err != nil {
+			return nil, fmt.Errorf("strategies[%d]: %w", i, err)
+		}
+	}
+	return bot, nil
+}
+
+// newFastStore builds the recent tick store selected in the config, keeping bufferSize ticks per market and trading pair.
+func newFastStore(cfg FastStoreConfig, bufferSize int) models.FastStore {
+	if strings.ToLower(cfg.Type) == "redis" {
+		return framework.NewRedisFastStore(cfg.Addr, cfg.Password, cfg.DB, bufferSize, framework.RedisFastStoreOptions{
+			Prefix:   cfg.Prefix,
+			Timeout:  time.Duration(cfg.TimeoutMs) * time.Millisecond,
+			ReadOnly: cfg.ReadOnly,
+		})
+	}
+	return framework.NewInMemoryFastStore(bufferSize)
+}
+
+// newLargeStore builds the persistent tick store selected in the config.
+func newLargeStore(cfg LargeStoreConfig) (models.LargeStore, error) {
+	switch strings.ToLower(cfg.Type) {
+	case "", "memory":
+		return framework.NewInMemoryFastStore(defaultLargeStoreLimit), nil
+	case "none":
+		return framework.NewNoopLargeStore(), nil
+	case "mongodb":
+		store, err := framework.NewMongoDBLargeStore(cfg.URI, cfg.Database, cfg.Collection, framework.MongoDBLargeStoreOptions{
+			TimeSeries: cfg.TimeSeries,
+			Retention:  time.Duration(cfg.RetentionDays) * 24 * time.Hour,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+		}
+		return store, nil
+	}
+	return nil, fmt.Errorf("unknown large store type %q", cfg.Type)
+}
+
+// newBacktestStores builds in-memory stores for a backtest, without a large store if the config has none.
+func newBacktestStores(cfg LargeStoreConfig, bufferSize int) (models.FastStore, models.LargeStore) {
+	fastStore := framework.NewInMemoryFastStore(bufferSize)
+	if strings.ToLower(cfg.Type) == "none" {
+		return fastStore, framework.NewNoopLargeStore()
+	}
+	return fastStore, framework.NewInMemoryFastStore(defaultLargeStoreLimit)
+}
+
 // EnableDebug enables debug mode for the bot, allowing detailed logging.
 func (b *Bot) EnableDebug() {
 	b.debugMode = true
 	b.logger = b.logger.Level(zerolog.DebugLevel)
 }
 
+// EnableWriteBehind moves large store writes off the tick path: ticks are queued and written in batches
+// on a background goroutine, and flushed when the bot stops. It must be called before the bot starts.
+func (b *Bot) EnableWriteBehind(cfg framework.WriteBehindConfig) error {
+	return b.fw.StoreManager().EnableWriteBehind(cfg)
+}
+
+// SetCandleLimit sets the number of closed candles kept per market, trading pair and interval, unless the fast
+// store keeps candles itself. Zero or less keeps framework.DefaultCandleLimit.
+func (b *Bot) SetCandleLimit(limit int) {
+	b.fw.StoreManager().SetCandleLimit(limit)
+}
+
+// StoreMetrics returns the counts of ticks queued, written, failed, dropped and written late by the
+// write-behind pipeline, all zero if it is not enabled.
+func (b *Bot) StoreMetrics() models.WriteMetrics {
+	return b.fw.StoreManager().WriteMetrics()
+}
+
 // RegisterConnector registers a trading connector.
 func (b *Bot) RegisterConnector(name string, connector types.Connector) {
 	b.fw.RegisterConnector(name, connector)
@@ -42,42 +211,142 @@ func (b *Bot) RegisterMiddleware(marketName, tradingPair string, mw types.Middle
 	b.fw.RegisterMiddleware(marketName, tradingPair, mw)
 }
 
+// RegisterStrategy adds a strategy for a specific market and trading pair, registering the indicators it
+// declares and running its hooks alongside the middleware in registration order. It returns an error if the
+// strategy's bar interval is shorter than a millisecond.
+func (b *Bot) RegisterStrategy(marketName, tradingPair string, strategy types.Strategy) error {
+	return b.fw.RegisterStrategy(marketName, tradingPair, strategy)
+}
+
+// SetStrategyStateStore sets where stateful strategies save their state on Stop and load it from on Start.
+func (b *Bot) SetStrategyStateStore(store types.StrategyStateStore) {
+	b.fw.SetStrategyStateStore(store)
+}
+
+// RegisterBarCloseMiddleware adds middleware that runs when a candle of the given interval closes
+// for a market and trading pair, e.g. every minute with interval time.Minute. Intervals shorter than a
+// millisecond are rejected.
+func (b *Bot) RegisterBarCloseMiddleware(marketName, tradingPair string, interval time.Duration, mw types.Middleware) error {
+	return b.fw.RegisterBarCloseMiddleware(marketName, tradingPair, interval, mw)
+}
+
+// RegisterCandleInterval aggregates ticks for a market and trading pair into candles of the given interval,
+// available to middleware through TickContext.Candles. Intervals shorter than a millisecond are rejected.
+func (b *Bot) RegisterCandleInterval(marketName, tradingPair string, interval time.Duration) error {
+	return b.fw.RegisterCandleInterval(marketName, tradingPair, interval)
+}
+
 // RegisterIndicator registers an indicator for a specific market and trading pair.
 func (b *Bot) RegisterIndicator(marketName, tradingPair string, indicator types.Indicator) {
 	b.fw.RegisterIndicator(marketName, tradingPair, indicator)
 }
 
+// SetBalance sets the starting balance of an asset in the bot's portfolio.
+func (b *Bot) SetBalance(asset string, amount float64) {
+	b.fw.Portfolio().SetBalance(asset, amount)
+}
+
+// Portfolio returns the balances and positions built up from the fills of orders placed by middleware.
+func (b *Bot) Portfolio() types.Portfolio {
+	return b.fw.Portfolio()
+}
+
+// OrderBook returns a copy of the best levels of a market and trading pair's local order book,
+// or nil if its connector streams no depth or the book is not synced yet.
+func (b *Bot) OrderBook(marketName, tradingPair string, levels int) *types.OrderBook {
+	return b.fw.OrderBook(marketName, tradingPair, levels)
+}
+
+// SetRiskLimits sets the pre-trade risk limits checked before every order is placed.
+func (b *Bot) SetRiskLimits(cfg RiskConfig) {
+	b.fw.Risk().SetLimits(risk.Limits{
+		MaxPositionSize:    cfg.MaxPositionSize,
+		MaxOrderNotional:   cfg.MaxOrderNotional,
+		MaxOrdersPerMinute: cfg.MaxOrdersPerMinute,
+		MaxDailyLoss:       cfg.MaxDailyLoss,
+	})
+}
+
+// Halt engages the kill switch, rejecting every order until Resume is called.
+func (b *Bot) Halt(reason string) {
+	b.logger.Warn().Str("Reason", reason).Msg("Trading halted")
+	b.fw.Risk().Halt(reason)
+}
+
+// Resume releases the kill switch.
+func (b *Bot) Resume() {
+	b.logger.Info().Msg("Trading resumed")
+	b.fw.Risk().Resume()
+}
+
 // Start begins processing data from connectors and applying registered indicators and strategies.
-func (b *Bot) Start() error {
+// The bot runs until ctx is cancelled or Stop is called, and cannot be started again once stopped.
+func (b *Bot) Start(ctx context.Context) error {
 	if len(b.fw.Connectors()) == 0 {
 		return fmt.Errorf("no connectors registered; add at least one connector to start the bot")
 	}
-	b.fw.Start(b.ProcessTick) // Pass ProcessTick as the callback
+	return b.fw.Start(ctx, b.ProcessTick) // Pass ProcessTick as the callback
+}
+
+// Stop stops every connector, waits for in-flight ticks, cancels open orders if SetCancelOrdersOnStop
+// is set and flushes the stores, giving up when ctx is done. It is safe to call more than once.
+func (b *Bot) Stop(ctx context.Context) error {
+	b.logger.Info().Msg("Stopping bot")
+	if err := b.fw.Stop(ctx); err != nil {
+		b.logger.Error().Err(err).Msg("Bot did not stop cleanly")
+		return err
+	}
+	b.logger.Info().Msg("Bot stopped")
 	return nil
 }
 
-// ProcessTick handles incoming ticks with logging and runs indicators on recent data.
+// Close stops the bot like Stop, then writes the ticks still queued by write-behind and closes the stores,
+// disconnecting from MongoDB or Redis. The bot cannot be started again once closed.
+func (b *Bot) Close(ctx context.Context) error {
+	if err := b.fw.Close(ctx); err != nil {
+		b.logger.Error().Err(err).Msg("Bot did not close cleanly")
+		return err
+	}
+	b.logger.Info().Msg("Bot closed")
+	return nil
+}
+
+// SetCancelOrdersOnStop sets whether Stop cancels the open orders on every trading pair the bot received ticks for.
+func (b *Bot) SetCancelOrdersOnStop(cancel bool) {
+	b.fw.SetCancelOrdersOnStop(cancel)
+}
+
+// SetOrderPollInterval sets how often the open orders the bot placed are looked up on connectors that do not report
+// order updates themselves, so later fills reach the portfolio and strategies. Zero or less disables polling.
+func (b *Bot) SetOrderPollInterval(interval time.Duration) {
+	b.fw.SetOrderPollInterval(interval)
+}
+
+// Backtest replays recorded ticks through the bot's indicators and middleware under marketName
+// and returns the simulated trading report. The bot must not have any other connectors registered.
+func (b *Bot) Backtest(marketName string, replay *backtest.ReplayConnector) (*backtest.Report, error) {
+	if count := len(b.fw.Connectors()); count > 0 {
+		return nil, fmt.Errorf("backtest requires a bot without live connectors; %d already registered", count)
+	}
+	b.RegisterConnector(marketName, replay)
+	if err := b.Start(context.Background()); err != nil {
+		return nil, err
+	}
+	<-replay.Done()
+	if err := b.fw.Stop(context.Background()); err != nil {
+		return nil, err
+	}
+	return replay.Report(), nil
+}
+
+// ProcessTick logs each tick once the framework has calculated its indicators and run its middleware.
 func (b *Bot) ProcessTick(ctx *types.TickContext) {
 	if b.debugMode {
 		b.logger.Debug().
 			Str("TradingPair", ctx.TradingPair).
 			Float64("Price", ctx.MarketData.Price).
 			Float64("Volume", ctx.MarketData.Volume).
+			Interface("Indicators", ctx.Indicators).
 			Msg("Received tick")
 	}
-
-	// Compute and update indicators in the context
-	indicators := b.fw.GetIndicators(ctx.MarketName, ctx.TradingPair)
-	for _, indicator := range indicators {
-		period := indicator.Period()                                                    // Get the period from each indicator
-		priceHistory := b.fw.QueryPriceHistory(ctx.MarketName, ctx.TradingPair, period) // Fetch recent or historical data
-		ctx.Indicators[indicator.Name()] = indicator.Calculate(priceHistory)
-	}
-
-	// Execute any middleware or strategy logic here
-	for _, middleware := range b.fw.GetMiddleware(ctx.MarketName, ctx.TradingPair) {
-		if err := middleware(ctx); err != nil {
-			b.logger.Error().Err(err).Msg("Error processing middleware")
-		}
-	}
 }
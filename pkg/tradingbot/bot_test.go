@@ -2,9 +2,15 @@ package tradingbot
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"github.com/bigmeech/tradingbot/internal/backtest"
+	"github.com/bigmeech/tradingbot/internal/framework"
+	"github.com/bigmeech/tradingbot/internal/indicators"
 	"github.com/bigmeech/tradingbot/pkg/types"
 	"github.com/bigmeech/tradingbot/testutils"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -16,6 +22,25 @@ type MockConnector struct {
 	connected    bool
 	streamDataFn func(handler func(ctx *types.TickContext))
 	stopCh       chan struct{} // Channel to stop streaming data
+	stopOnce     sync.Once
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes, for loggers shared by goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
 }
 
 func NewMockConnector() *MockConnector {
@@ -53,28 +78,49 @@ func (m *MockConnector) StreamMarketData(handler func(ctx *types.TickContext)) e
 	return nil
 }
 
-// Stop streaming data
-func (m *MockConnector) Stop() {
-	close(m.stopCh)
+// StopStreaming stops the simulated data stream.
+func (m *MockConnector) StopStreaming() error {
+	m.stopOnce.Do(func() { close(m.stopCh) })
+	return nil
+}
+
+// GetIdentifier returns an empty identifier so the registration name is used as the market name.
+func (m *MockConnector) GetIdentifier() string {
+	return ""
 }
 
 // ExecuteOrder simulates executing an order for testing purposes.
-func (m *MockConnector) ExecuteOrder(orderType types.OrderType, side types.OrderSide, tradingPair string, amount float64, price float64) error {
-	return nil // Simulate order execution
+func (m *MockConnector) ExecuteOrder(orderType types.OrderType, side types.OrderSide, tradingPair string, amount float64, price float64) (*types.Order, error) {
+	return nil, nil // Simulate order execution
+}
+
+// CancelOrder simulates cancelling an order for testing purposes.
+func (m *MockConnector) CancelOrder(tradingPair, orderID string) (*types.Order, error) {
+	return nil, nil
+}
+
+// AmendOrder simulates amending an order for testing purposes.
+func (m *MockConnector) AmendOrder(tradingPair, orderID string, amount, price float64) (*types.Order, error) {
+	return nil, nil
+}
+
+// GetOpenOrders simulates listing open orders for testing purposes.
+func (m *MockConnector) GetOpenOrders(tradingPair string) ([]*types.Order, error) {
+	return nil, nil
 }
 
 func TestBot_RegisterConnectorAndStart(t *testing.T) {
 	// Initialize the persistent store (largeStore) for testing
 	largeStore := testutils.NewMockStore()
 
-	// Set up log buffer and configure logger
-	var logBuffer bytes.Buffer
-	logger := zerolog.New(&logBuffer).With().Timestamp().Logger()
+	// Set up log buffer and configure logger; Stop and the tick goroutine both write to it
+	logBuffer := &syncBuffer{}
+	logger := zerolog.New(logBuffer).With().Timestamp().Logger()
 
-	// Initialize Bot with largeStore, bufferSize, and threshold
+	// Initialize Bot with an in-memory fast store of bufferSize ticks, largeStore, and threshold
 	bufferSize := 10
 	threshold := 5
-	bot := NewBot(largeStore, bufferSize, threshold, logger)
+	bot := NewBot(framework.NewInMemoryFastStore(bufferSize), largeStore, threshold, logger)
 	bot.EnableDebug()
 
 	// Create and register the mock connector
@@ -84,43 +130,120 @@ func TestBot_RegisterConnectorAndStart(t *testing.T) {
 			MarketName:  "MockConnector",
 			TradingPair: "BTC/USDT",
 			MarketData:  &types.MarketData{Price: 50000.0, Volume: 1.5},
-			ExecuteOrder: func(orderType types.OrderType, side types.OrderSide, amount, price float64) error {
-				return nil // Simulate order execution in the test
+			ExecuteOrder: func(orderType types.OrderType, side types.OrderSide, amount, price float64) (*types.Order, error) {
+				return nil, nil // Simulate order execution in the test
 			},
 		})
 	}
 
+	mockConnector.Connect()
 	bot.RegisterConnector("MockConnector", mockConnector)
 	if len(bot.fw.Connectors()) != 1 {
 		t.Fatalf("Expected 1 connector, got %v", len(bot.fw.Connectors()))
 	}
 
-	// Start the bot asynchronously
-	go func() {
-		err := bot.Start()
-		if err != nil {
-			t.Fatalf("Expected bot to start without error, got: %v", err)
-		}
-	}()
+	// Start the bot; connectors stream in the background
+	if err := bot.Start(context.Background()); err != nil {
+		t.Fatalf("Expected bot to start without error, got: %v", err)
+	}
 
 	// Allow time for several ticks to be processed
 	time.Sleep(1 * time.Second)
 
-	// Stop the mock connector's data stream
-	mockConnector.Stop()
+	// Stop the bot, which stops the mock connector's data stream
+	stopCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := bot.Stop(stopCtx); err != nil {
+		t.Fatalf("Expected bot to stop without error, got: %v", err)
+	}
+	select {
+	case <-mockConnector.stopCh:
+	default:
+		t.Error("Expected Stop to stop the connector's stream")
+	}
+	if err := bot.Start(context.Background()); err == nil {
+		t.Error("Expected a stopped bot not to start again")
+	}
 
 	// Check the log output for the tick details
 	logOutput := logBuffer.String()
 	if logOutput == "" {
 		t.Fatal("Expected log output but found none")
 	}
-	if !bytes.Contains(logBuffer.Bytes(), []byte("Received tick")) {
+	if !strings.Contains(logOutput, "Received tick") {
 		t.Errorf("Expected log to contain 'Received tick', got %v", logOutput)
 	}
-	if !bytes.Contains(logBuffer.Bytes(), []byte("BTC/USDT")) {
+	if !strings.Contains(logOutput, "BTC/USDT") {
 		t.Errorf("Expected log to contain 'BTC/USDT', got %v", logOutput)
 	}
-	if !bytes.Contains(logBuffer.Bytes(), []byte("Price\":50000")) {
+	if !strings.Contains(logOutput, "Price\":50000") {
 		t.Errorf("Expected log to contain 'Price\":50000', got %v", logOutput)
 	}
 }
+
+func TestBot_Backtest(t *testing.T) {
+	ticks := []backtest.Tick{
+		{TradingPair: "BTC/USDT", Price: 100, Time: 1},
+		{TradingPair: "BTC/USDT", Price: 102, Time: 2},
+		{TradingPair: "BTC/USDT", Price: 106, Time: 3},
+		{TradingPair: "BTC/USDT", Price: 104, Time: 4},
+		{TradingPair: "BTC/USDT", Price: 98, Time: 5},
+	}
+
+	runBacktest := func() *backtest.Report {
+		bot := NewBot(framework.NewInMemoryFastStore(10), testutils.NewMockStore(), 5, zerolog.Nop())
+		bot.RegisterIndicator("Backtest", "BTC/USDT", indicators.NewSMA(2))
+
+		// Hold a single unit while the price is above its 2-period SMA
+		holding := false
+		bot.RegisterMiddleware("Backtest", "BTC/USDT", func(ctx *types.TickContext) error {
+			sma := ctx.Indicators["SMA_2"]
+			switch {
+			case !holding && sma > 0 && ctx.MarketData.Price > sma:
+				holding = true
+				_, err := ctx.ExecuteOrder(types.OrderTypeMarket, types.OrderSideBuy, 1, ctx.MarketData.Price)
+				return err
+			case holding && ctx.MarketData.Price < sma:
+				holding = false
+				_, err := ctx.ExecuteOrder(types.OrderTypeMarket, types.OrderSideSell, 1, ctx.MarketData.Price)
+				return err
+			}
+			return nil
+		})
+
+		replay := backtest.NewReplayConnector("backtest://test", ticks, backtest.NewSimulatedBroker(1000, 0))
+		report, err := bot.Backtest("Backtest", replay)
+		if err != nil {
+			t.Fatalf("Expected backtest to run without error, got %v", err)
+		}
+		return report
+	}
+
+	report := runBacktest()
+	if len(report.Trades) != 2 {
+		t.Fatalf("Expected 2 trades, got %v", len(report.Trades))
+	}
+	// Bought at 102 when the price rose above SMA_2 = 101, sold at 104 below SMA_2 = 105
+	if report.PnL != 2 {
+		t.Errorf("Expected PnL 2, got %v", report.PnL)
+	}
+	if len(report.EquityCurve) != len(ticks) {
+		t.Errorf("Expected %v equity points, got %v", len(ticks), len(report.EquityCurve))
+	}
+
+	// Replaying the same data must produce the same result
+	again := runBacktest()
+	if again.PnL != report.PnL || len(again.Trades) != len(report.Trades) {
+		t.Errorf("Expected deterministic results, got %+v and %+v", report, again)
+	}
+}
+
+func TestBot_BacktestRejectsLiveConnectors(t *testing.T) {
+	bot := NewBot(framework.NewInMemoryFastStore(10), testutils.NewMockStore(), 5, zerolog.Nop())
+	bot.RegisterConnector("MockConnector", NewMockConnector())
+
+	replay := backtest.NewReplayConnector("backtest://test", nil, backtest.NewSimulatedBroker(1000, 0))
+	if _, err := bot.Backtest("Backtest", replay); err == nil {
+		t.Fatal("Expected an error when live connectors are registered")
+	}
+}
@@ -0,0 +1,265 @@
+package tradingbot
+
+import (
+	"fmt"
+	"github.com/bigmeech/tradingbot/internal/connectors"
+	"github.com/bigmeech/tradingbot/internal/indicators"
+	"github.com/bigmeech/tradingbot/internal/strategies"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"strings"
+	"time"
+)
+
+// Register the connectors, indicators and strategies that ship with the bot.
+func init() {
+	RegisterConnectorType("Binance", func(cfg ConnectorConfig) (types.Connector, error) {
+		pairs, err := cfg.Params.Strings("pairs", nil)
+		if err != nil {
+			return nil, err
+		}
+		streams, err := cfg.Params.Strings("streams", []string{string(connectors.BinanceTradeStream)})
+		if err != nil {
+			return nil, err
+		}
+		connector := connectors.NewBinanceConnector(cfg.WSURL, cfg.RestURL, cfg.APIKey, cfg.APISecret)
+		for _, stream := range streams {
+			if err := connector.Subscribe(connectors.BinanceStream(stream), pairs...); err != nil {
+				return nil, err
+			}
+		}
+		return connector, nil
+	})
+	RegisterConnectorType("Kraken", func(cfg ConnectorConfig) (types.Connector, error) {
+		pairs, err := cfg.Params.Strings("pairs", nil)
+		if err != nil {
+			return nil, err
+		}
+		channels, err := cfg.Params.Strings("channels", []string{string(connectors.KrakenTradeChannel)})
+		if err != nil {
+			return nil, err
+		}
+		connector := connectors.NewKrakenConnector(cfg.WSURL, cfg.RestURL, cfg.APIKey, cfg.APISecret)
+		for _, channel := range channels {
+			if err := connector.Subscribe(connectors.KrakenChannel(channel), pairs...); err != nil {
+				return nil, err
+			}
+		}
+		return connector, nil
+	})
+	RegisterConnectorType("Local", func(cfg ConnectorConfig) (types.Connector, error) {
+		return connectors.NewLocalConnector(cfg.WSURL, cfg.RestURL, cfg.APIKey), nil
+	})
+
+	RegisterIndicatorType("SMA", func(cfg IndicatorConfig) (types.Indicator, error) {
+		if err := requirePeriod(cfg); err != nil {
+			return nil, err
+		}
+		return indicators.NewSMA(cfg.Period), nil
+	})
+	RegisterIndicatorType("EMA", func(cfg IndicatorConfig) (types.Indicator, error) {
+		if err := requirePeriod(cfg); err != nil {
+			return nil, err
+		}
+		return indicators.NewEMA(cfg.Period), nil
+	})
+	RegisterIndicatorType("RSI", func(cfg IndicatorConfig) (types.Indicator, error) {
+		if err := requirePeriod(cfg); err != nil {
+			return nil, err
+		}
+		return indicators.NewRSI(cfg.Period), nil
+	})
+	RegisterIndicatorType("BollingerBands", func(cfg IndicatorConfig) (types.Indicator, error) {
+		if err := requirePeriod(cfg); err != nil {
+			return nil, err
+		}
+		multiplier, err := cfg.Params.Float("multiplier", 2)
+		if err != nil {
+			return nil, err
+		}
+		return indicators.NewBollingerBands(cfg.Period, multiplier), nil
+	})
+	RegisterIndicatorType("MACD", func(cfg IndicatorConfig) (types.Indicator, error) {
+		fast, err := cfg.Params.Int("fast", 12)
+		if err != nil {
+			return nil, err
+		}
+		slow, err := cfg.Params.Int("slow", 26)
+		if err != nil {
+			return nil, err
+		}
+		signal, err := cfg.Params.Int("signal", 9)
+		if err != nil {
+			return nil, err
+		}
+		if fast <= 0 || slow <= fast || signal <= 0 {
+			return nil, fmt.Errorf("MACD requires 0 < fast < slow and signal > 0, got %d/%d/%d", fast, slow, signal)
+		}
+		return indicators.NewMACD(fast, slow, signal), nil
+	})
+
+	RegisterStrategyType("MA_Crossover", newMovingAverageCrossover)
+	RegisterStrategyType("RSI", newRSIStrategy)
+	RegisterStrategyType("Bollinger", newBollingerStrategy)
+}
+
+// newMovingAverageCrossover builds a MA_Crossover strategy from the "average", "short" and "long" params
+// and the sizing params, defaulting to a 50/200 SMA cross buying one unit at market.
+func newMovingAverageCrossover(cfg StrategyConfig) (types.Strategy, error) {
+	defaults := strategies.DefaultMovingAverageCrossoverConfig()
+	average, err := cfg.Params.String("average", string(defaults.Average))
+	if err != nil {
+		return nil, err
+	}
+	short, err := cfg.Params.Int("short", defaults.ShortPeriod)
+	if err != nil {
+		return nil, err
+	}
+	long, err := cfg.Params.Int("long", defaults.LongPeriod)
+	if err != nil {
+		return nil, err
+	}
+	sizing, err := strategySizing(cfg, defaults.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	return strategies.NewMovingAverageCrossover(strategies.MovingAverageCrossoverConfig{
+		Average:       strategies.MovingAverageType(average),
+		ShortPeriod:   short,
+		LongPeriod:    long,
+		Quantity:      sizing.quantity,
+		QuoteFraction: sizing.quoteFraction,
+		OrderType:     sizing.orderType,
+	})
+}
+
+// newRSIStrategy builds an RSI strategy from the "period", "oversold", "overbought", "exitLevel", "stopLoss"
+// and "cooldown" params and the sizing params, defaulting to a 14-period RSI buying one unit at 30 and selling at 70.
+func newRSIStrategy(cfg StrategyConfig) (types.Strategy, error) {
+	defaults := strategies.DefaultRSIConfig()
+	period, err := cfg.Params.Int("period", defaults.Period)
+	if err != nil {
+		return nil, err
+	}
+	oversold, err := cfg.Params.Float("oversold", defaults.Oversold)
+	if err != nil {
+		return nil, err
+	}
+	overbought, err := cfg.Params.Float("overbought", defaults.Overbought)
+	if err != nil {
+		return nil, err
+	}
+	exitLevel, err := cfg.Params.Float("exitLevel", defaults.ExitLevel)
+	if err != nil {
+		return nil, err
+	}
+	exits, err := strategyExits(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sizing, err := strategySizing(cfg, defaults.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	return strategies.NewRSIStrategy(strategies.RSIConfig{
+		Period:        period,
+		Oversold:      oversold,
+		Overbought:    overbought,
+		ExitLevel:     exitLevel,
+		StopLoss:      exits.stopLoss,
+		Cooldown:      exits.cooldown,
+		Quantity:      sizing.quantity,
+		QuoteFraction: sizing.quoteFraction,
+		OrderType:     sizing.orderType,
+	})
+}
+
+// newBollingerStrategy builds a Bollinger strategy from the "mode", "period", "multiplier", "stopLoss" and
+// "cooldown" params and the sizing params, defaulting to mean reversion on 20-period bands two deviations wide.
+func newBollingerStrategy(cfg StrategyConfig) (types.Strategy, error) {
+	defaults := strategies.DefaultBollingerConfig()
+	mode, err := cfg.Params.String("mode", string(defaults.Mode))
+	if err != nil {
+		return nil, err
+	}
+	period, err := cfg.Params.Int("period", defaults.Period)
+	if err != nil {
+		return nil, err
+	}
+	multiplier, err := cfg.Params.Float("multiplier", defaults.Multiplier)
+	if err != nil {
+		return nil, err
+	}
+	exits, err := strategyExits(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sizing, err := strategySizing(cfg, defaults.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	return strategies.NewBollingerStrategy(strategies.BollingerConfig{
+		Mode:          strategies.BollingerMode(mode),
+		Period:        period,
+		Multiplier:    multiplier,
+		StopLoss:      exits.stopLoss,
+		Cooldown:      exits.cooldown,
+		Quantity:      sizing.quantity,
+		QuoteFraction: sizing.quoteFraction,
+		OrderType:     sizing.orderType,
+	})
+}
+
+// sizingParams are the order sizing settings shared by the built-in strategies.
+type sizingParams struct {
+	quantity      float64
+	quoteFraction float64
+	orderType     types.OrderType
+}
+
+// strategySizing reads the "quantity", "quoteFraction" and "orderType" params. The quantity defaults to
+// defaultQuantity unless a quote fraction is set, and the order type to market.
+func strategySizing(cfg StrategyConfig, defaultQuantity float64) (sizingParams, error) {
+	quoteFraction, err := cfg.Params.Float("quoteFraction", 0)
+	if err != nil {
+		return sizingParams{}, err
+	}
+	if quoteFraction > 0 {
+		defaultQuantity = 0
+	}
+	quantity, err := cfg.Params.Float("quantity", defaultQuantity)
+	if err != nil {
+		return sizingParams{}, err
+	}
+	orderType, err := cfg.Params.String("orderType", string(types.OrderTypeMarket))
+	if err != nil {
+		return sizingParams{}, err
+	}
+	return sizingParams{quantity: quantity, quoteFraction: quoteFraction, orderType: types.OrderType(strings.ToLower(orderType))}, nil
+}
+
+// exitParams are the stop loss and cooldown settings shared by the built-in strategies.
+type exitParams struct {
+	stopLoss float64
+	cooldown time.Duration
+}
+
+// strategyExits reads the "stopLoss" and "cooldown" params, both disabled by default.
+func strategyExits(cfg StrategyConfig) (exitParams, error) {
+	stopLoss, err := cfg.Params.Float("stopLoss", 0)
+	if err != nil {
+		return exitParams{}, err
+	}
+	cooldown, err := cfg.Params.Duration("cooldown", 0)
+	if err != nil {
+		return exitParams{}, err
+	}
+	return exitParams{stopLoss: stopLoss, cooldown: cooldown}, nil
+}
+
+// requirePeriod checks that an indicator config sets a positive period.
+func requirePeriod(cfg IndicatorConfig) error {
+	if cfg.Period <= 0 {
+		return fmt.Errorf("%s requires a positive period", cfg.Type)
+	}
+	return nil
+}
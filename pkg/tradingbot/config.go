@@ -1,28 +1,319 @@
 package tradingbot
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
 type BotConfig struct {
-	Connectors []ConnectorConfig // Configurations for each connector
-	Indicators []IndicatorConfig // Configurations for each indicator
-	Strategies []StrategyConfig  // Configurations for each strategy
+	Connectors []ConnectorConfig  `json:"connectors" yaml:"connectors"` // Configurations for each connector
+	Indicators []IndicatorConfig  `json:"indicators" yaml:"indicators"` // Configurations for each indicator
+	Strategies []StrategyConfig   `json:"strategies" yaml:"strategies"` // Configurations for each strategy
+	Risk       RiskConfig         `json:"risk" yaml:"risk"`             // Pre-trade risk limits applied to every order
+	Store      StoreConfig        `json:"store" yaml:"store"`           // Tick storage settings
+	Balances   map[string]float64 `json:"balances" yaml:"balances"`     // Starting portfolio balances per asset, e.g. {"USDT": 10000}
+	Debug      bool               `json:"debug" yaml:"debug"`           // Log every tick
+
+	CancelOrdersOnStop bool   `json:"cancelOrdersOnStop" yaml:"cancelOrdersOnStop"` // Cancel open orders when the bot stops
+	StrategyStateDir   string `json:"strategyStateDir" yaml:"strategyStateDir"`     // Directory stateful strategies persist their state in
 }
 
 type ConnectorConfig struct {
-	Name      string // e.g., "Binance"
-	APIKey    string
-	APISecret string
-	WSURL     string
-	RestURL   string
+	Name      string `json:"name" yaml:"name"` // e.g., "Binance"
+	APIKey    string `json:"apiKey" yaml:"apiKey"`
+	APISecret string `json:"apiSecret" yaml:"apiSecret"`
+	WSURL     string `json:"wsUrl" yaml:"wsUrl"`
+	RestURL   string `json:"restUrl" yaml:"restUrl"`
+	Params    Params `json:"params" yaml:"params"` // Connector specific settings
 }
 
 type IndicatorConfig struct {
-	MarketName  string // e.g., "Binance"
-	TradingPair string // e.g., "BTC/USDT"
-	Type        string // e.g., "SMA", "RSI"
-	Period      int
+	MarketName  string `json:"market" yaml:"market"` // e.g., "Binance"
+	TradingPair string `json:"pair" yaml:"pair"`     // e.g., "BTC/USDT"
+	Type        string `json:"type" yaml:"type"`     // e.g., "SMA", "RSI"
+	Period      int    `json:"period" yaml:"period"`
+	Params      Params `json:"params" yaml:"params"` // Extra settings, e.g. "multiplier" for Bollinger Bands
 }
 
 type StrategyConfig struct {
-	MarketName  string // e.g., "Binance"
-	TradingPair string // e.g., "BTC/USDT"
-	Type        string // e.g., "MA_Crossover", "RSI"
+	MarketName  string `json:"market" yaml:"market"` // e.g., "Binance"
+	TradingPair string `json:"pair" yaml:"pair"`     // e.g., "BTC/USDT"
+	Type        string `json:"type" yaml:"type"`     // e.g., "MA_Crossover", "RSI"
+	Params      Params `json:"params" yaml:"params"` // Strategy specific settings
+}
+
+// RiskConfig holds the pre-trade risk limits. A zero value disables the corresponding check.
+type RiskConfig struct {
+	MaxPositionSize    float64 `json:"maxPositionSize" yaml:"maxPositionSize"`       // Largest absolute position per market and trading pair, in the base asset
+	MaxOrderNotional   float64 `json:"maxOrderNotional" yaml:"maxOrderNotional"`     // Largest order value in the quote asset
+	MaxOrdersPerMinute int     `json:"maxOrdersPerMinute" yaml:"maxOrdersPerMinute"` // Most orders placed in any rolling minute
+	MaxDailyLoss       float64 `json:"maxDailyLoss" yaml:"maxDailyLoss"`             // Largest loss since the start of the UTC day before new exposure is refused
+}
+
+// StoreConfig configures where ticks are kept.
+type StoreConfig struct {
+	BufferSize  int              `json:"bufferSize" yaml:"bufferSize"`   // Recent ticks kept in the fast store per market and trading pair
+	Threshold   int              `json:"threshold" yaml:"threshold"`     // Period above which history is read from the large store
+	CandleLimit int              `json:"candleLimit" yaml:"candleLimit"` // Closed candles kept in memory per market, trading pair and interval, 1000 by default
+	FastStore   FastStoreConfig  `json:"fastStore" yaml:"fastStore"`
+	LargeStore  LargeStoreConfig `json:"largeStore" yaml:"largeStore"`
+
+	WriteBehind WriteBehindConfig `json:"writeBehind" yaml:"writeBehind"`
+}
+
+// WriteBehindConfig moves large store writes off the tick path into a queue written in batches.
+// Settings left at zero use the defaults.
+type WriteBehindConfig struct {
+	Enabled         bool   `json:"enabled" yaml:"enabled"`
+	QueueSize       int    `json:"queueSize" yaml:"queueSize"`             // Ticks queued before backpressure applies, 10000 by default
+	BatchSize       int    `json:"batchSize" yaml:"batchSize"`             // Ticks written at once, 500 by default
+	FlushIntervalMs int    `json:"flushIntervalMs" yaml:"flushIntervalMs"` // Longest a queued tick waits for its batch to fill, 1000 by default
+	LateAfterMs     int    `json:"lateAfterMs" yaml:"lateAfterMs"`         // Ticks written later than this are counted as late, 5000 by default
+	Backpressure    string `json:"backpressure" yaml:"backpressure"`       // When the queue is full: "block" (default), "drop_newest" or "drop_oldest"
+}
+
+// FastStoreConfig selects the store for recent ticks.
+type FastStoreConfig struct {
+	Type     string `json:"type" yaml:"type"` // "memory" (default) or "redis"
+	Addr     string `json:"addr" yaml:"addr"` // Redis address, e.g. "localhost:6379"
+	Password string `json:"password" yaml:"password"`
+	DB       int    `json:"db" yaml:"db"`
+
+	Prefix    string `json:"prefix" yaml:"prefix"`       // Prefix of the Redis keys, "tradingbot" if empty
+	TimeoutMs int    `json:"timeoutMs" yaml:"timeoutMs"` // Limit on each Redis call, 2000 if zero
+	ReadOnly  bool   `json:"readOnly" yaml:"readOnly"`   // Read a cache shared with a process that records the same pairs, without writing to it
+}
+
+// LargeStoreConfig selects the persistent store for tick history.
+type LargeStoreConfig struct {
+	Type       string `json:"type" yaml:"type"` // "memory" (default), "mongodb" or "none" to keep no history
+	URI        string `json:"uri" yaml:"uri"`
+	Database   string `json:"database" yaml:"database"`
+	Collection string `json:"collection" yaml:"collection"`
+
+	TimeSeries    bool `json:"timeSeries" yaml:"timeSeries"`       // Create the MongoDB collection as a time-series collection
+	RetentionDays int  `json:"retentionDays" yaml:"retentionDays"` // Delete ticks older than this many days, zero to keep them forever
+}
+
+// Params holds free-form settings for a connector, indicator or strategy.
+type Params map[string]interface{}
+
+// Float returns a numeric parameter, or def if it is not set.
+func (p Params) Float(key string, def float64) (float64, error) {
+	value, ok := p[key]
+	if !ok {
+		return def, nil
+	}
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	}
+	return 0, fmt.Errorf("parameter %q must be a number, got %v", key, value)
+}
+
+// Int returns an integer parameter, or def if it is not set.
+func (p Params) Int(key string, def int) (int, error) {
+	value, err := p.Float(key, float64(def))
+	if err != nil {
+		return 0, err
+	}
+	if value != float64(int(value)) {
+		return 0, fmt.Errorf("parameter %q must be a whole number, got %v", key, value)
+	}
+	return int(value), nil
+}
+
+// String returns a string parameter, or def if it is not set.
+func (p Params) String(key, def string) (string, error) {
+	value, ok := p[key]
+	if !ok {
+		return def, nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("parameter %q must be a string, got %v", key, value)
+	}
+	return s, nil
+}
+
+// Strings returns a list of strings parameter, or def if it is not set.
+func (p Params) Strings(key string, def []string) ([]string, error) {
+	value, ok := p[key]
+	if !ok {
+		return def, nil
+	}
+	switch v := value.(type) {
+	case []string:
+		return v, nil
+	case []interface{}:
+		values := make([]string, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("parameter %q must be a list of strings, got %v", key, value)
+			}
+			values[i] = s
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("parameter %q must be a list of strings, got %v", key, value)
+}
+
+// Duration returns a duration parameter written like "90s" or "5m", or def if it is not set.
+func (p Params) Duration(key string, def time.Duration) (time.Duration, error) {
+	value, err := p.String(key, "")
+	if err != nil || value == "" {
+		return def, err
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("parameter %q must be a duration such as \"5m\", got %v", key, value)
+	}
+	return d, nil
+}
+
+// LoadConfig reads a bot configuration from a .yaml, .yml or .json file and validates it.
+// Values of the form ${NAME} are replaced with environment variables so secrets can stay out of the file.
+func LoadConfig(path string) (*BotConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	cfg, err := ParseConfig([]byte(os.ExpandEnv(string(data))), format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ParseConfig decodes a bot configuration in the given format ("yaml", "yml" or "json") and validates it.
+func ParseConfig(data []byte, format string) (*BotConfig, error) {
+	cfg := &BotConfig{}
+	switch format {
+	case "yaml", "yml":
+		decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML config: %w", err)
+		}
+	case "json":
+		decoder := json.NewDecoder(strings.NewReader(string(data)))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON config: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config format %q; use yaml or json", format)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks the configuration for missing fields, duplicate connectors, unknown types and
+// indicators or strategies that refer to markets without a connector, reporting every problem found.
+func (c *BotConfig) Validate() error {
+	var errs []error
+
+	if len(c.Connectors) == 0 {
+		errs = append(errs, errors.New("at least one connector is required"))
+	}
+	markets := make(map[string]bool)
+	for i, connector := range c.Connectors {
+		switch {
+		case connector.Name == "":
+			errs = append(errs, fmt.Errorf("connectors[%d]: name is required", i))
+		case markets[connector.Name]:
+			errs = append(errs, fmt.Errorf("connectors[%d]: duplicate connector %q", i, connector.Name))
+		case !connectorRegistry.has(connector.Name):
+			errs = append(errs, fmt.Errorf("connectors[%d]: unknown connector %q; available: %s", i, connector.Name, strings.Join(ConnectorTypes(), ", ")))
+		}
+		markets[connector.Name] = true
+	}
+
+	for i, indicator := range c.Indicators {
+		prefix := fmt.Sprintf("indicators[%d]", i)
+		errs = append(errs, validateTarget(prefix, indicator.MarketName, indicator.TradingPair, markets)...)
+		if !indicatorRegistry.has(indicator.Type) {
+			errs = append(errs, fmt.Errorf("%s: unknown indicator type %q; available: %s", prefix, indicator.Type, strings.Join(IndicatorTypes(), ", ")))
+		}
+		if indicator.Period < 0 {
+			errs = append(errs, fmt.Errorf("%s: period must not be negative", prefix))
+		}
+	}
+
+	for i, strategy := range c.Strategies {
+		prefix := fmt.Sprintf("strategies[%d]", i)
+		errs = append(errs, validateTarget(prefix, strategy.MarketName, strategy.TradingPair, markets)...)
+		if !strategyRegistry.has(strategy.Type) {
+			errs = append(errs, fmt.Errorf("%s: unknown strategy type %q; available: %s", prefix, strategy.Type, strings.Join(StrategyTypes(), ", ")))
+		}
+	}
+
+	if c.Store.BufferSize < 0 || c.Store.Threshold < 0 || c.Store.CandleLimit < 0 {
+		errs = append(errs, errors.New("store: bufferSize, threshold and candleLimit must not be negative"))
+	}
+	switch strings.ToLower(c.Store.FastStore.Type) {
+	case "", "memory":
+	case "redis":
+		if c.Store.FastStore.Addr == "" {
+			errs = append(errs, errors.New("store.fastStore: redis requires addr"))
+		}
+		if c.Store.FastStore.TimeoutMs < 0 {
+			errs = append(errs, errors.New("store.fastStore: timeoutMs must not be negative"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("store.fastStore: unknown type %q; use memory or redis", c.Store.FastStore.Type))
+	}
+	switch strings.ToLower(c.Store.LargeStore.Type) {
+	case "", "memory", "none":
+	case "mongodb":
+		if c.Store.LargeStore.URI == "" || c.Store.LargeStore.Database == "" || c.Store.LargeStore.Collection == "" {
+			errs = append(errs, errors.New("store.largeStore: mongodb requires uri, database and collection"))
+		}
+		if c.Store.LargeStore.RetentionDays < 0 {
+			errs = append(errs, errors.New("store.largeStore: retentionDays must not be negative"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("store.largeStore: unknown type %q; use memory, mongodb or none", c.Store.LargeStore.Type))
+	}
+
+	writeBehind := c.Store.WriteBehind
+	if writeBehind.QueueSize < 0 || writeBehind.BatchSize < 0 || writeBehind.FlushIntervalMs < 0 || writeBehind.LateAfterMs < 0 {
+		errs = append(errs, errors.New("store.writeBehind: settings must not be negative"))
+	}
+	switch strings.ToLower(writeBehind.Backpressure) {
+	case "", "block", "drop_newest", "drop_oldest":
+	default:
+		errs = append(errs, fmt.Errorf("store.writeBehind: unknown backpressure %q; use block, drop_newest or drop_oldest", writeBehind.Backpressure))
+	}
+
+	if c.Risk.MaxPositionSize < 0 || c.Risk.MaxOrderNotional < 0 || c.Risk.MaxOrdersPerMinute < 0 || c.Risk.MaxDailyLoss < 0 {
+		errs = append(errs, errors.New("risk: limits must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// validateTarget checks that an indicator or strategy names a configured market and a trading pair.
+func validateTarget(prefix, marketName, tradingPair string, markets map[string]bool) []error {
+	var errs []error
+	if !markets[marketName] {
+		errs = append(errs, fmt.Errorf("%s: market %q has no connector", prefix, marketName))
+	}
+	if tradingPair == "" {
+		errs = append(errs, fmt.Errorf("%s: pair is required", prefix))
+	}
+	return errs
 }
@@ -0,0 +1,263 @@
+package tradingbot
+
+import (
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"github.com/rs/zerolog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func init() {
+	RegisterConnectorType("Mock", func(cfg ConnectorConfig) (types.Connector, error) {
+		return NewMockConnector(), nil
+	})
+}
+
+const testConfigYAML = `
+connectors:
+  - name: Mock
+    apiKey: ${TRADINGBOT_TEST_KEY}
+indicators:
+  - market: Mock
+    pair: BTC/USDT
+    type: sma
+    period: 50
+  - market: Mock
+    pair: BTC/USDT
+    type: BollingerBands
+    period: 20
+    params:
+      multiplier: 2.5
+  - market: Mock
+    pair: BTC/USDT
+    type: MACD
+    params: {fast: 3, slow: 6, signal: 4}
+strategies:
+  - market: Mock
+    pair: BTC/USDT
+    type: MA_Crossover
+risk:
+  maxOrdersPerMinute: 5
+balances:
+  USDT: 1000
+`
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("TRADINGBOT_TEST_KEY", "secret")
+	dir := t.TempDir()
+
+	yamlPath := filepath.Join(dir, "bot.yaml")
+	os.WriteFile(yamlPath, []byte(testConfigYAML), 0o600)
+	cfg, err := LoadConfig(yamlPath)
+	if err != nil {
+		t.Fatalf("Expected YAML config to load, got %v", err)
+	}
+	if cfg.Connectors[0].APIKey != "secret" {
+		t.Errorf("Expected the API key from the environment, got %q", cfg.Connectors[0].APIKey)
+	}
+	if len(cfg.Indicators) != 3 || cfg.Risk.MaxOrdersPerMinute != 5 || cfg.Balances["USDT"] != 1000 {
+		t.Errorf("Unexpected config %+v", cfg)
+	}
+	if multiplier, _ := cfg.Indicators[1].Params.Float("multiplier", 2); multiplier != 2.5 {
+		t.Errorf("Expected multiplier 2.5, got %v", multiplier)
+	}
+
+	jsonPath := filepath.Join(dir, "bot.json")
+	os.WriteFile(jsonPath, []byte(`{
+		"connectors": [{"name": "Mock"}],
+		"indicators": [{"market": "Mock", "pair": "ETH/USDT", "type": "RSI", "period": 14}]
+	}`), 0o600)
+	cfg, err = LoadConfig(jsonPath)
+	if err != nil {
+		t.Fatalf("Expected JSON config to load, got %v", err)
+	}
+	if cfg.Indicators[0].Type != "RSI" || cfg.Indicators[0].Period != 14 {
+		t.Errorf("Unexpected indicator %+v", cfg.Indicators[0])
+	}
+
+	if _, err := LoadConfig(filepath.Join("..", "..", "config.example.yaml")); err != nil {
+		t.Errorf("Expected the example config to be valid, got %v", err)
+	}
+	if _, err := LoadConfig(filepath.Join(dir, "bot.toml")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+	if _, err := ParseConfig([]byte("connectors: [{name: Mock, unknown: 1}]"), "yaml"); err == nil {
+		t.Error("Expected an error for unknown fields")
+	}
+}
+
+func TestBotConfig_Validate(t *testing.T) {
+	cfg := &BotConfig{
+		Connectors: []ConnectorConfig{{Name: "Mock"}, {Name: "Mock"}, {Name: "Nope"}},
+		Indicators: []IndicatorConfig{{MarketName: "Elsewhere", TradingPair: "BTC/USDT", Type: "SMA", Period: 5}},
+		Strategies: []StrategyConfig{{MarketName: "Mock", Type: "Unknown"}},
+		Store: StoreConfig{
+			FastStore:   FastStoreConfig{Type: "redis"},
+			LargeStore:  LargeStoreConfig{Type: "mongodb"},
+			WriteBehind: WriteBehindConfig{Backpressure: "spill"},
+		},
+		Risk: RiskConfig{MaxDailyLoss: -1},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Expected validation errors")
+	}
+	for _, expected := range []string{
+		`duplicate connector "Mock"`,
+		`unknown connector "Nope"`,
+		`market "Elsewhere" has no connector`,
+		"strategies[0]: pair is required",
+		`unknown strategy type "Unknown"`,
+		"redis requires addr",
+		"mongodb requires uri",
+		`unknown backpressure "spill"`,
+		"risk: limits must not be negative",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to mention %q, got:\n%v", expected, err)
+		}
+	}
+
+	if err := (&BotConfig{}).Validate(); err == nil || !strings.Contains(err.Error(), "at least one connector") {
+		t.Errorf("Expected an error for a config without connectors, got %v", err)
+	}
+}
+
+func TestNewBotFromConfig(t *testing.T) {
+	cfg, err := ParseConfig([]byte(testConfigYAML), "yaml")
+	if err != nil {
+		t.Fatalf("Expected config to parse, got %v", err)
+	}
+
+	bot, err := NewBotFromConfig(cfg, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("Expected bot to build, got %v", err)
+	}
+	if _, ok := bot.fw.Connectors()["Mock"]; !ok {
+		t.Errorf("Expected the Mock connector to be registered, got %v", bot.fw.Connectors())
+	}
+	names := []string{}
+	for _, indicator := range bot.fw.GetIndicators("Mock", "BTC/USDT") {
+		names = append(names, indicator.Name())
+	}
+	// MA_Crossover reuses SMA_50 and registers the SMA_200 it also compares
+	if strings.Join(names, ",") != "SMA_50,BollingerBands_20,MACD_3_6_4,SMA_200" {
+		t.Errorf("Unexpected indicators %v", names)
+	}
+	if len(bot.fw.GetMiddleware("Mock", "BTC/USDT")) != 1 {
+		t.Errorf("Expected the strategy to be registered")
+	}
+	if bot.Portfolio().Balance("USDT") != 1000 {
+		t.Errorf("Expected the starting balance to be set, got %v", bot.Portfolio().Balances())
+	}
+
+	cfg.Strategies[0].Params = Params{"average": "ema", "short": 20, "long": 10}
+	if _, err := NewBotFromConfig(cfg, zerolog.Nop()); err == nil || !strings.Contains(err.Error(), "strategies[0]") {
+		t.Errorf("Expected an error for a crossover with short above long, got %v", err)
+	}
+	cfg.Strategies[0].Params = Params{"average": "ema", "short": 10, "long": 20, "quoteFraction": 0.5}
+	bot, err = NewBotFromConfig(cfg, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("Expected an EMA crossover to build, got %v", err)
+	}
+	if indicators := bot.fw.GetIndicators("Mock", "BTC/USDT"); indicators[len(indicators)-1].Name() != "EMA_20" {
+		t.Errorf("Expected the crossover to register EMA_20, got %v", indicators)
+	}
+
+	cfg.Indicators = append(cfg.Indicators, IndicatorConfig{MarketName: "Mock", TradingPair: "BTC/USDT", Type: "EMA"})
+	if _, err := NewBotFromConfig(cfg, zerolog.Nop()); err == nil || !strings.Contains(err.Error(), "indicators[3]") {
+		t.Errorf("Expected an error for an EMA without a period, got %v", err)
+	}
+}
+
+func TestNewBotFromConfig_Stores(t *testing.T) {
+	cfg, err := ParseConfig([]byte(testConfigYAML), "yaml")
+	if err != nil {
+		t.Fatalf("Expected config to parse, got %v", err)
+	}
+	cfg.Store = StoreConfig{
+		BufferSize:  5,
+		LargeStore:  LargeStoreConfig{Type: "none"},
+		WriteBehind: WriteBehindConfig{Enabled: true, BatchSize: 10, Backpressure: "drop_oldest"},
+	}
+
+	bot, err := NewBotFromConfig(cfg, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("Expected bot to build, got %v", err)
+	}
+	storeManager := bot.fw.StoreManager()
+	for i := 1; i <= 7; i++ {
+		storeManager.RecordTick("Mock", "BTC/USDT", &types.MarketData{Price: float64(i)})
+	}
+	if err := storeManager.Flush(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if metrics := bot.StoreMetrics(); metrics.Queued != 7 || metrics.Written != 7 || metrics.Batches != 1 {
+		t.Errorf("Expected the ticks to be written in one batch, got %+v", metrics)
+	}
+	if prices := bot.fw.QueryPriceHistory("Mock", "BTC/USDT", 10); len(prices) != 5 {
+		t.Errorf("Expected only the buffered prices without a large store, got %v", prices)
+	}
+	storeManager.Close()
+}
+
+func TestNewBacktestBotFromConfig_Stores(t *testing.T) {
+	cfg, err := ParseConfig([]byte(testConfigYAML), "yaml")
+	if err != nil {
+		t.Fatalf("Expected config to parse, got %v", err)
+	}
+	cfg.Store = StoreConfig{
+		BufferSize:  5,
+		FastStore:   FastStoreConfig{Type: "redis", Addr: "127.0.0.1:1"},
+		LargeStore:  LargeStoreConfig{Type: "mongodb", URI: "mongodb://127.0.0.1:1", Database: "tradingbot", Collection: "ticks"},
+		WriteBehind: WriteBehindConfig{Enabled: true},
+	}
+
+	// The live stores are unreachable, so building or recording through them would fail
+	bot, err := NewBacktestBotFromConfig(cfg, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("Expected backtest bot to build without the live stores, got %v", err)
+	}
+	storeManager := bot.fw.StoreManager()
+	for i := 1; i <= 7; i++ {
+		if err := storeManager.RecordTick("Mock", "BTC/USDT", &types.MarketData{Price: float64(i)}); err != nil {
+			t.Fatalf("Expected ticks to be recorded in memory, got %v", err)
+		}
+	}
+	if metrics := bot.StoreMetrics(); metrics.Queued != 0 {
+		t.Errorf("Expected write-behind to be ignored in backtests, got %+v", metrics)
+	}
+	if prices := bot.fw.QueryPriceHistory("Mock", "BTC/USDT", 7); len(prices) != 7 || prices[6] != 7 {
+		t.Errorf("Expected the history from the in-memory stores, got %v", prices)
+	}
+}
+
+func TestNewStrategy(t *testing.T) {
+	tests := []struct {
+		cfg  StrategyConfig
+		name string // Expected strategy name, empty if the config is invalid
+	}{
+		{StrategyConfig{Type: "rsi"}, "RSI(RSI_14)"},
+		{StrategyConfig{Type: "RSI", Params: Params{"period": 7, "oversold": 25, "overbought": 75, "exitLevel": 50, "cooldown": "15m", "stopLoss": 0.05}}, "RSI(RSI_7)"},
+		{StrategyConfig{Type: "RSI", Params: Params{"cooldown": 15}}, ""},
+		{StrategyConfig{Type: "RSI", Params: Params{"oversold": 80}}, ""},
+		{StrategyConfig{Type: "Bollinger"}, "Bollinger_mean_reversion(BollingerBands_20)"},
+		{StrategyConfig{Type: "Bollinger", Params: Params{"mode": "breakout", "period": 30, "multiplier": 2.5, "quoteFraction": 0.1, "orderType": "LIMIT"}}, "Bollinger_breakout(BollingerBands_30)"},
+		{StrategyConfig{Type: "Bollinger", Params: Params{"orderType": "iceberg"}}, ""},
+		{StrategyConfig{Type: "MA_Crossover", Params: Params{"short": 5, "long": 15, "quantity": 0.5}}, "MA_Crossover(SMA_5,SMA_15)"},
+	}
+	for _, test := range tests {
+		strategy, err := NewStrategy(test.cfg)
+		switch {
+		case test.name == "" && err == nil:
+			t.Errorf("Expected %+v to be rejected", test.cfg)
+		case test.name != "" && err != nil:
+			t.Errorf("Expected %+v to build, got %v", test.cfg, err)
+		case test.name != "" && strategy.Name() != test.name:
+			t.Errorf("Expected strategy %s, got %s", test.name, strategy.Name())
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package tradingbot
+
+import (
+	"fmt"
+	"github.com/bigmeech/tradingbot/pkg/types"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ConnectorFactory builds a connector from its configuration.
+type ConnectorFactory func(cfg ConnectorConfig) (types.Connector, error)
+
+// IndicatorFactory builds an indicator from its configuration.
+type IndicatorFactory func(cfg IndicatorConfig) (types.Indicator, error)
+
+// StrategyFactory builds a strategy from its configuration. Existing middleware can be returned
+// wrapped with types.MiddlewareStrategy.
+type StrategyFactory func(cfg StrategyConfig) (types.Strategy, error)
+
+var (
+	connectorRegistry = newRegistry[ConnectorFactory]()
+	indicatorRegistry = newRegistry[IndicatorFactory]()
+	strategyRegistry  = newRegistry[StrategyFactory]()
+)
+
+// registry maps names to factories, ignoring case on lookup.
+type registry[F any] struct {
+	mu        sync.RWMutex
+	names     map[string]string // Registered spelling by lower-cased name
+	factories map[string]F
+}
+
+func newRegistry[F any]() *registry[F] {
+	return &registry[F]{
+		names:     make(map[string]string),
+		factories: make(map[string]F),
+	}
+}
+
+func (r *registry[F]) register(name string, factory F) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := strings.ToLower(name)
+	r.names[key] = name
+	r.factories[key] = factory
+}
+
+func (r *registry[F]) lookup(name string) (F, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	factory, ok := r.factories[strings.ToLower(name)]
+	return factory, ok
+}
+
+func (r *registry[F]) has(name string) bool {
+	_, ok := r.lookup(name)
+	return ok
+}
+
+func (r *registry[F]) list() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.names))
+	for _, name := range r.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RegisterConnectorType makes a connector available to configs under ConnectorConfig.Name, replacing any
+// connector registered under the same name. Names are matched ignoring case.
+func RegisterConnectorType(name string, factory ConnectorFactory) {
+	connectorRegistry.register(name, factory)
+}
+
+// RegisterIndicatorType makes an indicator available to configs under IndicatorConfig.Type.
+func RegisterIndicatorType(name string, factory IndicatorFactory) {
+	indicatorRegistry.register(name, factory)
+}
+
+// RegisterStrategyType makes a strategy available to configs under StrategyConfig.Type.
+func RegisterStrategyType(name string, factory StrategyFactory) {
+	strategyRegistry.register(name, factory)
+}
+
+// ConnectorTypes returns the registered connector names, sorted.
+func ConnectorTypes() []string {
+	return connectorRegistry.list()
+}
+
+// IndicatorTypes returns the registered indicator types, sorted.
+func IndicatorTypes() []string {
+	return indicatorRegistry.list()
+}
+
+// StrategyTypes returns the registered strategy types, sorted.
+func StrategyTypes() []string {
+	return strategyRegistry.list()
+}
+
+// NewConnector builds a connector with the factory registered under cfg.Name.
+func NewConnector(cfg ConnectorConfig) (types.Connector, error) {
+	factory, ok := connectorRegistry.lookup(cfg.Name)
+	if !ok {
+		return nil, fmt.Errorf("unknown connector %q", cfg.Name)
+	}
+	return factory(cfg)
+}
+
+// NewIndicator builds an indicator with the factory registered under cfg.Type.
+func NewIndicator(cfg IndicatorConfig) (types.Indicator, error) {
+	factory, ok := indicatorRegistry.lookup(cfg.Type)
+	if !ok {
+		return nil, fmt.Errorf("unknown indicator type %q", cfg.Type)
+	}
+	return factory(cfg)
+}
+
+// NewStrategy builds a strategy with the factory registered under cfg.Type.
+func NewStrategy(cfg StrategyConfig) (types.Strategy, error) {
+	factory, ok := strategyRegistry.lookup(cfg.Type)
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy type %q", cfg.Type)
+	}
+	return factory(cfg)
+}
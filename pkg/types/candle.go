@@ -0,0 +1,17 @@
+package types
+
+import "time"
+
+// Candle represents an OHLCV bar aggregated from the ticks of a trading pair over a fixed interval.
+type Candle struct {
+	TradingPair string        // Trading pair the bar was built for
+	Interval    time.Duration // Length of the bar, e.g. time.Minute
+	OpenTime    int64         // Start of the interval in Unix milliseconds, inclusive
+	CloseTime   int64         // End of the interval in Unix milliseconds, exclusive
+	Open        float64       // Price of the first tick in the interval
+	High        float64       // Highest tick price in the interval
+	Low         float64       // Lowest tick price in the interval
+	Close       float64       // Price of the last tick in the interval
+	Volume      float64       // Total tick volume in the interval
+	Trades      int           // Number of ticks aggregated into the bar
+}
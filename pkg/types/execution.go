@@ -2,6 +2,34 @@ package types
 
 // OrderExecutor interface defines the method signature for executing an order.
 type OrderExecutor interface {
-	// ExecuteOrder places an order with the specified type, side, trading pair, amount, and price.
-	ExecuteOrder(orderType OrderType, side OrderSide, tradingPair string, amount float64, price float64) error
+	// ExecuteOrder places an order with the specified type, side, trading pair, amount, and price
+	// and returns the order as acknowledged by the exchange.
+	ExecuteOrder(orderType OrderType, side OrderSide, tradingPair string, amount float64, price float64) (*Order, error)
+}
+
+// OrderManager interface defines the methods for managing orders after they have been placed.
+type OrderManager interface {
+	// CancelOrder cancels an open order by its exchange order ID.
+	CancelOrder(tradingPair, orderID string) (*Order, error)
+
+	// AmendOrder replaces an open order with a new amount and price, returning the replacement order.
+	AmendOrder(tradingPair, orderID string, amount, price float64) (*Order, error)
+
+	// GetOpenOrders lists the open orders for a trading pair.
+	GetOpenOrders(tradingPair string) ([]*Order, error)
+}
+
+// OrderUpdateNotifier is implemented by connectors that report changes to orders after they were placed,
+// such as fills of resting orders, as they happen.
+type OrderUpdateNotifier interface {
+	// OnOrderUpdate sets the handler called with a snapshot of an order each time it fills, or is
+	// cancelled or rejected, after it was placed.
+	OnOrderUpdate(handler func(order *Order))
+}
+
+// OrderQuerier is implemented by connectors that can look up the current state of an order, so changes
+// to resting orders can be polled for.
+type OrderQuerier interface {
+	// GetOrder returns the current state of an order by its exchange order ID.
+	GetOrder(tradingPair, orderID string) (*Order, error)
 }
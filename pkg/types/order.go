@@ -0,0 +1,103 @@
+package types
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// OrderStatus represents the lifecycle state of an order.
+type OrderStatus string
+
+const (
+	// OrderStatusNew represents an order accepted by the exchange with nothing filled yet.
+	OrderStatusNew OrderStatus = "new"
+
+	// OrderStatusPartiallyFilled represents an open order with part of its quantity filled.
+	OrderStatusPartiallyFilled OrderStatus = "partially-filled"
+
+	// OrderStatusFilled represents an order whose full quantity has been filled.
+	OrderStatusFilled OrderStatus = "filled"
+
+	// OrderStatusCancelled represents an order cancelled or expired before it was fully filled.
+	OrderStatusCancelled OrderStatus = "cancelled"
+
+	// OrderStatusRejected represents an order the exchange refused to accept.
+	OrderStatusRejected OrderStatus = "rejected"
+)
+
+// Fill represents a single execution against an order.
+type Fill struct {
+	TradeID  string  // Exchange trade identifier, if provided
+	Price    float64 // Execution price
+	Quantity float64 // Executed base asset quantity
+	Fee      float64 // Fee charged for this execution
+	FeeAsset string  // Asset the fee was charged in
+	Time     int64   // Execution time in Unix milliseconds
+}
+
+// Order represents an order placed on an exchange and its execution state.
+type Order struct {
+	ClientOrderID   string      // Identifier assigned by the bot when the order is placed
+	ExchangeOrderID string      // Identifier assigned by the exchange
+	TradingPair     string      // Trading pair the order was placed on
+	Type            OrderType   // Order type, e.g. market or limit
+	Side            OrderSide   // Buy or sell
+	Status          OrderStatus // Current lifecycle state
+	Price           float64     // Requested price, zero for market orders
+	Quantity        float64     // Requested base asset quantity
+	FilledQuantity  float64     // Base asset quantity filled so far
+	AveragePrice    float64     // Volume-weighted average fill price
+	Fee             float64     // Total fees charged so far
+	FeeAsset        string      // Asset the fees were charged in
+	Fills           []Fill      // Individual executions, if reported by the exchange
+	CreatedAt       int64       // Time the order was placed in Unix milliseconds
+	UpdatedAt       int64       // Time of the last status change in Unix milliseconds
+}
+
+// IsOpen reports whether the order can still be filled.
+func (o *Order) IsOpen() bool {
+	return o.Status == OrderStatusNew || o.Status == OrderStatusPartiallyFilled
+}
+
+// RemainingQuantity returns the quantity that has not been filled yet.
+func (o *Order) RemainingQuantity() float64 {
+	return o.Quantity - o.FilledQuantity
+}
+
+// AddFill records an execution, updating the filled quantity, average price, fees and status.
+func (o *Order) AddFill(fill Fill) {
+	filledValue := o.AveragePrice*o.FilledQuantity + fill.Price*fill.Quantity
+	o.FilledQuantity += fill.Quantity
+	if o.FilledQuantity > 0 {
+		o.AveragePrice = filledValue / o.FilledQuantity
+	}
+	o.Fee += fill.Fee
+	if fill.FeeAsset != "" {
+		o.FeeAsset = fill.FeeAsset
+	}
+	o.Fills = append(o.Fills, fill)
+	o.UpdatedAt = fill.Time
+
+	if o.FilledQuantity >= o.Quantity {
+		o.Status = OrderStatusFilled
+	} else {
+		o.Status = OrderStatusPartiallyFilled
+	}
+}
+
+// Clone returns a copy of the order that does not share its fills with the original.
+func (o *Order) Clone() *Order {
+	clone := *o
+	clone.Fills = append([]Fill(nil), o.Fills...)
+	return &clone
+}
+
+// NewClientOrderID generates a random 18 character identifier for tagging an order placed by the bot,
+// short enough for every supported exchange.
+func NewClientOrderID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic("failed to generate client order ID: " + err.Error())
+	}
+	return "tb" + hex.EncodeToString(b)
+}
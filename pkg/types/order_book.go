@@ -0,0 +1,112 @@
+package types
+
+// PriceLevel is the total quantity resting at a price on one side of an order book.
+type PriceLevel struct {
+	Price    float64
+	Quantity float64
+}
+
+// OrderBook is a copy of the top levels of a trading pair's L2 order book. Methods are safe to call on a
+// nil book, which has no levels, so strategies can use ctx.OrderBook(10).Spread() before a book is synced.
+type OrderBook struct {
+	TradingPair string
+	Bids        []PriceLevel // Best (highest) bid first
+	Asks        []PriceLevel // Best (lowest) ask first
+	Sequence    int64        // Exchange sequence number of the last update applied, zero if the exchange sends none
+	Time        int64        // Time of the last update applied
+}
+
+// BestBid returns the highest bid, reporting false if there are no bids.
+func (b *OrderBook) BestBid() (PriceLevel, bool) {
+	if b == nil || len(b.Bids) == 0 {
+		return PriceLevel{}, false
+	}
+	return b.Bids[0], true
+}
+
+// BestAsk returns the lowest ask, reporting false if there are no asks.
+func (b *OrderBook) BestAsk() (PriceLevel, bool) {
+	if b == nil || len(b.Asks) == 0 {
+		return PriceLevel{}, false
+	}
+	return b.Asks[0], true
+}
+
+// Spread returns the best ask minus the best bid, or zero if either side is empty.
+func (b *OrderBook) Spread() float64 {
+	bid, okBid := b.BestBid()
+	ask, okAsk := b.BestAsk()
+	if !okBid || !okAsk {
+		return 0
+	}
+	return ask.Price - bid.Price
+}
+
+// MidPrice returns the mean of the best bid and ask, or zero if either side is empty.
+func (b *OrderBook) MidPrice() float64 {
+	bid, okBid := b.BestBid()
+	ask, okAsk := b.BestAsk()
+	if !okBid || !okAsk {
+		return 0
+	}
+	return (bid.Price + ask.Price) / 2
+}
+
+// BidDepth returns the total quantity of the best levels bids.
+func (b *OrderBook) BidDepth(levels int) float64 {
+	if b == nil {
+		return 0
+	}
+	return sumQuantity(b.Bids, levels)
+}
+
+// AskDepth returns the total quantity of the best levels asks.
+func (b *OrderBook) AskDepth(levels int) float64 {
+	if b == nil {
+		return 0
+	}
+	return sumQuantity(b.Asks, levels)
+}
+
+func sumQuantity(side []PriceLevel, levels int) float64 {
+	if levels > len(side) {
+		levels = len(side)
+	}
+	total := 0.0
+	for _, level := range side[:levels] {
+		total += level.Quantity
+	}
+	return total
+}
+
+// OrderBookUpdate is a snapshot of, or a diff to, a trading pair's order book as streamed by a connector.
+// In a diff, a level with zero quantity removes the price from the book.
+type OrderBookUpdate struct {
+	TradingPair string
+	Snapshot    bool // Replaces the whole book rather than changing some levels
+	Bids        []PriceLevel
+	Asks        []PriceLevel
+
+	// Exchange sequence numbers of the first and last change in the update. A snapshot sets LastSequence
+	// only; a diff follows without a gap when FirstSequence is at most one past the book's sequence.
+	// Both are zero for exchanges that do not number updates.
+	FirstSequence int64
+	LastSequence  int64
+	Time          int64
+}
+
+// DepthStreamer is implemented by connectors that can stream L2 order book depth in addition to ticks.
+type DepthStreamer interface {
+	// StreamDepth passes each order book snapshot and diff to handler, until StopStreaming is called.
+	StreamDepth(handler func(update *OrderBookUpdate)) error
+
+	// ResyncDepth requests a new snapshot of a trading pair's book, which is passed to the StreamDepth handler.
+	ResyncDepth(tradingPair string) error
+}
+
+// OrderBookProvider is implemented by depth streamers that keep the local order books themselves, e.g. to
+// verify them against the exchange's checksums. The framework reads their books instead of building its own.
+type OrderBookProvider interface {
+	// OrderBook returns a copy of the best levels of a trading pair's book, or nil if it has no synced book.
+	OrderBook(tradingPair string, levels int) *OrderBook
+}
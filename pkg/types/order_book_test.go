@@ -0,0 +1,22 @@
+package types
+
+import "testing"
+
+func TestOrderBook(t *testing.T) {
+	book := &OrderBook{
+		Bids: []PriceLevel{{Price: 99, Quantity: 1}, {Price: 98, Quantity: 2}, {Price: 97, Quantity: 4}},
+		Asks: []PriceLevel{{Price: 101, Quantity: 3}},
+	}
+	if book.Spread() != 2 || book.MidPrice() != 100 {
+		t.Errorf("Expected spread 2 and mid price 100, got %v and %v", book.Spread(), book.MidPrice())
+	}
+	if book.BidDepth(2) != 3 || book.BidDepth(10) != 7 || book.AskDepth(5) != 3 {
+		t.Errorf("Unexpected depth %v, %v, %v", book.BidDepth(2), book.BidDepth(10), book.AskDepth(5))
+	}
+
+	// A nil book has no levels
+	var empty *OrderBook
+	if _, ok := empty.BestBid(); ok || empty.Spread() != 0 || empty.MidPrice() != 0 || empty.AskDepth(5) != 0 {
+		t.Error("Expected a nil book to have no levels")
+	}
+}
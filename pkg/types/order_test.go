@@ -0,0 +1,32 @@
+package types
+
+import "testing"
+
+func TestOrder_AddFill(t *testing.T) {
+	order := &Order{Quantity: 3, Status: OrderStatusNew}
+
+	order.AddFill(Fill{Price: 100, Quantity: 1, Fee: 0.1, FeeAsset: "USDT", Time: 1})
+	if order.Status != OrderStatusPartiallyFilled || !order.IsOpen() {
+		t.Errorf("Expected partially filled open order, got %v", order.Status)
+	}
+
+	order.AddFill(Fill{Price: 106, Quantity: 2, Fee: 0.2, FeeAsset: "USDT", Time: 2})
+	if order.Status != OrderStatusFilled || order.IsOpen() {
+		t.Errorf("Expected filled order, got %v", order.Status)
+	}
+	if order.AveragePrice != 104 {
+		t.Errorf("Expected average price 104, got %v", order.AveragePrice)
+	}
+	if order.RemainingQuantity() != 0 || len(order.Fills) != 2 || order.UpdatedAt != 2 {
+		t.Errorf("Unexpected order state %+v", order)
+	}
+	if order.Fee < 0.3-1e-9 || order.Fee > 0.3+1e-9 {
+		t.Errorf("Expected total fee 0.3, got %v", order.Fee)
+	}
+
+	clone := order.Clone()
+	clone.Fills[0].Price = 1
+	if order.Fills[0].Price != 100 {
+		t.Error("Expected clone not to share fills with the original")
+	}
+}
@@ -0,0 +1,46 @@
+package types
+
+// positionEpsilon is the quantity below which a position is treated as flat, absorbing float rounding.
+const positionEpsilon = 1e-12
+
+// Position represents the net holding on a market and trading pair built up from order fills.
+type Position struct {
+	MarketName        string  // Market the position was opened on
+	TradingPair       string  // Trading pair of the position
+	Quantity          float64 // Net base asset quantity, positive when long and negative when short
+	AverageEntryPrice float64 // Volume-weighted entry price of the open quantity
+	RealizedPnL       float64 // Profit or loss locked in by reducing or closing the position, before fees
+	UnrealizedPnL     float64 // Profit or loss of the open quantity at LastPrice
+	Fees              float64 // Total fees paid on the position's fills
+	LastPrice         float64 // Latest price the position was marked at
+}
+
+// IsLong reports whether the position holds a positive quantity.
+func (p Position) IsLong() bool {
+	return p.Quantity > positionEpsilon
+}
+
+// IsShort reports whether the position holds a negative quantity.
+func (p Position) IsShort() bool {
+	return p.Quantity < -positionEpsilon
+}
+
+// IsFlat reports whether the position holds no quantity.
+func (p Position) IsFlat() bool {
+	return !p.IsLong() && !p.IsShort()
+}
+
+// Portfolio provides read access to asset balances and positions tracked from order fills.
+type Portfolio interface {
+	// Balance returns the current balance of an asset.
+	Balance(asset string) float64
+
+	// Balances returns a copy of all asset balances.
+	Balances() map[string]float64
+
+	// Position returns the position on a market and trading pair, which is flat if nothing has been traded.
+	Position(marketName, tradingPair string) Position
+
+	// Positions returns every position that has been traded, including closed ones.
+	Positions() []Position
+}
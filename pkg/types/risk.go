@@ -0,0 +1,34 @@
+package types
+
+import "fmt"
+
+// RiskRule identifies the pre-trade risk check that rejected an order.
+type RiskRule string
+
+const (
+	// RiskRuleKillSwitch rejects every order while trading is halted.
+	RiskRuleKillSwitch RiskRule = "kill-switch"
+
+	// RiskRuleMaxPositionSize rejects orders that would grow a position beyond the maximum size.
+	RiskRuleMaxPositionSize RiskRule = "max-position-size"
+
+	// RiskRuleMaxOrderNotional rejects orders whose value in the quote asset exceeds the maximum.
+	RiskRuleMaxOrderNotional RiskRule = "max-order-notional"
+
+	// RiskRuleMaxOrdersPerMinute rejects orders beyond the maximum placed in the last minute.
+	RiskRuleMaxOrdersPerMinute RiskRule = "max-orders-per-minute"
+
+	// RiskRuleDailyLossLimit rejects orders that add to a position once the day's loss reaches the limit.
+	RiskRuleDailyLossLimit RiskRule = "daily-loss-limit"
+)
+
+// RiskRejectionError is returned by TickContext.ExecuteOrder when a pre-trade risk check refuses an order.
+// The order is never sent to the exchange.
+type RiskRejectionError struct {
+	Rule   RiskRule // Rule that rejected the order
+	Reason string   // Human readable explanation, including the limit that was hit
+}
+
+func (e *RiskRejectionError) Error() string {
+	return fmt.Sprintf("order rejected by risk rule %s: %s", e.Rule, e.Reason)
+}
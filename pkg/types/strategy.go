@@ -0,0 +1,101 @@
+package types
+
+import "time"
+
+// Strategy is a trading strategy run by the framework for one market and trading pair. Strategies are adapted
+// into the middleware pipeline: OnTick runs as tick middleware and OnBar as bar close middleware, in the order
+// strategies and middleware were registered. Embed BaseStrategy to implement only the hooks a strategy needs.
+type Strategy interface {
+	// Name identifies the strategy in logs and persisted state.
+	Name() string
+
+	// Indicators returns the indicators the strategy reads from TickContext.Indicators. They are registered
+	// for the strategy's market and trading pair unless an indicator with the same name already is.
+	Indicators() []Indicator
+
+	// WarmupPeriod is the number of recorded prices required before OnTick and OnBar are called.
+	WarmupPeriod() int
+
+	// BarInterval is the interval of the candles passed to OnBar, or zero to receive no bars.
+	BarInterval() time.Duration
+
+	// OnStart is called when the framework starts, before any tick. An error stops the framework from starting.
+	OnStart(ctx *StrategyContext) error
+
+	// OnTick is called for every tick once the strategy has warmed up.
+	OnTick(ctx *TickContext) error
+
+	// OnBar is called with ctx.Candle set each time a candle of BarInterval closes, once the strategy has warmed up.
+	OnBar(ctx *TickContext) error
+
+	// OnOrderUpdate is called with every order returned by the strategy's own ExecuteOrder, CancelOrder and
+	// AmendOrder calls, including rejections, so fills and status changes can be tracked. Later fills and status
+	// changes of its resting orders are passed to it before its next OnTick or OnBar, or before OnStop.
+	OnOrderUpdate(ctx *StrategyContext, order *Order) error
+
+	// OnStop is called when the framework stops, after the last tick and before the stores are flushed.
+	OnStop(ctx *StrategyContext) error
+}
+
+// StrategyContext gives lifecycle hooks access to the strategy's market outside of a tick.
+type StrategyContext struct {
+	MarketName  string
+	TradingPair string
+	Portfolio   Portfolio
+
+	// PriceHistory returns up to period of the most recent recorded prices, oldest first
+	PriceHistory func(period int) []float64
+
+	// Candles returns up to count of the most recent closed candles of an interval, oldest first
+	Candles func(interval time.Duration, count int) []Candle
+}
+
+// StatefulStrategy is implemented by strategies that persist state across restarts. The framework loads
+// the saved state before OnStart and saves it after OnStop when a StrategyStateStore is configured.
+type StatefulStrategy interface {
+	Strategy
+
+	// SaveState encodes the strategy's state.
+	SaveState() ([]byte, error)
+
+	// LoadState restores state saved by SaveState.
+	LoadState(data []byte) error
+}
+
+// StrategyStateStore persists the state of stateful strategies by key.
+type StrategyStateStore interface {
+	// LoadStrategyState returns the state saved under key, or nil if there is none.
+	LoadStrategyState(key string) ([]byte, error)
+
+	// SaveStrategyState saves state under key, replacing any earlier state.
+	SaveStrategyState(key string, data []byte) error
+}
+
+// BaseStrategy provides no-op implementations of every Strategy method except Name, requiring no indicators
+// and no warm-up. Embed it and override the hooks a strategy uses.
+type BaseStrategy struct{}
+
+func (BaseStrategy) Indicators() []Indicator                                { return nil }
+func (BaseStrategy) WarmupPeriod() int                                      { return 0 }
+func (BaseStrategy) BarInterval() time.Duration                             { return 0 }
+func (BaseStrategy) OnStart(ctx *StrategyContext) error                     { return nil }
+func (BaseStrategy) OnTick(ctx *TickContext) error                          { return nil }
+func (BaseStrategy) OnBar(ctx *TickContext) error                           { return nil }
+func (BaseStrategy) OnOrderUpdate(ctx *StrategyContext, order *Order) error { return nil }
+func (BaseStrategy) OnStop(ctx *StrategyContext) error                      { return nil }
+
+// middlewareStrategy adapts a Middleware into a Strategy that runs it on every tick.
+type middlewareStrategy struct {
+	BaseStrategy
+	name string
+	mw   Middleware
+}
+
+// MiddlewareStrategy returns a Strategy that runs mw on every tick, so existing middleware can be
+// registered wherever a Strategy is expected.
+func MiddlewareStrategy(name string, mw Middleware) Strategy {
+	return &middlewareStrategy{name: name, mw: mw}
+}
+
+func (s *middlewareStrategy) Name() string                  { return s.name }
+func (s *middlewareStrategy) OnTick(ctx *TickContext) error { return s.mw(ctx) }
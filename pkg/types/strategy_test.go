@@ -0,0 +1,26 @@
+package types
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMiddlewareStrategy(t *testing.T) {
+	errStop := errors.New("stop")
+	var seen *TickContext
+	strategy := MiddlewareStrategy("Legacy", func(ctx *TickContext) error {
+		seen = ctx
+		return errStop
+	})
+
+	if strategy.Name() != "Legacy" || strategy.WarmupPeriod() != 0 || strategy.BarInterval() != 0 || strategy.Indicators() != nil {
+		t.Errorf("Expected a named strategy with no indicators, warm-up or bars")
+	}
+	ctx := &TickContext{TradingPair: "BTC/USDT"}
+	if err := strategy.OnTick(ctx); !errors.Is(err, errStop) || seen != ctx {
+		t.Errorf("Expected OnTick to run the middleware and return its error, got %v", err)
+	}
+	if err := strategy.OnBar(ctx); err != nil {
+		t.Errorf("Expected OnBar to do nothing, got %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+package types
+
+import "strings"
+
+// knownQuoteAssets lists quote assets used to split trading pairs written without a separator, e.g. "BTCUSDT".
+var knownQuoteAssets = []string{"USDT", "USDC", "BUSD", "FDUSD", "TUSD", "USD", "EUR", "GBP", "BTC", "ETH", "BNB"}
+
+// SplitTradingPair splits a trading pair such as "BTC/USDT", "BTC-USDT" or "BTCUSDT" into its base and quote assets.
+// If the pair cannot be split, the whole pair is returned as the base and the quote is empty.
+func SplitTradingPair(tradingPair string) (base, quote string) {
+	for _, separator := range []string{"/", "-", "_"} {
+		if parts := strings.SplitN(tradingPair, separator, 2); len(parts) == 2 {
+			return parts[0], parts[1]
+		}
+	}
+	upper := strings.ToUpper(tradingPair)
+	for _, asset := range knownQuoteAssets {
+		if len(upper) > len(asset) && strings.HasSuffix(upper, asset) {
+			return tradingPair[:len(tradingPair)-len(asset)], tradingPair[len(tradingPair)-len(asset):]
+		}
+	}
+	return tradingPair, ""
+}
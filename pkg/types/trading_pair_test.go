@@ -0,0 +1,23 @@
+package types
+
+import "testing"
+
+func TestSplitTradingPair(t *testing.T) {
+	tests := []struct {
+		pair        string
+		base, quote string
+	}{
+		{"BTC/USDT", "BTC", "USDT"},
+		{"ETH-USD", "ETH", "USD"},
+		{"SOL_EUR", "SOL", "EUR"},
+		{"BTCUSDT", "BTC", "USDT"},
+		{"ethbtc", "eth", "btc"},
+		{"UNKNOWN", "UNKNOWN", ""},
+	}
+	for _, test := range tests {
+		base, quote := SplitTradingPair(test.pair)
+		if base != test.base || quote != test.quote {
+			t.Errorf("SplitTradingPair(%q) = %q, %q; expected %q, %q", test.pair, base, quote, test.base, test.quote)
+		}
+	}
+}
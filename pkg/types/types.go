@@ -1,9 +1,14 @@
 package types
 
+import "time"
+
 type Connector interface {
 	StreamMarketData(handler func(ctx *TickContext)) error
 	StopStreaming() error
-	ExecuteOrder(orderType OrderType, side OrderSide, tradingPair string, amount float64, price float64) error
+	ExecuteOrder(orderType OrderType, side OrderSide, tradingPair string, amount float64, price float64) (*Order, error)
+	CancelOrder(tradingPair, orderID string) (*Order, error)
+	AmendOrder(tradingPair, orderID string, amount, price float64) (*Order, error)
+	GetOpenOrders(tradingPair string) ([]*Order, error)
 
 	// Optional method: Returns a unique identifier for the connector, such as a URL or name.
 	GetIdentifier() string
@@ -15,6 +20,46 @@ type Indicator interface {
 	Period() int
 }
 
+// MultiValueIndicator is an Indicator with several named outputs, e.g. the upper, middle and lower
+// Bollinger Bands. Each output is stored in TickContext.Indicators under IndicatorKey(Name(), output),
+// alongside the primary value returned by Calculate under Name().
+type MultiValueIndicator interface {
+	Indicator
+	Outputs() []string
+	CalculateOutputs(data []float64) map[string]float64
+}
+
+// StreamingIndicator is a stateful indicator updated with one value at a time, so reading it
+// costs O(1) per tick instead of recalculating over the whole period.
+type StreamingIndicator interface {
+	// Update adds the next value to the indicator.
+	Update(value float64)
+
+	// Value returns the current value, or 0 until enough values have been added.
+	Value() float64
+}
+
+// MultiValueStreamingIndicator is a StreamingIndicator with several named outputs.
+type MultiValueStreamingIndicator interface {
+	StreamingIndicator
+
+	// OutputValues returns the current value of each output, or nil until enough values have been added.
+	OutputValues() map[string]float64
+}
+
+// StreamableIndicator is an Indicator that can also be calculated incrementally. The framework creates
+// one stream per market and trading pair it is registered for.
+type StreamableIndicator interface {
+	Indicator
+	NewStream() StreamingIndicator
+}
+
+// IndicatorKey returns the TickContext.Indicators key of a named output of a multi-value indicator,
+// e.g. "BollingerBands_20.upper".
+func IndicatorKey(name, output string) string {
+	return name + "." + output
+}
+
 type Middleware func(*TickContext) error
 
 type Store interface {
@@ -95,13 +140,55 @@ type TickContext struct {
 	Store       Store
 	Indicators  map[string]float64
 
-	// ExecuteOrder function to place orders with order_type and side
-	ExecuteOrder func(orderType OrderType, side OrderSide, amount, price float64) error
+	// Candle is the bar that just closed when middleware registered for bar close runs, and nil otherwise
+	Candle *Candle
+
+	// Candles returns up to count of the most recent closed candles of an interval, oldest first
+	Candles func(interval time.Duration, count int) []Candle
+
+	// OrderBook returns a copy of the best levels of the trading pair's local order book, or nil if the
+	// connector streams no depth or the book is not synced, e.g. ctx.OrderBook(10).Spread()
+	OrderBook func(levels int) *OrderBook
+
+	// Portfolio exposes balances and positions, e.g. ctx.Portfolio.Position(ctx.MarketName, ctx.TradingPair).IsLong()
+	Portfolio Portfolio
+
+	// ExecuteOrder function to place orders with order_type and side, returning the resulting order
+	ExecuteOrder func(orderType OrderType, side OrderSide, amount, price float64) (*Order, error)
+
+	// CancelOrder, AmendOrder and GetOpenOrders manage resting orders on the tick's trading pair
+	CancelOrder   func(orderID string) (*Order, error)
+	AmendOrder    func(orderID string, amount, price float64) (*Order, error)
+	GetOpenOrders func() ([]*Order, error)
 }
 
 // MarketData represents market information for a given trading pair at a specific time.
+// Fields an exchange message does not carry are left zero.
 type MarketData struct {
 	Price  float64 // The price of the asset
 	Volume float64 // The volume of the asset traded
-	Time   int64   // Timestamp of the data
+	Time   int64   // Exchange event time in Unix milliseconds
+
+	Bid         float64   // Best bid price when the tick was produced
+	Ask         float64   // Best ask price when the tick was produced
+	Side        OrderSide // Aggressor (taker) side of the trade
+	TradeID     string    // Exchange trade ID, unique per trading pair on an exchange
+	ReceiveTime int64     // Local time the message was received in Unix milliseconds
+}
+
+// Spread returns Ask minus Bid, or zero if either is unknown.
+func (m *MarketData) Spread() float64 {
+	if m.Bid <= 0 || m.Ask <= 0 {
+		return 0
+	}
+	return m.Ask - m.Bid
+}
+
+// Latency returns the time between the exchange event and its receipt in milliseconds, or zero if either is unknown.
+// It includes any clock skew between the exchange and the local machine.
+func (m *MarketData) Latency() int64 {
+	if m.Time == 0 || m.ReceiveTime == 0 {
+		return 0
+	}
+	return m.ReceiveTime - m.Time
 }
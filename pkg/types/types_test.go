@@ -0,0 +1,13 @@
+package types
+
+import "testing"
+
+func TestMarketData_SpreadAndLatency(t *testing.T) {
+	data := &MarketData{Price: 100, Bid: 99.5, Ask: 100.5, Time: 1700000000000, ReceiveTime: 1700000000042}
+	if data.Spread() != 1 || data.Latency() != 42 {
+		t.Errorf("Expected spread 1 and latency 42, got %v and %v", data.Spread(), data.Latency())
+	}
+	if (&MarketData{Price: 100, Bid: 99.5}).Spread() != 0 || (&MarketData{ReceiveTime: 1}).Latency() != 0 {
+		t.Error("Expected zero spread and latency when a side or time is unknown")
+	}
+}
@@ -18,20 +18,21 @@ func NewMockStore() *MockStore {
 	}
 }
 
-// RecordTick simulates recording a tick for a trading pair.
-func (m *MockStore) RecordTick(tradingPair string, marketData *types.MarketData) error {
+// RecordTick simulates recording a tick for a market and trading pair.
+func (m *MockStore) RecordTick(market, tradingPair string, marketData *types.MarketData) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.recordedData[tradingPair] = append(m.recordedData[tradingPair], *marketData)
+	key := market + ":" + tradingPair
+	m.recordedData[key] = append(m.recordedData[key], *marketData)
 	return nil
 }
 
-// QueryPriceHistory simulates querying the price history for a trading pair.
-func (m *MockStore) QueryPriceHistory(tradingPair string, period int) []float64 {
+// QueryPriceHistory simulates querying the price history for a market and trading pair.
+func (m *MockStore) QueryPriceHistory(market, tradingPair string, period int) []float64 {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	history := make([]float64, 0, period)
-	data := m.recordedData[tradingPair]
+	data := m.recordedData[market+":"+tradingPair]
 	count := len(data)
 	start := count - period
 	if start < 0 {